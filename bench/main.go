@@ -1,13 +1,10 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
+	"goanna/haskell"
 	"goanna/inventory"
-	"goanna/marco"
-	"io"
-	"log"
-	"net/http"
+	"goanna/translator"
 	"strings"
 	"time"
 )
@@ -20,40 +17,10 @@ type datum struct {
 	duration            int
 }
 
-func parseHaskellFile(text string) (inventory.Input, error) {
-	resp, err := http.Post(
-		"http://localhost:8090/translate",
-		"text/plain",
-		strings.NewReader(text))
-	if err != nil {
-		fmt.Println("Error making HTTP request")
-		return inventory.Input{}, err
-
-	}
-	defer func() {
-		err := resp.Body.Close()
-		if err != nil {
-			log.Printf("Error closing response body: %v", err)
-		}
-	}()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Println("Error read respond body")
-		return inventory.Input{}, err
-	}
-	var input inventory.Input
-	err = json.Unmarshal(body, &input)
-	if err != nil {
-		fmt.Printf("Error unmarshalling JSON response: %v", err)
-		return inventory.Input{}, err
-	}
-	return input, nil
-}
-
 func typecheck(source string) datum {
 	start := time.Now()
 
-	input, err := parseHaskellFile(source)
+	input, err := translator.Translate(source)
 	if err != nil {
 		fmt.Println("Error parsing Haskell file")
 		panic("Error parsing Haskell file")
@@ -69,36 +36,12 @@ func typecheck(source string) datum {
 		fmt.Println(inv.ImportErrors)
 		panic("Error importing names")
 	}
-	level := input.MaxLevel
-	errors := make([]marco.Error, 0)
-
-	for {
-		if level == 0 {
-			panic("No more level to generalize ")
-		}
-		inv.Generalize(level)
-		if !inv.AxiomCheck() {
-			level = level - 1
-			continue
-		}
-		if !inv.TypeCheck() {
-			ruleIds := inv.EffectiveRules
-			inv.ConsultAxioms()
-			mc := marco.NewMarco(ruleIds, inv.Satisfiable)
-			mc.Run()
-
-			errors = mc.Analysis()
-			if len(errors) == 1 && len(errors[0].CriticalNodes) == 0 {
-				fmt.Printf("No solutions: %v\n", errors)
-				level = level - 1
-				continue
-			}
-			break
-		} else {
-			fmt.Println("No type error")
-			break
-		}
+	localisation := haskell.Localise(inv, input.MaxLevel)
+	if !localisation.Localised {
+		fmt.Println(localisation.Trace)
+		panic("Could not localise the type error")
 	}
+	errors := localisation.Errors
 	if len(errors) != 0 { // Type error found
 		duration := time.Since(start)
 		fmt.Println(duration)
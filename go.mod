@@ -6,8 +6,10 @@ require (
 	github.com/alecthomas/participle/v2 v2.1.1
 	github.com/crillab/gophersat v1.4.0
 	github.com/deckarep/golang-set/v2 v2.6.0
+	github.com/fatih/color v1.18.0
 	github.com/ichiban/prolog v1.2.1
 	github.com/irifrance/gini v1.0.1
+	github.com/mattn/go-isatty v0.0.20
 	github.com/stretchr/testify v1.11.1
 	github.com/tree-sitter/go-tree-sitter v0.24.0
 	github.com/tree-sitter/tree-sitter-haskell v0.23.1
@@ -16,10 +18,8 @@ require (
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/fatih/color v1.18.0 // indirect
 	github.com/kr/text v0.2.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-pointer v0.0.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/sys v0.27.0 // indirect
@@ -0,0 +1,181 @@
+package haskell
+
+import (
+	"errors"
+	"fmt"
+	"goanna/haskell/parser"
+	"goanna/inventory"
+	"slices"
+	"strings"
+)
+
+// NumericDefaults says what annotations do with type variables that only
+// carry numeric classes, as in `x = 1`.
+type NumericDefaults int
+
+const (
+	// GeneraliseDefaults keeps the constrained variable: `x :: Num a => a`.
+	GeneraliseDefaults NumericDefaults = iota
+	// MonomorphiseDefaults picks the type Haskell would default to:
+	// `x :: Integer`.
+	MonomorphiseDefaults
+)
+
+// diffContext is the number of unchanged lines around each hunk of a diff.
+const diffContext = 3
+
+// defaultable are the classes of the Prelude that do not stop a variable from
+// being defaulted, as in section 4.3.4 of the Haskell report.
+var defaultable = []string{"Num", "Integral", "Real", "Fractional", "Floating", "RealFrac", "RealFloat", "Eq", "Ord", "Show", "Enum"}
+
+var fractionalClasses = []string{"Fractional", "Floating", "RealFrac", "RealFloat"}
+
+// Annotation is a type signature to insert above a declaration.
+type Annotation struct {
+	Name string
+	Type string
+	// The line the signature goes on; the declaration moves down by one
+	Line   int
+	Indent string
+}
+
+// Text is the line the annotation inserts, without its line terminator.
+func (a Annotation) Text() string {
+	return a.Indent + a.Name + " :: " + a.Type
+}
+
+// Annotate returns a signature for every top-level binding of a well-typed
+// program that does not have one, in source order. types are the inferred
+// types of the declarations, as InferStructuredTypes returns them. It fails if
+// the native parser cannot read the program.
+func Annotate(source string, types map[string]Type, defaults NumericDefaults) ([]Annotation, error) {
+	module := parseQuietly(source)
+	if module == nil {
+		return nil, errors.New("the program uses syntax that annotate does not support yet")
+	}
+	program := inventory.NewSource(source)
+	signed := make([]string, 0)
+	for _, decl := range module.Decls {
+		if sig, ok := decl.(*parser.TypeSig); ok {
+			signed = append(signed, sig.Names...)
+		}
+	}
+	inferred := make(map[string]Type)
+	for _, decl := range sortedKeys(types) {
+		inferred[declNamePrefix.ReplaceAllString(decl, "")] = types[decl]
+	}
+
+	annotations := make([]Annotation, 0)
+	for _, decl := range module.Decls {
+		bind, ok := decl.(*parser.PatBind)
+		if !ok {
+			continue
+		}
+		var name parser.PVar
+		switch pat := bind.Pat.(type) {
+		case *parser.PVar:
+			name = *pat
+		case *parser.PApp:
+			name = pat.Constructor
+		default:
+			continue
+		}
+		t, ok := inferred[name.Name]
+		if !ok || slices.Contains(signed, name.Name) {
+			continue
+		}
+		// Later clauses of the same function share the signature
+		signed = append(signed, name.Name)
+		if defaults == MonomorphiseDefaults {
+			t = defaultNumeric(t)
+		}
+		line := bind.Loc().FromLine()
+		indent := program.Line(line)[:program.ByteColumn(line, bind.Loc().FromCol())]
+		annotations = append(annotations, Annotation{
+			Name:   name.Pretty(),
+			Type:   t.String(),
+			Line:   line,
+			Indent: strings.Map(blankOut, indent),
+		})
+	}
+	return annotations, nil
+}
+
+// ApplyAnnotations inserts the annotations into source.
+func ApplyAnnotations(source string, annotations []Annotation) string {
+	program := inventory.NewSource(source)
+	edits := make([]TextEdit, len(annotations))
+	for i, a := range annotations {
+		at := inventory.Range{FromLine: a.Line, ToLine: a.Line}
+		edits[i] = TextEdit{Range: at, NewText: a.Text() + "\n"}
+	}
+	return applyEdits(program, edits)
+}
+
+// AnnotationDiff renders the annotations as a unified diff of file.
+func AnnotationDiff(file, source string, annotations []Annotation) string {
+	if len(annotations) == 0 {
+		return ""
+	}
+	program := inventory.NewSource(source)
+	lines := len(strings.Split(strings.TrimSuffix(source, "\n"), "\n"))
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", file, file)
+	inserted := 0
+	for start := 0; start < len(annotations); {
+		// Annotations whose context overlaps go in the same hunk
+		end := start + 1
+		for end < len(annotations) && annotations[end].Line-annotations[end-1].Line <= 2*diffContext {
+			end++
+		}
+		from := max(0, annotations[start].Line-diffContext)
+		to := min(lines, annotations[end-1].Line+diffContext)
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", from+1, to-from, from+1+inserted, to-from+end-start)
+		next := start
+		for n := from; n < to; n++ {
+			for next < end && annotations[next].Line == n {
+				fmt.Fprintf(&sb, "+%s\n", annotations[next].Text())
+				next++
+			}
+			fmt.Fprintf(&sb, " %s\n", program.Line(n))
+		}
+		inserted += end - start
+		start = end
+	}
+	return sb.String()
+}
+
+// defaultNumeric replaces every type variable whose classes are all
+// defaultable and include a numeric one by Integer, or by Double if one of
+// them is fractional.
+func defaultNumeric(t Type) Type {
+	return t.Map(func(t Type) Type {
+		if t.Kind != KindVar || len(t.Classes) == 0 {
+			return t
+		}
+		numeric := false
+		fractional := false
+		for _, class := range t.Classes {
+			if !slices.Contains(defaultable, class) {
+				return t
+			}
+			numeric = numeric || (class != "Eq" && class != "Ord" && class != "Show" && class != "Enum")
+			fractional = fractional || slices.Contains(fractionalClasses, class)
+		}
+		switch {
+		case !numeric:
+			return t
+		case fractional:
+			return ConType("Double")
+		default:
+			return ConType("Integer")
+		}
+	})
+}
+
+func blankOut(r rune) rune {
+	if r == '\t' {
+		return r
+	}
+	return ' '
+}
@@ -0,0 +1,63 @@
+package haskell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotate(t *testing.T) {
+	source := "-- | Doubles\ndouble x = x * 2\n\nhalf :: Double -> Double\nhalf x = x / 2\n\nfact 0 = 1\nfact n = n * fact (n - 1)\n"
+	num := VarType("a", "Num")
+	eqNum := VarType("a", "Eq", "Num")
+	types := map[string]Type{
+		"m0_double": FunType(num, num),
+		"m0_half":   FunType(ConType("Double"), ConType("Double")),
+		"m0_fact":   FunType(eqNum, eqNum),
+	}
+	annotations, err := Annotate(source, types, GeneraliseDefaults)
+	assert.NoError(t, err)
+	assert.Equal(t, []Annotation{
+		{Name: "double", Type: "Num a => a -> a", Line: 1},
+		{Name: "fact", Type: "(Eq a, Num a) => a -> a", Line: 6},
+	}, annotations)
+	assert.Equal(t,
+		"-- | Doubles\ndouble :: Num a => a -> a\ndouble x = x * 2\n\nhalf :: Double -> Double\nhalf x = x / 2\n\nfact :: (Eq a, Num a) => a -> a\nfact 0 = 1\nfact n = n * fact (n - 1)\n",
+		ApplyAnnotations(source, annotations))
+	assert.Equal(t, `--- a/Main.hs
++++ b/Main.hs
+@@ -1,8 +1,10 @@
+ -- | Doubles
++double :: Num a => a -> a
+ double x = x * 2
+ 
+ half :: Double -> Double
+ half x = x / 2
+ 
++fact :: (Eq a, Num a) => a -> a
+ fact 0 = 1
+ fact n = n * fact (n - 1)
+`, AnnotationDiff("Main.hs", source, annotations))
+
+	monomorphic, err := Annotate(source, types, MonomorphiseDefaults)
+	assert.NoError(t, err)
+	assert.Equal(t, "Integer -> Integer", monomorphic[0].Type)
+	assert.Equal(t, "Integer -> Integer", monomorphic[1].Type)
+}
+
+func TestAnnotateUnsupportedSyntax(t *testing.T) {
+	types := map[string]Type{"m0_x": ConType("Int")}
+	annotations, err := Annotate("type family F a\n\nx = 1\n", types, GeneraliseDefaults)
+	assert.Error(t, err)
+	assert.Nil(t, annotations)
+}
+
+func TestDefaultNumeric(t *testing.T) {
+	fractional := VarType("a", "Fractional")
+	assert.Equal(t, "Double -> Double", defaultNumeric(FunType(fractional, fractional)).String())
+	assert.Equal(t, "Show b => Integer -> b",
+		defaultNumeric(FunType(VarType("a", "Integral"), VarType("b", "Show"))).String())
+	assert.Equal(t, "Monad m => m Integer",
+		defaultNumeric(AppType(VarType("m", "Monad"), VarType("a", "Num"))).String())
+	assert.Equal(t, "Int", defaultNumeric(ConType("Int")).String())
+}
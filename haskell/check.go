@@ -0,0 +1,87 @@
+package haskell
+
+import (
+	"goanna/inventory"
+	"slices"
+)
+
+type Status string
+
+const (
+	StatusWellTyped   Status = "well-typed"
+	StatusTypeError   Status = "type-error"
+	StatusUnlocalised Status = "unlocalised"
+)
+
+// CheckResult is everything the checker found out about one file. It is the
+// input of every output format.
+type CheckResult struct {
+	File          string
+	Source        string
+	Status        Status
+	Localisation  Localisation
+	Report        Report
+	InferredTypes map[string]string
+	// InferredTypes before they are printed
+	StructuredTypes map[string]Type
+	Holes           []HoleType
+}
+
+// NewCheckResult reports on a file that has been localised.
+func NewCheckResult(inv *inventory.Inventory, localisation Localisation, file, source string) CheckResult {
+	result := CheckResult{
+		File:            file,
+		Source:          source,
+		Localisation:    localisation,
+		Report:          Report{TypeErrors: []TypeError{}, NodeRange: inv.NodeRange},
+		InferredTypes:   make(map[string]string),
+		StructuredTypes: make(map[string]Type),
+		Holes:           []HoleType{},
+	}
+	switch {
+	case !localisation.Localised:
+		result.Status = StatusUnlocalised
+	case len(localisation.Errors) != 0:
+		result.Status = StatusTypeError
+		result.Report = MakeReport(localisation.Errors, *inv, source)
+	default:
+		result.Status = StatusWellTyped
+		result.StructuredTypes = InferStructuredTypes(*inv)
+		for decl, t := range result.StructuredTypes {
+			result.InferredTypes[decl] = t.String()
+		}
+		result.Holes = InferHoles(*inv, inv.EffectiveRules)
+	}
+	return result
+}
+
+// OrderedCriticalNodes returns the critical nodes of the error in source order.
+func (e TypeError) OrderedCriticalNodes() []int {
+	nodes := make([]int, 0, len(e.CriticalNodes))
+	for node := range e.CriticalNodes {
+		nodes = append(nodes, node)
+	}
+	slices.SortFunc(nodes, func(a, b int) int {
+		return compareRanges(e.CriticalNodes[a].Range, e.CriticalNodes[b].Range, a, b)
+	})
+	return nodes
+}
+
+func compareRanges(a, b inventory.Range, nodeA, nodeB int) int {
+	if a.FromLine != b.FromLine {
+		return a.FromLine - b.FromLine
+	}
+	if a.FromCol != b.FromCol {
+		return a.FromCol - b.FromCol
+	}
+	return nodeA - nodeB
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,128 @@
+package haskell
+
+import (
+	"encoding/json"
+	"flag"
+	"goanna/inventory"
+	"goanna/translator"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the corpus")
+
+const corpusDir = "../test/corpus"
+
+// goldenReport is the part of a report the corpus pins down. Node IDs are left
+// out, as they change whenever the translator numbers nodes differently.
+type goldenReport struct {
+	Status        Status            `json:"status"`
+	TypeErrors    []goldenError     `json:"type_errors"`
+	InferredTypes map[string]string `json:"inferred_types"`
+}
+
+type goldenError struct {
+	CriticalNodes []goldenNode `json:"critical_nodes"`
+	Fixes         []goldenFix  `json:"fixes"`
+}
+
+type goldenNode struct {
+	Name  string          `json:"name"`
+	Range inventory.Range `json:"range"`
+}
+
+type goldenFix struct {
+	MCS []goldenNode `json:"mcs"`
+	// The types of the critical nodes under the fix, in the same order
+	LocalTypes []string `json:"local_types"`
+}
+
+func goldenOf(result CheckResult) goldenReport {
+	report := result.JSON()
+	golden := goldenReport{
+		Status:        report.Status,
+		TypeErrors:    make([]goldenError, len(report.TypeErrors)),
+		InferredTypes: report.InferredTypes,
+	}
+	nodes := func(jsonNodes []JSONNode) []goldenNode {
+		result := make([]goldenNode, len(jsonNodes))
+		for i, node := range jsonNodes {
+			result[i] = goldenNode{Name: node.Name, Range: node.Range}
+		}
+		return result
+	}
+	for i, typeError := range report.TypeErrors {
+		fixes := make([]goldenFix, len(typeError.Fixes))
+		for j, fix := range typeError.Fixes {
+			localTypes := make([]string, len(fix.LocalTypes))
+			for k, localType := range fix.LocalTypes {
+				localTypes[k] = localType.Type
+			}
+			fixes[j] = goldenFix{MCS: nodes(fix.MCS), LocalTypes: localTypes}
+		}
+		golden.TypeErrors[i] = goldenError{CriticalNodes: nodes(typeError.CriticalNodes), Fixes: fixes}
+	}
+	return golden
+}
+
+// requireTranslator skips a test that needs the translator when it is not
+// running, except under CI, where the test fails instead so that the corpus is
+// never silently left unchecked.
+func requireTranslator(t *testing.T) {
+	t.Helper()
+	if translator.Ready() {
+		return
+	}
+	if os.Getenv("CI") != "" {
+		t.Fatalf("translator is not running at %s", translator.Address)
+	}
+	t.Skipf("translator is not running at %s", translator.Address)
+}
+
+func TestCorpus(t *testing.T) {
+	requireTranslator(t)
+	programs, err := filepath.Glob(filepath.Join(corpusDir, "*", "*.hs"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, programs)
+
+	for _, program := range programs {
+		name := strings.TrimSuffix(program[len(corpusDir)+1:], ".hs")
+		t.Run(name, func(t *testing.T) {
+			code, err := os.ReadFile(program)
+			assert.NoError(t, err)
+			input, err := translator.Translate(string(code))
+			if !assert.NoError(t, err) {
+				return
+			}
+			inv := inventory.NewInventory(input)
+			if !assert.Empty(t, inv.ParsingErrors, "parse errors") || !assert.Empty(t, inv.ImportErrors, "import errors") {
+				return
+			}
+			localisation := Localise(inv, input.MaxLevel)
+			result := NewCheckResult(inv, localisation, program, string(code))
+			if strings.HasPrefix(name, "well-typed") {
+				assert.Equal(t, StatusWellTyped, result.Status)
+			} else {
+				assert.Equal(t, StatusTypeError, result.Status)
+			}
+
+			actual, err := json.MarshalIndent(goldenOf(result), "", "  ")
+			assert.NoError(t, err)
+			actual = append(actual, '\n')
+			goldenFile := strings.TrimSuffix(program, ".hs") + ".golden.json"
+			if *update {
+				assert.NoError(t, os.WriteFile(goldenFile, actual, 0o644))
+				return
+			}
+			expected, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatalf("no golden file for %s; run the test with -update to create it", program)
+			}
+			assert.Equal(t, string(expected), string(actual))
+		})
+	}
+}
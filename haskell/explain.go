@@ -0,0 +1,102 @@
+package haskell
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// maxPremises is the most nodes an explanation cites as the reason a changed
+// expression has to have some type.
+const maxPremises = 2
+
+var typeVariable = regexp.MustCompile(`^[a-z][A-Za-z0-9_']*$`)
+
+// explainFix describes fix i of an error in a sentence or two. Every node the
+// fix changes is explained by the MUSs it takes part in: the other nodes of
+// those MUSs, which the fix keeps, force a type on it (its type under this
+// fix) that differs from the type it has on its own (its type under a fix that
+// keeps it).
+func explainFix(i int, fixes []Fix, muses [][]int, details map[int]NodeDetail) string {
+	fix := fixes[i]
+	changed := slices.Clone(fix.MCS)
+	slices.SortFunc(changed, func(a, b int) int {
+		return compareRanges(details[a].Range, details[b].Range, a, b)
+	})
+
+	sentences := make([]string, 0, len(changed))
+	for _, node := range changed {
+		premises := make([]string, 0)
+		for _, premise := range premisesOf(node, fix.MCS, muses, details) {
+			premises = append(premises, fmt.Sprintf("`%s` is `%s`", details[premise].DisplayName, fix.LocalType[premise]))
+		}
+
+		var sb strings.Builder
+		if len(premises) != 0 {
+			fmt.Fprintf(&sb, "If %s, then ", strings.Join(premises, " and "))
+		}
+		name := details[node].DisplayName
+		expected := fix.LocalType[node]
+		actual, kept := ownType(node, fixes)
+		constrained := !typeVariable.MatchString(expected)
+		switch {
+		case constrained && kept && actual != expected:
+			fmt.Fprintf(&sb, "`%s` is wrong because it has to be `%s` here but on its own it is `%s`.", name, expected, actual)
+		case constrained:
+			fmt.Fprintf(&sb, "`%s` is wrong because it has to be `%s` here.", name, expected)
+		case kept:
+			fmt.Fprintf(&sb, "`%s` is wrong because on its own it is `%s`, which conflicts with the rest.", name, actual)
+		default:
+			fmt.Fprintf(&sb, "`%s` has to change.", name)
+		}
+		sentences = append(sentences, sb.String())
+	}
+	return strings.Join(sentences, " ")
+}
+
+// premisesOf returns the nodes that share a MUS with node and are kept by the
+// fix, nearest first.
+func premisesOf(node int, mcs []int, muses [][]int, details map[int]NodeDetail) []int {
+	premises := make([]int, 0)
+	for _, mus := range muses {
+		if !slices.Contains(mus, node) {
+			continue
+		}
+		for _, other := range mus {
+			if other != node && !slices.Contains(mcs, other) && !slices.Contains(premises, other) {
+				premises = append(premises, other)
+			}
+		}
+	}
+	at := details[node].Range
+	distance := func(n int) int {
+		r := details[n].Range
+		lines := r.FromLine - at.FromLine
+		if lines < 0 {
+			lines = -lines
+		}
+		cols := r.FromCol - at.FromCol
+		if cols < 0 {
+			cols = -cols
+		}
+		return lines*1000 + cols
+	}
+	slices.SortFunc(premises, func(a, b int) int {
+		if d := distance(a) - distance(b); d != 0 {
+			return d
+		}
+		return compareRanges(details[a].Range, details[b].Range, a, b)
+	})
+	return premises[:min(maxPremises, len(premises))]
+}
+
+// ownType is the type of node under the first fix that does not change it.
+func ownType(node int, fixes []Fix) (string, bool) {
+	for _, fix := range fixes {
+		if !slices.Contains(fix.MCS, node) {
+			return fix.LocalType[node], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,29 @@
+package haskell
+
+import (
+	"goanna/inventory"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainFix(t *testing.T) {
+	// f :: [Int] -> [Char]; f xs = length xs ++ "a"
+	details := map[int]NodeDetail{
+		1: {DisplayName: "xs", Range: inventory.Range{FromLine: 1, ToLine: 1, FromCol: 14, ToCol: 16}},
+		2: {DisplayName: "length xs", Range: inventory.Range{FromLine: 1, ToLine: 1, FromCol: 7, ToCol: 16}},
+		3: {DisplayName: "++", Range: inventory.Range{FromLine: 1, ToLine: 1, FromCol: 17, ToCol: 19}},
+	}
+	fixes := []Fix{
+		{MCS: []int{2}, LocalType: map[int]string{1: "[Int]", 2: "[Char]", 3: "[Char] -> [Char] -> [Char]"}},
+		{MCS: []int{3}, LocalType: map[int]string{1: "[Int]", 2: "Int", 3: "Int -> [Char] -> [Char]"}},
+	}
+	muses := [][]int{{1, 2, 3}}
+
+	assert.Equal(t,
+		"If `xs` is `[Int]` and `++` is `[Char] -> [Char] -> [Char]`, then `length xs` is wrong because it has to be `[Char]` here but on its own it is `Int`.",
+		explainFix(0, fixes, muses, details))
+	assert.Equal(t,
+		"If `xs` is `[Int]` and `length xs` is `Int`, then `++` is wrong because it has to be `Int -> [Char] -> [Char]` here but on its own it is `[Char] -> [Char] -> [Char]`.",
+		explainFix(1, fixes, muses, details))
+}
@@ -0,0 +1,193 @@
+package haskell
+
+import (
+	"goanna/inventory"
+	"slices"
+)
+
+// ReportSchemaVersion is the version of the JSON report. It is bumped on any
+// change that could break a consumer: a field removed, renamed or retyped.
+// Adding a field does not bump it.
+//
+// Schema, version 1:
+//
+//	{
+//	  "schema_version": 1,
+//	  "file": "path as given on the command line",
+//	  "status": "well-typed" | "type-error" | "unlocalised",
+//	  "level": generalisation level the errors were localised at (0 if unlocalised),
+//	  "type_errors": [{
+//	    "critical_nodes": [node], in source order
+//	    "fixes": [{
+//	      "mcs": [node], the expressions to change, in source order
+//	      "local_types": [{"node": node, "type": "Int", "structured": type}], critical node types after the fix
+//	      "global_types": {"declaration": "type"}, declaration types after the fix
+//	      "structured_global_types": {"declaration": type},
+//	      "holes": [hole],
+//	      "explanation": "why the expressions in mcs are to blame, in English",
+//	      "suggestions": [{"title": "Convert `n` with `show`", "edits": [edit]}],
+//	        rewrites that were checked to remove the error; empty unless asked for
+//	    }]
+//	  }],
+//	  "inferred_types": {"declaration": "type"}, only for well-typed programs
+//	  "structured_inferred_types": {"declaration": type}, only for well-typed programs
+//	  "holes": [hole], only for well-typed programs
+//	}
+//
+//	node = {"id": 12, "name": "short source text", "range": range}
+//	hole = {"name": "_x", "range": range, "type": "a", "locals": [{"name": "x", "type": "Int"}]}
+//	type = {"kind": "con" | "var" | "app" | "fun" | "tuple" | "list", "name": "Maybe",
+//	        "classes": ["Eq"], "args": [type]}, see Type; name is set for con and var,
+//	        classes only for var, args for the rest
+//	edit = {"range": range, "new_text": "(show n)"}
+//	range = {"from_line", "from_col", "to_line", "to_col"}, zero based, to_col exclusive,
+//	        columns count bytes of UTF-8 like the translator's ranges
+//
+// Declarations are named as the translator names them. Every list is present,
+// possibly empty.
+const ReportSchemaVersion = 1
+
+type JSONReport struct {
+	SchemaVersion int               `json:"schema_version"`
+	File          string            `json:"file"`
+	Status        Status            `json:"status"`
+	Level         int               `json:"level"`
+	TypeErrors    []JSONTypeError   `json:"type_errors"`
+	InferredTypes map[string]string `json:"inferred_types"`
+	// The structured form of InferredTypes
+	StructuredInferredTypes map[string]Type `json:"structured_inferred_types"`
+	Holes                   []JSONHole      `json:"holes"`
+}
+
+type JSONNode struct {
+	Id    int             `json:"id"`
+	Name  string          `json:"name"`
+	Range inventory.Range `json:"range"`
+}
+
+type JSONTypeError struct {
+	CriticalNodes []JSONNode `json:"critical_nodes"`
+	Fixes         []JSONFix  `json:"fixes"`
+}
+
+type JSONLocalType struct {
+	Node       int    `json:"node"`
+	Type       string `json:"type"`
+	Structured Type   `json:"structured"`
+}
+
+type JSONFix struct {
+	MCS         []JSONNode        `json:"mcs"`
+	LocalTypes  []JSONLocalType   `json:"local_types"`
+	GlobalTypes map[string]string `json:"global_types"`
+	// The structured form of GlobalTypes
+	StructuredGlobalTypes map[string]Type  `json:"structured_global_types"`
+	Holes                 []JSONHole       `json:"holes"`
+	Explanation           string           `json:"explanation"`
+	Suggestions           []JSONSuggestion `json:"suggestions"`
+}
+
+type JSONSuggestion struct {
+	Title string         `json:"title"`
+	Edits []JSONTextEdit `json:"edits"`
+}
+
+type JSONTextEdit struct {
+	Range   inventory.Range `json:"range"`
+	NewText string          `json:"new_text"`
+}
+
+type JSONBinding struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type JSONHole struct {
+	Name   string          `json:"name"`
+	Range  inventory.Range `json:"range"`
+	Type   string          `json:"type"`
+	Locals []JSONBinding   `json:"locals"`
+}
+
+// JSON converts a result to the versioned JSON report.
+func (r CheckResult) JSON() JSONReport {
+	typeErrors := make([]JSONTypeError, len(r.Report.TypeErrors))
+	for i, typeError := range r.Report.TypeErrors {
+		ordered := typeError.OrderedCriticalNodes()
+		criticalNodes := make([]JSONNode, len(ordered))
+		for j, node := range ordered {
+			criticalNodes[j] = JSONNode{
+				Id:    node,
+				Name:  typeError.CriticalNodes[node].DisplayName,
+				Range: typeError.CriticalNodes[node].Range,
+			}
+		}
+		fixes := make([]JSONFix, len(typeError.Fixes))
+		for j, fix := range typeError.Fixes {
+			localTypes := make([]JSONLocalType, len(ordered))
+			for k, node := range ordered {
+				localTypes[k] = JSONLocalType{Node: node, Type: fix.LocalType[node], Structured: fix.StructuredLocalType[node]}
+			}
+			fixes[j] = JSONFix{
+				MCS:                   r.jsonNodes(fix.MCS),
+				LocalTypes:            localTypes,
+				GlobalTypes:           fix.GlobalType,
+				StructuredGlobalTypes: fix.StructuredGlobalType,
+				Holes:                 jsonHoles(fix.Holes),
+				Explanation:           fix.Explanation,
+				Suggestions:           jsonSuggestions(fix.Suggestions),
+			}
+		}
+		typeErrors[i] = JSONTypeError{CriticalNodes: criticalNodes, Fixes: fixes}
+	}
+	return JSONReport{
+		SchemaVersion:           ReportSchemaVersion,
+		File:                    r.File,
+		Status:                  r.Status,
+		Level:                   r.Localisation.Level,
+		TypeErrors:              typeErrors,
+		InferredTypes:           r.InferredTypes,
+		StructuredInferredTypes: r.StructuredTypes,
+		Holes:                   jsonHoles(r.Holes),
+	}
+}
+
+func jsonSuggestions(suggestions []Suggestion) []JSONSuggestion {
+	result := make([]JSONSuggestion, len(suggestions))
+	for i, suggestion := range suggestions {
+		edits := make([]JSONTextEdit, len(suggestion.Edits))
+		for j, edit := range suggestion.Edits {
+			edits[j] = JSONTextEdit{Range: edit.Range, NewText: edit.NewText}
+		}
+		result[i] = JSONSuggestion{Title: suggestion.Title, Edits: edits}
+	}
+	return result
+}
+
+func (r CheckResult) jsonNodes(ids []int) []JSONNode {
+	ids = slices.Clone(ids)
+	slices.SortFunc(ids, func(a, b int) int {
+		return compareRanges(r.Report.NodeRange[a], r.Report.NodeRange[b], a, b)
+	})
+	nodes := make([]JSONNode, len(ids))
+	for i, id := range ids {
+		nodes[i] = JSONNode{
+			Id:    id,
+			Name:  getDisplayName(r.Report.NodeRange[id], r.Source),
+			Range: r.Report.NodeRange[id],
+		}
+	}
+	return nodes
+}
+
+func jsonHoles(holes []HoleType) []JSONHole {
+	result := make([]JSONHole, len(holes))
+	for i, hole := range holes {
+		locals := make([]JSONBinding, len(hole.Locals))
+		for j, local := range hole.Locals {
+			locals[j] = JSONBinding{Name: local.Name, Type: local.Type}
+		}
+		result[i] = JSONHole{Name: hole.Name, Range: hole.Range, Type: hole.Type, Locals: locals}
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package haskell
+
+import (
+	"encoding/json"
+	"goanna/inventory"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// exampleSource has a type error with two fixes: change `"é"` or change `1`.
+// `é` is two bytes of UTF-8 and one UTF-16 code unit.
+const exampleSource = "f = \"é\" ++ 1\n"
+
+var (
+	exampleString = inventory.Range{FromLine: 0, FromCol: 4, ToLine: 0, ToCol: 8}
+	exampleNumber = inventory.Range{FromLine: 0, FromCol: 12, ToLine: 0, ToCol: 13}
+)
+
+// exampleResult is the result of checking exampleSource. Only changing `1`
+// has a suggestion.
+func exampleResult() CheckResult {
+	return CheckResult{
+		File:         "example.hs",
+		Source:       exampleSource,
+		Status:       StatusTypeError,
+		Localisation: Localisation{Level: 2, Localised: true},
+		Report: Report{
+			TypeErrors: []TypeError{{
+				CriticalNodes: map[int]NodeDetail{
+					5: {DisplayName: "1", Range: exampleNumber},
+					3: {DisplayName: "\"é\"", Range: exampleString},
+				},
+				Fixes: []Fix{
+					{
+						MCS:         []int{5},
+						LocalType:   map[int]string{3: "[Char]", 5: "[Char]"},
+						GlobalType:  map[string]string{"f": "[Char]"},
+						Holes:       []HoleType{},
+						Explanation: "`1` is a number, but `++` joins lists.",
+						Suggestions: []Suggestion{{
+							Title: "Convert `1` with `show`",
+							Edits: []TextEdit{{Range: exampleNumber, NewText: "(show 1)"}},
+						}},
+					},
+					{
+						MCS:         []int{3},
+						LocalType:   map[int]string{3: "[Int]", 5: "Int"},
+						GlobalType:  map[string]string{"f": "[Int]"},
+						Holes:       []HoleType{},
+						Explanation: "`\"é\"` is a string, but `++` joins it with a number.",
+					},
+				},
+			}},
+			NodeRange: map[int]inventory.Range{3: exampleString, 5: exampleNumber},
+		},
+		InferredTypes:   map[string]string{},
+		StructuredTypes: map[string]Type{},
+		Holes:           []HoleType{},
+	}
+}
+
+func TestJSON(t *testing.T) {
+	report := exampleResult().JSON()
+	assert.Equal(t, ReportSchemaVersion, report.SchemaVersion)
+	assert.Equal(t, "example.hs", report.File)
+	assert.Equal(t, StatusTypeError, report.Status)
+	assert.Equal(t, 2, report.Level)
+	assert.Len(t, report.TypeErrors, 1)
+
+	typeError := report.TypeErrors[0]
+	// Critical nodes are in source order
+	assert.Equal(t, []JSONNode{
+		{Id: 3, Name: "\"é\"", Range: exampleString},
+		{Id: 5, Name: "1", Range: exampleNumber},
+	}, typeError.CriticalNodes)
+	assert.Len(t, typeError.Fixes, 2)
+
+	fix := typeError.Fixes[0]
+	assert.Equal(t, []JSONNode{{Id: 5, Name: "1", Range: exampleNumber}}, fix.MCS)
+	assert.Equal(t, []JSONLocalType{{Node: 3, Type: "[Char]"}, {Node: 5, Type: "[Char]"}}, fix.LocalTypes)
+	assert.Equal(t, map[string]string{"f": "[Char]"}, fix.GlobalTypes)
+	assert.Equal(t, []JSONSuggestion{{
+		Title: "Convert `1` with `show`",
+		Edits: []JSONTextEdit{{Range: exampleNumber, NewText: "(show 1)"}},
+	}}, fix.Suggestions)
+	assert.Empty(t, typeError.Fixes[1].Suggestions)
+}
+
+func TestJSONListsArePresent(t *testing.T) {
+	data, err := json.Marshal(exampleResult().JSON())
+	assert.NoError(t, err)
+
+	var report map[string]any
+	assert.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, []any{}, report["holes"])
+	fix := report["type_errors"].([]any)[0].(map[string]any)["fixes"].([]any)[1].(map[string]any)
+	assert.Equal(t, []any{}, fix["suggestions"])
+	assert.Equal(t, []any{}, fix["holes"])
+}
@@ -0,0 +1,190 @@
+package haskell
+
+import (
+	"fmt"
+	"goanna/inventory"
+	"strings"
+)
+
+// The subset of SARIF 2.1.0 that code scanning dashboards read.
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+const typeErrorRuleId = "goanna/type-error"
+
+type SarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool       SarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []SarifResult `json:"results"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []SarifRule `json:"rules"`
+}
+
+type SarifRule struct {
+	Id               string       `json:"id"`
+	ShortDescription SarifMessage `json:"shortDescription"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifResult struct {
+	RuleId           string          `json:"ruleId"`
+	Level            string          `json:"level"`
+	Message          SarifMessage    `json:"message"`
+	Locations        []SarifLocation `json:"locations"`
+	RelatedLocations []SarifLocation `json:"relatedLocations,omitempty"`
+	Fixes            []SarifFix      `json:"fixes,omitempty"`
+}
+
+// SarifLocation identifies a region of the checked file. Related locations
+// have an Id, starting at 1, which is unique within their result.
+type SarifLocation struct {
+	Id               int                   `json:"id,omitempty"`
+	PhysicalLocation SarifPhysicalLocation `json:"physicalLocation"`
+	Message          *SarifMessage         `json:"message,omitempty"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Region           SarifRegion           `json:"region"`
+}
+
+type SarifArtifactLocation struct {
+	Uri string `json:"uri"`
+}
+
+// SarifRegion is one based; EndColumn is one past the last character. Columns
+// count UTF-16 code units.
+type SarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+type SarifFix struct {
+	Description     SarifMessage          `json:"description"`
+	ArtifactChanges []SarifArtifactChange `json:"artifactChanges"`
+}
+
+type SarifArtifactChange struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []SarifReplacement    `json:"replacements"`
+}
+
+// SarifReplacement replaces a region with the text of a suggestion.
+type SarifReplacement struct {
+	DeletedRegion   SarifRegion          `json:"deletedRegion"`
+	InsertedContent SarifArtifactContent `json:"insertedContent"`
+}
+
+type SarifArtifactContent struct {
+	Text string `json:"text"`
+}
+
+func sarifRegion(source inventory.Source, r inventory.Range) SarifRegion {
+	r = source.FromBytesRange(r, inventory.UTF16Columns)
+	return SarifRegion{
+		StartLine:   r.FromLine + 1,
+		StartColumn: r.FromCol + 1,
+		EndLine:     r.ToLine + 1,
+		EndColumn:   r.ToCol + 1,
+	}
+}
+
+// SARIF converts a result to a SARIF log with one result per type error. The
+// locations of a result are its critical nodes, and the expressions each fix
+// would change are its related locations. Only suggestions, whose edits have
+// been checked to remove the error, become SARIF fixes.
+func (r CheckResult) SARIF() SarifLog {
+	artifact := SarifArtifactLocation{Uri: r.File}
+	source := inventory.NewSource(r.Source)
+	results := make([]SarifResult, 0)
+	for _, typeError := range r.JSON().TypeErrors {
+		locations := make([]SarifLocation, len(typeError.CriticalNodes))
+		names := make([]string, len(typeError.CriticalNodes))
+		for i, node := range typeError.CriticalNodes {
+			locations[i] = SarifLocation{
+				PhysicalLocation: SarifPhysicalLocation{ArtifactLocation: artifact, Region: sarifRegion(source, node.Range)},
+				Message:          &SarifMessage{Text: node.Name},
+			}
+			names[i] = "`" + node.Name + "`"
+		}
+		related := make([]SarifLocation, 0)
+		for i, fix := range typeError.Fixes {
+			for _, node := range fix.MCS {
+				related = append(related, SarifLocation{
+					Id:               len(related) + 1,
+					PhysicalLocation: SarifPhysicalLocation{ArtifactLocation: artifact, Region: sarifRegion(source, node.Range)},
+					Message:          &SarifMessage{Text: fmt.Sprintf("Fix %d: change `%s`. %s", i+1, node.Name, fix.Explanation)},
+				})
+			}
+		}
+		fixes := make([]SarifFix, 0)
+		for _, fix := range typeError.Fixes {
+			for _, suggestion := range fix.Suggestions {
+				replacements := make([]SarifReplacement, len(suggestion.Edits))
+				for j, edit := range suggestion.Edits {
+					replacements[j] = SarifReplacement{
+						DeletedRegion:   sarifRegion(source, edit.Range),
+						InsertedContent: SarifArtifactContent{Text: edit.NewText},
+					}
+				}
+				fixes = append(fixes, SarifFix{
+					Description: SarifMessage{Text: suggestion.Title},
+					ArtifactChanges: []SarifArtifactChange{{
+						ArtifactLocation: artifact,
+						Replacements:     replacements,
+					}},
+				})
+			}
+		}
+		results = append(results, SarifResult{
+			RuleId: typeErrorRuleId,
+			Level:  "error",
+			Message: SarifMessage{Text: fmt.Sprintf("Type error involving %s; %d possible fixes",
+				strings.Join(names, ", "), len(typeError.Fixes))},
+			Locations:        locations,
+			RelatedLocations: related,
+			Fixes:            fixes,
+		})
+	}
+	if r.Status == StatusUnlocalised {
+		results = append(results, SarifResult{
+			RuleId:    typeErrorRuleId,
+			Level:     "error",
+			Message:   SarifMessage{Text: "Type error that could not be localised at any generalisation level"},
+			Locations: []SarifLocation{},
+		})
+	}
+	return SarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs: []SarifRun{{
+			Tool: SarifTool{Driver: SarifDriver{
+				Name: "goanna",
+				Rules: []SarifRule{{
+					Id:               typeErrorRuleId,
+					ShortDescription: SarifMessage{Text: "Type error"},
+				}},
+			}},
+			ColumnKind: "utf16CodeUnits",
+			Results:    results,
+		}},
+	}
+}
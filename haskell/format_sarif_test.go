@@ -0,0 +1,73 @@
+package haskell
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSARIF(t *testing.T) {
+	log := exampleResult().SARIF()
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Len(t, log.Runs, 1)
+	assert.Equal(t, "utf16CodeUnits", log.Runs[0].ColumnKind)
+	assert.Len(t, log.Runs[0].Results, 1)
+
+	artifact := SarifArtifactLocation{Uri: "example.hs"}
+	stringRegion := SarifRegion{StartLine: 1, StartColumn: 5, EndLine: 1, EndColumn: 8}
+	numberRegion := SarifRegion{StartLine: 1, StartColumn: 12, EndLine: 1, EndColumn: 13}
+	result := log.Runs[0].Results[0]
+	assert.Equal(t, "Type error involving `\"é\"`, `1`; 2 possible fixes", result.Message.Text)
+	assert.Equal(t, []SarifLocation{
+		{PhysicalLocation: SarifPhysicalLocation{ArtifactLocation: artifact, Region: stringRegion}, Message: &SarifMessage{Text: "\"é\""}},
+		{PhysicalLocation: SarifPhysicalLocation{ArtifactLocation: artifact, Region: numberRegion}, Message: &SarifMessage{Text: "1"}},
+	}, result.Locations)
+
+	// The expressions each fix would change are related locations
+	assert.Equal(t, []SarifLocation{
+		{
+			Id:               1,
+			PhysicalLocation: SarifPhysicalLocation{ArtifactLocation: artifact, Region: numberRegion},
+			Message:          &SarifMessage{Text: "Fix 1: change `1`. `1` is a number, but `++` joins lists."},
+		},
+		{
+			Id:               2,
+			PhysicalLocation: SarifPhysicalLocation{ArtifactLocation: artifact, Region: stringRegion},
+			Message:          &SarifMessage{Text: "Fix 2: change `\"é\"`. `\"é\"` is a string, but `++` joins it with a number."},
+		},
+	}, result.RelatedLocations)
+
+	// Only the suggestion is a fix, and it says what to insert
+	assert.Equal(t, []SarifFix{{
+		Description: SarifMessage{Text: "Convert `1` with `show`"},
+		ArtifactChanges: []SarifArtifactChange{{
+			ArtifactLocation: artifact,
+			Replacements: []SarifReplacement{{
+				DeletedRegion:   numberRegion,
+				InsertedContent: SarifArtifactContent{Text: "(show 1)"},
+			}},
+		}},
+	}}, result.Fixes)
+}
+
+func TestSARIFWithoutSuggestions(t *testing.T) {
+	result := exampleResult()
+	result.Report.TypeErrors[0].Fixes[0].Suggestions = nil
+	data, err := json.Marshal(result.SARIF())
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), `"fixes"`)
+	assert.Contains(t, string(data), `"relatedLocations"`)
+}
+
+func TestSARIFUnlocalised(t *testing.T) {
+	result := exampleResult()
+	result.Status = StatusUnlocalised
+	result.Localisation = Localisation{}
+	result.Report.TypeErrors = []TypeError{}
+
+	results := result.SARIF().Runs[0].Results
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Type error that could not be localised at any generalisation level", results[0].Message.Text)
+	assert.Empty(t, results[0].Locations)
+}
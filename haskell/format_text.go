@@ -0,0 +1,233 @@
+package haskell
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"goanna/inventory"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// palette colours the parts of a terminal report. Without colour every part
+// is printed as is, so the output is plain ASCII apart from the source itself.
+type palette struct {
+	error    *color.Color
+	critical *color.Color
+	mcs      *color.Color
+	types    *color.Color
+	gutter   *color.Color
+	fix      *color.Color
+}
+
+func newPalette(colour bool) palette {
+	p := palette{
+		error:    color.New(color.FgRed, color.Bold),
+		critical: color.New(color.FgYellow),
+		mcs:      color.New(color.FgRed, color.Bold),
+		types:    color.New(color.FgCyan),
+		gutter:   color.New(color.FgBlue, color.Bold),
+		fix:      color.New(color.FgGreen, color.Bold),
+	}
+	for _, c := range []*color.Color{p.error, p.critical, p.mcs, p.types, p.gutter, p.fix} {
+		if colour {
+			c.EnableColor()
+		} else {
+			c.DisableColor()
+		}
+	}
+	return p
+}
+
+func (p palette) forTag(tag Tag) *color.Color {
+	if tag == tagError {
+		return p.mcs
+	}
+	return p.critical
+}
+
+// Text renders a result for reading in a terminal, in the style of rustc and
+// Elm: every type error shows the source with the critical nodes underlined,
+// followed by a numbered list of fixes, each with the expressions it changes
+// underlined with ^ and the types the critical nodes take under it. Positions
+// are in the one-based file:line:col form editors understand, counting
+// characters.
+func (r CheckResult) Text(colour bool) string {
+	var sb strings.Builder
+	p := newPalette(colour)
+	switch r.Status {
+	case StatusUnlocalised:
+		fmt.Fprintf(&sb, "%s %s\n", p.error.Sprint("error:"),
+			"type error that could not be localised at any generalisation level")
+		fmt.Fprintf(&sb, "  %s %s\n", p.gutter.Sprint("-->"), r.File)
+	case StatusWellTyped:
+		for _, decl := range sortedKeys(r.InferredTypes) {
+			fmt.Fprintf(&sb, "%s :: %s\n", decl, p.types.Sprint(r.InferredTypes[decl]))
+		}
+		source := inventory.NewSource(r.Source)
+		for _, hole := range r.Holes {
+			col := source.FromBytes(hole.Range.FromLine, hole.Range.FromCol, inventory.CodepointColumns)
+			fmt.Fprintf(&sb, "%s:%d:%d: hole %s :: %s\n",
+				r.File, hole.Range.FromLine+1, col+1, hole.Name, p.types.Sprint(hole.Type))
+			for _, local := range hole.Locals {
+				fmt.Fprintf(&sb, "    %s :: %s\n", local.Name, p.types.Sprint(local.Type))
+			}
+		}
+	}
+	for i, typeError := range r.Report.TypeErrors {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		r.renderTypeError(&sb, typeError, p)
+	}
+	return sb.String()
+}
+
+func (r CheckResult) renderTypeError(sb *strings.Builder, typeError TypeError, p palette) {
+	nodes := typeError.OrderedCriticalNodes()
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i] = "`" + typeError.CriticalNodes[node].DisplayName + "`"
+	}
+	fmt.Fprintf(sb, "%s type error involving %s\n", p.error.Sprint("error:"), strings.Join(names, ", "))
+	var first inventory.Range
+	if len(nodes) != 0 {
+		first = typeError.CriticalNodes[nodes[0]].Range
+	}
+	width := gutterWidth(typeError)
+	source := inventory.NewSource(r.Source)
+	fmt.Fprintf(sb, "%s %s %s:%d:%d\n", strings.Repeat(" ", width), p.gutter.Sprint("-->"),
+		r.File, first.FromLine+1, source.FromBytes(first.FromLine, first.FromCol, inventory.CodepointColumns)+1)
+
+	for i, fix := range typeError.Fixes {
+		changed := make([]string, 0, len(fix.MCS))
+		for _, node := range r.jsonNodes(fix.MCS) {
+			changed = append(changed, "`"+node.Name+"`")
+		}
+		fmt.Fprintf(sb, "\n%s change %s\n",
+			p.fix.Sprintf("fix %d of %d:", i+1, len(typeError.Fixes)), strings.Join(changed, ", "))
+		renderSnapshot(sb, fix, r.Report.NodeRange, source, width, p)
+		if fix.Explanation != "" {
+			fmt.Fprintf(sb, "%s %s %s\n", strings.Repeat(" ", width), p.gutter.Sprint("="), fix.Explanation)
+		}
+		for _, suggestion := range fix.Suggestions {
+			fmt.Fprintf(sb, "%s %s %s\n", strings.Repeat(" ", width), p.gutter.Sprint("= help:"), suggestion.Title)
+		}
+	}
+}
+
+// gutterWidth is the width of the widest line number of an error.
+func gutterWidth(typeError TypeError) int {
+	width := 1
+	for _, fix := range typeError.Fixes {
+		for _, line := range fix.Snapshot {
+			width = max(width, len(strconv.Itoa(line.LineNumber+1)))
+		}
+	}
+	return width
+}
+
+// renderSnapshot prints the lines of a fix with its nodes underlined. Columns
+// are display columns, so that underlines stay aligned under tabs and
+// multi-byte characters.
+func renderSnapshot(sb *strings.Builder, fix Fix, nodeRange map[int]inventory.Range, source inventory.Source, width int, p palette) {
+	blank := strings.Repeat(" ", width)
+	bar := p.gutter.Sprint("|")
+	fmt.Fprintf(sb, "%s %s\n", blank, bar)
+	for _, line := range fix.Snapshot {
+		marked := make([]Span, 0)
+		for _, span := range line.Spans {
+			if span.Tag != tagNormal {
+				span.From = source.DisplayColumn(line.LineNumber, span.From)
+				span.To = source.DisplayColumn(line.LineNumber, span.To)
+				marked = append(marked, span)
+			}
+		}
+		fmt.Fprintf(sb, "%s %s %s\n", p.gutter.Sprintf("%*d", width, line.LineNumber+1), bar, source.DisplayLine(line.LineNumber))
+
+		// Label every node on the last line it covers with its type under the fix
+		labelled := make([]Span, 0)
+		for _, span := range marked {
+			if nodeRange[span.Node].ToLine == line.LineNumber {
+				labelled = append(labelled, span)
+			}
+		}
+		slices.SortStableFunc(labelled, func(a, b Span) int { return a.From - b.From })
+
+		underline := underlineRow(marked, p)
+		if len(labelled) != 0 {
+			last := labelled[len(labelled)-1]
+			underline += " " + p.types.Sprint(fix.LocalType[last.Node])
+		}
+		fmt.Fprintf(sb, "%s %s %s\n", blank, bar, underline)
+		for j := len(labelled) - 2; j >= 0; j-- {
+			connectors := make([]cell, 0)
+			for _, span := range labelled[:j+1] {
+				connectors = append(connectors, cell{span.From, p.forTag(span.Tag).Sprint("|"), 1})
+			}
+			fmt.Fprintf(sb, "%s %s %s\n", blank, bar, renderCells(connectors))
+			label := fix.LocalType[labelled[j].Node]
+			cells := connectors[:j]
+			cells = append(cells, cell{labelled[j].From, p.types.Sprint(label), len([]rune(label))})
+			fmt.Fprintf(sb, "%s %s %s\n", blank, bar, renderCells(cells))
+		}
+	}
+}
+
+// underlineRow marks the nodes of a line with ^ when the fix changes them and
+// with - otherwise. Where nodes overlap, ^ wins.
+func underlineRow(spans []Span, p palette) string {
+	end := 0
+	for _, span := range spans {
+		end = max(end, span.To)
+	}
+	tags := make([]Tag, end)
+	for _, span := range spans {
+		for col := span.From; col < span.To; col++ {
+			if tags[col] != tagError {
+				tags[col] = span.Tag
+			}
+		}
+	}
+	var sb strings.Builder
+	for col := 0; col < end; {
+		run := col
+		for run < end && tags[run] == tags[col] {
+			run++
+		}
+		switch tags[col] {
+		case tagError:
+			sb.WriteString(p.mcs.Sprint(strings.Repeat("^", run-col)))
+		case tagCritical:
+			sb.WriteString(p.critical.Sprint(strings.Repeat("-", run-col)))
+		default:
+			sb.WriteString(strings.Repeat(" ", run-col))
+		}
+		col = run
+	}
+	return sb.String()
+}
+
+// cell is text placed at a column; width is its visible width.
+type cell struct {
+	col   int
+	text  string
+	width int
+}
+
+func renderCells(cells []cell) string {
+	var sb strings.Builder
+	cursor := 0
+	for _, c := range cells {
+		if c.col > cursor {
+			sb.WriteString(strings.Repeat(" ", c.col-cursor))
+			cursor = c.col
+		} else if cursor > 0 && c.col < cursor {
+			sb.WriteString(" ")
+			cursor++
+		}
+		sb.WriteString(c.text)
+		cursor += c.width
+	}
+	return sb.String()
+}
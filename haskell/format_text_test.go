@@ -0,0 +1,158 @@
+package haskell
+
+import (
+	"goanna/inventory"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// consSource conses a Char onto a list of Ints. Changing both Ints is one of
+// its fixes.
+const consSource = "f = 'c' : [1, 2]\n"
+
+func consInput() inventory.Input {
+	rule := func(id int, body string) inventory.Rule {
+		return inventory.Rule{Id: id, Head: inventory.RuleHead{Name: "f", Module: "Main", Type: "type"}, Body: body}
+	}
+	return inventory.Input{
+		Declarations: []string{"f"},
+		Rules: []inventory.Rule{
+			rule(1, "_1 = builtin_Char"),
+			rule(2, "_2 = builtin_Int"),
+			rule(3, "_3 = builtin_Int"),
+			rule(4, "_4 = pair(list, _2), _2 = _3"),
+			rule(5, "_5 = pair(list, _1), _5 = _4"),
+			rule(6, "T = _5"),
+		},
+		NodeTable: []inventory.NodePair{
+			{Parent: 0, Child: 1}, {Parent: 0, Child: 2}, {Parent: 0, Child: 3},
+			{Parent: 0, Child: 4}, {Parent: 0, Child: 5}, {Parent: 0, Child: 6},
+		},
+		NodeDepth: map[int]int{1: 1, 2: 1, 3: 1, 4: 1, 5: 1, 6: 1},
+		NodeRange: map[int]inventory.Range{
+			1: {FromLine: 0, FromCol: 4, ToLine: 0, ToCol: 7},
+			2: {FromLine: 0, FromCol: 11, ToLine: 0, ToCol: 12},
+			3: {FromLine: 0, FromCol: 14, ToLine: 0, ToCol: 15},
+			4: {FromLine: 0, FromCol: 10, ToLine: 0, ToCol: 16},
+			5: {FromLine: 0, FromCol: 4, ToLine: 0, ToCol: 16},
+			6: {FromLine: 0, FromCol: 0, ToLine: 0, ToCol: 16},
+		},
+		TopLevels: []string{"f"},
+		MaxLevel:  1,
+	}
+}
+
+// checkInput checks the program whose translation is input.
+func checkInput(input inventory.Input, source string) CheckResult {
+	inv := inventory.NewInventory(input)
+	return NewCheckResult(inv, Localise(inv, input.MaxLevel), "Main.hs", source)
+}
+
+// text joins the lines of a terminal report.
+func text(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestTextOneError(t *testing.T) {
+	result := checkInput(plusInput(), plusSource)
+	assert.Equal(t, text(
+		"error: type error involving `'c'`, `'c' + 1`, `1`",
+		"  --> Main.hs:1:5",
+		"",
+		"fix 1 of 3: change `'c'`",
+		"  |",
+		"1 | f = 'c' + 1",
+		"  |     ^^^---- Int",
+		"  |     | |",
+		"  |     | Int",
+		"  |     |",
+		"  |     Int",
+		"  = If `'c' + 1` is `Int` and `1` is `Int`, then `'c'` is wrong because it has to be `Int` here but on its own it is `Char`.",
+		"",
+		"fix 2 of 3: change `'c' + 1`",
+		"  |",
+		"1 | f = 'c' + 1",
+		"  |     ^^^^^^^ Int",
+		"  |     | |",
+		"  |     | a",
+		"  |     |",
+		"  |     Char",
+		"  = If `'c'` is `Char` and `1` is `Int`, then `'c' + 1` is wrong because on its own it is `Int`, which conflicts with the rest.",
+		"",
+		"fix 3 of 3: change `1`",
+		"  |",
+		"1 | f = 'c' + 1",
+		"  |     ------^ Char",
+		"  |     | |",
+		"  |     | Char",
+		"  |     |",
+		"  |     Char",
+		"  = If `'c'` is `Char` and `'c' + 1` is `Char`, then `1` is wrong because it has to be `Char` here but on its own it is `Int`.",
+	), result.Text(false))
+}
+
+func TestTextMultiNodeFix(t *testing.T) {
+	result := checkInput(consInput(), consSource)
+	assert.Equal(t, text(
+		"error: type error involving `'c'`, `'c' ..., 2]`, `[1, 2]`, `1`, `2`",
+		"  --> Main.hs:1:5",
+		"",
+		"fix 1 of 4: change `'c'`",
+		"  |",
+		"1 | f = 'c' : [1, 2]",
+		"  |     ^^^--------- Int",
+		"  |     | |   ||",
+		"  |     | |   |Int",
+		"  |     | |   |",
+		"  |     | |   [Int]",
+		"  |     | |",
+		"  |     | [Int]",
+		"  |     |",
+		"  |     Int",
+		"  = If `'c' ..., 2]` is `[Int]` and `[1, 2]` is `[Int]`, then `'c'` is wrong because it has to be `Int` here but on its own it is `Char`.",
+		"",
+		"fix 2 of 4: change `'c' ..., 2]`",
+		"  |",
+		"1 | f = 'c' : [1, 2]",
+		"  |     ^^^^^^^^^^^^ Int",
+		"  |     | |   ||",
+		"  |     | |   |Int",
+		"  |     | |   |",
+		"  |     | |   [Int]",
+		"  |     | |",
+		"  |     | a",
+		"  |     |",
+		"  |     Char",
+		"  = If `'c'` is `Char` and `[1, 2]` is `[Int]`, then `'c' ..., 2]` is wrong because on its own it is `[Int]`, which conflicts with the rest.",
+		"",
+		"fix 3 of 4: change `[1, 2]`",
+		"  |",
+		"1 | f = 'c' : [1, 2]",
+		"  |     ------^^^^^^ Int",
+		"  |     | |   ||",
+		"  |     | |   |Int",
+		"  |     | |   |",
+		"  |     | |   [Char]",
+		"  |     | |",
+		"  |     | [Char]",
+		"  |     |",
+		"  |     Char",
+		"  = If `1` is `Int` and `2` is `Int`, then `[1, 2]` is wrong because it has to be `[Char]` here but on its own it is `[Int]`.",
+		"",
+		"fix 4 of 4: change `1`, `2`",
+		"  |",
+		"1 | f = 'c' : [1, 2]",
+		"  |     -------^--^- Char",
+		"  |     | |   ||",
+		"  |     | |   |Char",
+		"  |     | |   |",
+		"  |     | |   [Char]",
+		"  |     | |",
+		"  |     | [Char]",
+		"  |     |",
+		"  |     Char",
+		"  = If `[1, 2]` is `[Char]` and `'c'` is `Char`, then `1` is wrong because it has to be `Char` here but on its own it is `Int`. If `[1, 2]` is `[Char]` and `'c'` is `Char`, then `2` is wrong because it has to be `Char` here but on its own it is `Int`.",
+	), result.Text(false))
+}
@@ -0,0 +1,77 @@
+package haskell
+
+import (
+	"goanna/inventory"
+	prologtool "goanna/prolog-tool"
+	"slices"
+)
+
+type LocalBinding struct {
+	Name string
+	Type string
+}
+
+type HoleType struct {
+	Name   string
+	Node   int
+	Range  inventory.Range
+	Type   string
+	Locals []LocalBinding
+}
+
+// InferHoles reports the type of every typed hole, together with the types of
+// the local bindings in scope, for a SATISFIABLE set of rules.
+func InferHoles(inv inventory.Inventory, rules []int) []HoleType {
+	holes := make([]inventory.Hole, 0)
+	captures := make([]int, 0)
+	for _, hole := range inv.Holes {
+		if !slices.Contains(inv.Declarations, hole.Declaration) {
+			continue
+		}
+		holes = append(holes, hole)
+		captures = append(captures, hole.NodeId, hole.ContextId)
+	}
+	if len(holes) == 0 {
+		return []HoleType{}
+	}
+	slices.Sort(captures)
+
+	prologResult := inv.QueryTypes(rules, captures)
+	capturedTypes, err := prologtool.ParseTerm(prologResult["L"])
+	if err != nil {
+		panic("Error in parse types")
+	}
+	captured := make(map[int]prologtool.Term)
+	for i, v := range capturedTypes.(prologtool.List).Values {
+		captured[captures[i]] = v
+	}
+
+	result := make([]HoleType, len(holes))
+	for i, hole := range holes {
+		// One printer per hole, so that the hole and its locals share type variable names
+		printer := NewPrinter(inv.Classes)
+		holeType := printer.PrepareType(captured[hole.NodeId])
+		localTypes := make([]Type, len(hole.Locals))
+		if context, ok := captured[hole.ContextId].(prologtool.List); ok {
+			for j, v := range context.Values {
+				localTypes[j] = printer.PrepareType(v)
+			}
+		}
+		printer.AssignVars()
+		locals := make([]LocalBinding, len(hole.Locals))
+		for j, name := range hole.Locals {
+			locals[j] = LocalBinding{
+				Name: name,
+				Type: printer.CompileType(localTypes[j]).String(),
+			}
+		}
+		result[i] = HoleType{
+			Name:   hole.Name,
+			Node:   hole.NodeId,
+			Range:  inv.NodeRange[hole.NodeId],
+			Type:   printer.CompileType(holeType).String(),
+			Locals: locals,
+		}
+	}
+	return result
+}
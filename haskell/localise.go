@@ -0,0 +1,82 @@
+package haskell
+
+import (
+	"goanna/inventory"
+	"goanna/marco"
+)
+
+type Outcome string
+
+const (
+	// OutcomeAxiomsUnsatisfiable means the rules that were made axioms at this
+	// level already contradict each other, so no subset of the effective rules
+	// can be blamed.
+	OutcomeAxiomsUnsatisfiable Outcome = "axioms-unsatisfiable"
+	// OutcomeNoCriticalNodes means MARCO found the program unsatisfiable but
+	// could not point at any node.
+	OutcomeNoCriticalNodes Outcome = "no-critical-nodes"
+	OutcomeWellTyped       Outcome = "well-typed"
+	OutcomeLocalised       Outcome = "localised"
+)
+
+// LevelStep records one attempt of the level search.
+type LevelStep struct {
+	Level          int
+	EffectiveRules int
+	AxiomaticRules int
+	Outcome        Outcome
+}
+
+// Localisation is the result of the level search. When Localised is false,
+// every level was rejected and Errors is empty; Trace tells why.
+type Localisation struct {
+	Level     int
+	Localised bool
+	Errors    []marco.Error
+	Trace     []LevelStep
+}
+
+func (l Localisation) WellTyped() bool {
+	return l.Localised && len(l.Errors) == 0
+}
+
+// Localise generalises inv from maxLevel downwards until the type errors can be
+// pinned to critical nodes, or the program is found to be well typed. The
+// inventory is left generalised at the level that was accepted. options are
+// passed on to every MARCO run.
+func Localise(inv *inventory.Inventory, maxLevel int, options ...marco.Option) Localisation {
+	trace := make([]LevelStep, 0)
+	for level := maxLevel; level > 0; level-- {
+		inv.Generalize(level)
+		step := LevelStep{
+			Level:          level,
+			EffectiveRules: len(inv.EffectiveRules),
+			AxiomaticRules: len(inv.AxiomaticRules),
+		}
+		if !inv.AxiomCheck() {
+			step.Outcome = OutcomeAxiomsUnsatisfiable
+			trace = append(trace, step)
+			continue
+		}
+		if inv.TypeCheck() {
+			step.Outcome = OutcomeWellTyped
+			trace = append(trace, step)
+			return Localisation{Level: level, Localised: true, Errors: []marco.Error{}, Trace: trace}
+		}
+		ruleIds := inv.EffectiveRules
+		inv.ConsultAxioms()
+		mc := marco.NewMarco(ruleIds, inv.Satisfiable, options...)
+		mc.Run()
+
+		errors := mc.Analysis()
+		if len(errors) == 1 && len(errors[0].CriticalNodes) == 0 {
+			step.Outcome = OutcomeNoCriticalNodes
+			trace = append(trace, step)
+			continue
+		}
+		step.Outcome = OutcomeLocalised
+		trace = append(trace, step)
+		return Localisation{Level: level, Localised: true, Errors: errors, Trace: trace}
+	}
+	return Localisation{Level: 0, Localised: false, Errors: []marco.Error{}, Trace: trace}
+}
@@ -0,0 +1,93 @@
+package haskell
+
+import (
+	"goanna/inventory"
+	"goanna/marco"
+	"goanna/translator"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mcses returns the MCS of every cause of e.
+func mcses(e marco.Error) [][]int {
+	result := make([][]int, len(e.Causes))
+	for i, cause := range e.Causes {
+		result[i] = marco.Sorted(cause.MCS)
+	}
+	return result
+}
+
+func TestLocalise(t *testing.T) {
+	inv := inventory.NewInventory(plusInput())
+	localisation := Localise(inv, 1)
+	assert.True(t, localisation.Localised)
+	assert.Equal(t, 1, localisation.Level)
+	assert.Equal(t, []LevelStep{
+		{Level: 1, EffectiveRules: 4, AxiomaticRules: 0, Outcome: OutcomeLocalised},
+	}, localisation.Trace)
+	assert.Len(t, localisation.Errors, 1)
+	assert.Equal(t, []int{1, 2, 3}, localisation.Errors[0].CriticalNodes)
+	assert.ElementsMatch(t, [][]int{{1}, {2}, {3}}, mcses(localisation.Errors[0]))
+}
+
+func TestLocaliseRejectsLevel(t *testing.T) {
+	// `'c' + 1` is made to contradict itself. At level 2 its rule is an
+	// axiom, so no effective rule can be blamed and level 1 is tried.
+	input := plusInput()
+	input.Rules[2].Body = "_3 = builtin_Char, _3 = builtin_Int"
+	input.NodeTable = []inventory.NodePair{{Parent: 0, Child: 4}, {Parent: 4, Child: 3}, {Parent: 3, Child: 1}, {Parent: 3, Child: 2}}
+	input.NodeDepth = map[int]int{4: 1, 3: 1, 1: 2, 2: 2}
+	input.MaxLevel = 2
+	inv := inventory.NewInventory(input)
+
+	localisation := Localise(inv, input.MaxLevel)
+	assert.True(t, localisation.Localised)
+	assert.Equal(t, 1, localisation.Level)
+	assert.Equal(t, []Outcome{OutcomeAxiomsUnsatisfiable, OutcomeLocalised},
+		[]Outcome{localisation.Trace[0].Outcome, localisation.Trace[1].Outcome})
+	assert.Len(t, localisation.Errors, 1)
+	assert.Equal(t, [][]int{{3}}, mcses(localisation.Errors[0]))
+}
+
+func TestLocaliseExhaustsLevels(t *testing.T) {
+	// Without a node graph every rule is an axiom
+	input := plusInput()
+	input.NodeTable = nil
+	inv := inventory.NewInventory(input)
+
+	localisation := Localise(inv, 1)
+	assert.False(t, localisation.Localised)
+	assert.Empty(t, localisation.Errors)
+	assert.Equal(t, []LevelStep{
+		{Level: 1, EffectiveRules: 0, AxiomaticRules: 4, Outcome: OutcomeAxiomsUnsatisfiable},
+	}, localisation.Trace)
+}
+
+func TestLocaliseTranslated(t *testing.T) {
+	requireTranslator(t)
+	program := corpusDir + "/ill-typed/if-branches.hs"
+	code, err := os.ReadFile(program)
+	assert.NoError(t, err)
+	input, err := translator.Translate(string(code))
+	assert.NoError(t, err)
+	inv := inventory.NewInventory(input)
+
+	localisation := Localise(inv, input.MaxLevel)
+	assert.True(t, localisation.Localised)
+	assert.Equal(t, OutcomeLocalised, localisation.Trace[len(localisation.Trace)-1].Outcome)
+	assert.Len(t, localisation.Errors, 1)
+
+	// Changing the string alone makes both branches numbers
+	result := NewCheckResult(inv, localisation, program, string(code)).JSON()
+	mcs := make([][]string, 0)
+	for _, fix := range result.TypeErrors[0].Fixes {
+		names := make([]string, len(fix.MCS))
+		for i, node := range fix.MCS {
+			names[i] = node.Name
+		}
+		mcs = append(mcs, names)
+	}
+	assert.Contains(t, mcs, []string{`"positive"`})
+}
@@ -2,6 +2,7 @@ package meta
 
 import (
 	"goanna/haskell/parser"
+	"slices"
 )
 
 type paramsEnv struct {
@@ -59,6 +60,19 @@ func patsToVars(pat parser.Pat) []string {
 		vars := patsToVars(p.Pat1)
 		vars = append(vars, patsToVars(p.Pat2)...)
 		return vars
+	case *parser.PRecord:
+		var vars []string
+		for _, field := range p.Fields {
+			vars = append(vars, patsToVars(field.Pat)...)
+		}
+		return vars
+	case *parser.PAs:
+		vars := patsToVars(&p.Var)
+		return append(vars, patsToVars(p.Pat)...)
+	case *parser.PIrrefutable:
+		return patsToVars(p.Pat)
+	case *parser.PBang:
+		return patsToVars(p.Pat)
 	case *parser.Lit:
 		return nil
 	default:
@@ -96,6 +110,9 @@ func (pe *paramsEnv) enter(ast parser.AST, parent parser.AST) {
 				pe.result[declName] = []string{}
 			}
 		default:
+			// Pattern binding: `(a, b) = ...` — its variables are bound in
+			// the enclosing decl, like the variables of a case alt.
+			pe.addParams(pe.currentDecl(), patsToVars(node.Pat))
 			pe.declStack = append(pe.declStack, "")
 		}
 
@@ -106,6 +123,11 @@ func (pe *paramsEnv) enter(ast parser.AST, parent parser.AST) {
 		vars := patsToVars(node.Pat)
 		pe.addParams(decl, vars)
 
+	case *parser.Generator:
+		// `x <- e` in a do block or a list comprehension: the pat's
+		// variables are params of the enclosing decl.
+		pe.addParams(pe.currentDecl(), patsToVars(node.Pat))
+
 	case *parser.ExpLambda:
 		// `\a b -> ...`: lambda params attributed to the enclosing decl.
 		decl := pe.currentDecl()
@@ -128,7 +150,9 @@ func (pe *paramsEnv) leave(ast parser.AST) {
 // InheritParams takes a params map (decl -> own params) and a decl graph
 // (child -> [parents], as returned by GetDeclGraph) and returns a new params
 // map where each decl's param list is prefixed with all ancestor params,
-// outermost ancestor first.
+// outermost ancestor first. The parents of a decl are all of its ancestors,
+// outermost first, so it inherits the params of the innermost one, which
+// has inherited those of the others.
 func InheritParams(params map[string][]string, graph map[string][]string) map[string][]string {
 	// memo caches the fully-inherited param list for each decl.
 	memo := make(map[string][]string)
@@ -148,8 +172,8 @@ func InheritParams(params map[string][]string, graph map[string][]string) map[st
 		defer func() { visiting[decl] = false }()
 
 		var ancestorParams []string
-		for _, parent := range graph[decl] {
-			ancestorParams = append(ancestorParams, inherited(parent)...)
+		if parents := graph[decl]; len(parents) > 0 {
+			ancestorParams = slices.Clone(inherited(parents[len(parents)-1]))
 		}
 		own := params[decl]
 		result := append(ancestorParams, own...)
@@ -237,12 +237,7 @@ func (p *PApp) Pretty() string {
 	if len(p.Pats) > 0 {
 		patStrs := make([]string, len(p.Pats))
 		for i, pat := range p.Pats {
-			patStr := pat.Pretty()
-			// Wrap PApp patterns in parentheses when they appear as arguments
-			if _, isPApp := pat.(*PApp); isPApp {
-				patStr = "(" + patStr + ")"
-			}
-			patStrs[i] = patStr
+			patStrs[i] = prettyPatArg(pat)
 		}
 		result += " " + strings.Join(patStrs, " ")
 	}
@@ -327,6 +322,85 @@ func (pi *PInfix) Pretty() string {
 func (n *PInfix) Loc() Loc { return n.Node.loc }
 func (n *PInfix) Id() int  { return n.Node.id }
 
+// PRecord is a record pattern, `P { name = n }`. Fields it does not mention
+// match anything.
+type PRecord struct {
+	Con    ExpVar
+	Fields []PFieldBind
+	Node
+}
+
+func (*PRecord) isPat() {}
+func (pr *PRecord) Pretty() string {
+	fieldStrs := make([]string, len(pr.Fields))
+	for i, field := range pr.Fields {
+		fieldStrs[i] = field.Pretty()
+	}
+	return pr.Con.Pretty() + " {" + strings.Join(fieldStrs, ", ") + "}"
+}
+func (n *PRecord) Loc() Loc { return n.Node.loc }
+func (n *PRecord) Id() int  { return n.Node.id }
+
+// PAs is an as-pattern, `all@(x:xs)`, which binds Var to the whole value that
+// Pat matches.
+type PAs struct {
+	Var PVar
+	Pat Pat
+	Node
+}
+
+func (*PAs) isPat() {}
+func (pa *PAs) Pretty() string {
+	return pa.Var.Pretty() + "@" + prettyPatArg(pa.Pat)
+}
+func (n *PAs) Loc() Loc { return n.Node.loc }
+func (n *PAs) Id() int  { return n.Node.id }
+
+// PIrrefutable is a lazy pattern, `~(a, b)`, which always matches. Pat is only
+// matched once one of its variables is used.
+type PIrrefutable struct {
+	Pat Pat
+	Node
+}
+
+func (*PIrrefutable) isPat() {}
+func (pi *PIrrefutable) Pretty() string {
+	return "~" + prettyPatArg(pi.Pat)
+}
+func (n *PIrrefutable) Loc() Loc { return n.Node.loc }
+func (n *PIrrefutable) Id() int  { return n.Node.id }
+
+// PBang is a strict pattern, `!n`, which evaluates the value before matching
+// Pat.
+type PBang struct {
+	Pat Pat
+	Node
+}
+
+func (*PBang) isPat() {}
+func (pb *PBang) Pretty() string {
+	return "!" + prettyPatArg(pb.Pat)
+}
+func (n *PBang) Loc() Loc { return n.Node.loc }
+func (n *PBang) Id() int  { return n.Node.id }
+
+// prettyPatArg prints pat where it is the argument of a constructor or
+// prefix, wrapping constructor applications and negative literals in
+// parentheses.
+func prettyPatArg(pat Pat) string {
+	switch p := pat.(type) {
+	case *PApp:
+		if len(p.Pats) > 0 {
+			return "(" + p.Pretty() + ")"
+		}
+	case *Lit:
+		if strings.HasPrefix(p.Content, "-") {
+			return "(" + p.Pretty() + ")"
+		}
+	}
+	return pat.Pretty()
+}
+
 // ExpVar
 type ExpVar struct {
 	Name      string
@@ -346,6 +420,19 @@ func (v *ExpVar) Pretty() string {
 func (n *ExpVar) Loc() Loc { return n.Node.loc }
 func (n *ExpVar) Id() int  { return n.Node.id }
 
+// ExpHole is a typed hole: `_` or `_name` in expression position. A `_name`
+// that is bound is a variable rather than a hole; renaming sets its Canonical.
+type ExpHole struct {
+	Name      string
+	Canonical string
+	Node
+}
+
+func (*ExpHole) isExp()           {}
+func (h *ExpHole) Pretty() string { return h.Name }
+func (n *ExpHole) Loc() Loc       { return n.Node.loc }
+func (n *ExpHole) Id() int        { return n.Node.id }
+
 // ExpCon
 // type ExpCon struct {
 //	name      string
@@ -601,6 +688,58 @@ func (e *ExpComprehension) Pretty() string {
 func (n *ExpComprehension) Loc() Loc { return n.Node.loc }
 func (n *ExpComprehension) Id() int  { return n.Node.id }
 
+// ExpRecordCon builds a record with its constructor, `P { name = "x" }`
+type ExpRecordCon struct {
+	Con    ExpVar
+	Fields []FieldBind
+	Node
+}
+
+func (*ExpRecordCon) isExp() {}
+func (e *ExpRecordCon) Pretty() string {
+	return e.Con.Pretty() + " " + prettyFieldBinds(e.Fields)
+}
+func (n *ExpRecordCon) Loc() Loc { return n.Node.loc }
+func (n *ExpRecordCon) Id() int  { return n.Node.id }
+
+// ExpRecordUpdate copies a record with some of its fields replaced,
+// `p { age = 3 }`
+type ExpRecordUpdate struct {
+	Exp    Exp
+	Fields []FieldBind
+	Node
+}
+
+func (*ExpRecordUpdate) isExp() {}
+func (e *ExpRecordUpdate) Pretty() string {
+	return e.Exp.Pretty() + " " + prettyFieldBinds(e.Fields)
+}
+func (n *ExpRecordUpdate) Loc() Loc { return n.Node.loc }
+func (n *ExpRecordUpdate) Id() int  { return n.Node.id }
+
+// ExpTyped is an expression with a type annotation, `(e :: T)`. The type
+// variables of Ty are its own, as in a type signature.
+type ExpTyped struct {
+	Exp Exp
+	Ty  Type
+	Node
+}
+
+func (*ExpTyped) isExp() {}
+func (e *ExpTyped) Pretty() string {
+	return "(" + e.Exp.Pretty() + " :: " + e.Ty.Pretty() + ")"
+}
+func (n *ExpTyped) Loc() Loc { return n.Node.loc }
+func (n *ExpTyped) Id() int  { return n.Node.id }
+
+func prettyFieldBinds(fields []FieldBind) string {
+	fieldStrs := make([]string, len(fields))
+	for i, field := range fields {
+		fieldStrs[i] = field.Pretty()
+	}
+	return "{" + strings.Join(fieldStrs, ", ") + "}"
+}
+
 // Lit
 type Lit struct {
 	Lit     string // integer/char/string/float
@@ -767,6 +906,9 @@ type DataDecl struct {
 	DHead        DeclHead
 	Constructors []DataCon
 	Deriving     []TyCon
+	// NewType is set for a newtype, which has exactly one constructor with a
+	// single field
+	NewType bool
 	Node
 }
 
@@ -777,7 +919,11 @@ func (dd *DataDecl) Pretty() string {
 	for i, con := range dd.Constructors {
 		conStrs[i] = con.Pretty()
 	}
-	result := "data " + dd.DHead.Pretty() + " = " + strings.Join(conStrs, " | ")
+	keyword := "data "
+	if dd.NewType {
+		keyword = "newtype "
+	}
+	result := keyword + dd.DHead.Pretty() + " = " + strings.Join(conStrs, " | ")
 
 	// Add deriving clause if present
 	if len(dd.Deriving) > 0 {
@@ -952,12 +1098,40 @@ type DataCon struct {
 	Name      string
 	Canonical string
 	Tys       []Type
+	// Fields are those of a record constructor, which has no Tys
+	Fields []FieldDecl
 	Node
 }
 
 func (dc *DataCon) SetCanonical(s string) { dc.Canonical = s }
+
+// FieldTypes returns the types of the arguments of the constructor, one per
+// field name for a record constructor.
+func (dc *DataCon) FieldTypes() []Type {
+	if len(dc.Fields) == 0 {
+		return dc.Tys
+	}
+	tys := make([]Type, 0)
+	for _, field := range dc.Fields {
+		for range field.Names {
+			tys = append(tys, field.Ty)
+		}
+	}
+	return tys
+}
+
 func (dc *DataCon) Pretty() string {
 	result := dc.Name
+	if len(dc.Fields) > 0 {
+		fieldStrs := make([]string, len(dc.Fields))
+		for i := range dc.Fields {
+			fieldStrs[i] = dc.Fields[i].Pretty()
+		}
+		return result + " {" + strings.Join(fieldStrs, ", ") + "}"
+	}
+	if isOperator(dc.Name) && len(dc.Tys) == 2 {
+		return dc.Tys[0].Pretty() + " " + dc.Name + " " + dc.Tys[1].Pretty()
+	}
 	if len(dc.Tys) > 0 {
 		tyStrs := make([]string, len(dc.Tys))
 		for i, ty := range dc.Tys {
@@ -970,6 +1144,47 @@ func (dc *DataCon) Pretty() string {
 func (n *DataCon) Loc() Loc { return n.Node.loc }
 func (n *DataCon) Id() int  { return n.Node.id }
 
+// FieldDecl declares the fields of a record constructor that share a type,
+// `age, size :: Int`. Every name is also a selector function.
+type FieldDecl struct {
+	Names      []string
+	Canonicals []string
+	Ty         Type
+	Node
+}
+
+func (fd *FieldDecl) Pretty() string {
+	return strings.Join(fd.Names, ", ") + " :: " + fd.Ty.Pretty()
+}
+func (n *FieldDecl) Loc() Loc { return n.Node.loc }
+func (n *FieldDecl) Id() int  { return n.Node.id }
+
+// FieldBind sets a field in a record construction or update, `age = 3`
+type FieldBind struct {
+	Field ExpVar
+	Exp   Exp
+	Node
+}
+
+func (fb *FieldBind) Pretty() string {
+	return fb.Field.Pretty() + " = " + fb.Exp.Pretty()
+}
+func (n *FieldBind) Loc() Loc { return n.Node.loc }
+func (n *FieldBind) Id() int  { return n.Node.id }
+
+// PFieldBind matches a field in a record pattern, `name = n`
+type PFieldBind struct {
+	Field ExpVar
+	Pat   Pat
+	Node
+}
+
+func (pfb *PFieldBind) Pretty() string {
+	return pfb.Field.Pretty() + " = " + pfb.Pat.Pretty()
+}
+func (n *PFieldBind) Loc() Loc { return n.Node.loc }
+func (n *PFieldBind) Id() int  { return n.Node.id }
+
 // DeclHead
 type DeclHead struct {
 	Name      string
@@ -998,7 +1213,7 @@ type Import struct {
 	Module    string   // Module name (e.g., "Data.List")
 	Qualified bool     // true for "import qualified"
 	Alias     string   // Alias name for "import ... as X" (empty if not present)
-	Items     []string // Imported items (empty if importing everything)
+	Items     []Entity // Imported items (empty if importing everything)
 	Hiding    bool     // true for "import ... hiding (...)"
 	Node
 }
@@ -1020,7 +1235,11 @@ func (i *Import) Pretty() string {
 		} else {
 			result += " ("
 		}
-		result += strings.Join(i.Items, ", ")
+		items := make([]string, len(i.Items))
+		for j, item := range i.Items {
+			items[j] = item.Pretty()
+		}
+		result += strings.Join(items, ", ")
 		result += ")"
 	}
 
@@ -1031,14 +1250,35 @@ func (n *Import) Id() int  { return n.Node.id }
 
 // Module
 type Module struct {
-	Name    string
+	Name string
+	// Exports is nil if the module has no export list, and exports everything
+	// it declares
+	Exports []Export
 	Decls   []Decl
 	Imports []Import
+	// Fixities are the fixity declarations of the module, including those in
+	// class bodies
+	Fixities        []FixityDecl
+	FixityConflicts []FixityConflict
+	// ImportErrors are set by renaming
+	ImportErrors []ImportError
+	// Diagnostics are the declarations and expressions renaming finds
+	// malformed, such as equations with different numbers of arguments
+	Diagnostics []Diagnostic
 	Node
+	chains []infixChain
+}
+
+// Diagnostic is a declaration or expression that is malformed although it
+// parses.
+type Diagnostic struct {
+	Message string
+	NodeId  int
+	Loc     Loc
 }
 
 func (m *Module) Pretty() string {
-	t := `module {{ .Name }} where
+	t := `module {{ .Name }}{{ .Exports }} where
 {{- range .Imports }}
 {{ . }}
 {{- end }}
@@ -1051,16 +1291,26 @@ func (m *Module) Pretty() string {
 		imports[i] = imp.Pretty()
 	}
 
+	exports := ""
+	if m.Exports != nil {
+		exportStrs := make([]string, len(m.Exports))
+		for i, export := range m.Exports {
+			exportStrs[i] = export.Pretty()
+		}
+		exports = " (" + strings.Join(exportStrs, ", ") + ")"
+	}
+
 	decls := make([]string, len(m.Decls))
 	for i, decl := range m.Decls {
 		decls[i] = decl.Pretty()
 	}
 	return render(t, "module", struct {
 		Name    string
+		Exports string
 		Imports []string
 		Decls   []string
 	}{
-		m.Name, imports, decls,
+		m.Name, exports, imports, decls,
 	})
 }
 func (n *Module) Loc() Loc { return n.Node.loc }
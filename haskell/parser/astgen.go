@@ -3,6 +3,7 @@ package parser
 import (
 	"slices"
 	"strings"
+	"unicode"
 
 	treesitter "github.com/tree-sitter/go-tree-sitter"
 )
@@ -60,30 +61,12 @@ func (pe parseEnv) parseImport(node *treesitter.Node) Import {
 	}
 
 	// Get import items list
-	var items []string
+	var items []Entity
 	if itemsNode := pe.child(node, "names"); itemsNode != nil {
-		// Get the items (import_name nodes) from the field "name"
 		itemNodes := pe.children(itemsNode, "name")
-		items = make([]string, len(itemNodes))
+		items = make([]Entity, len(itemNodes))
 		for i, itemNode := range itemNodes {
-			// Get the name from inside the import_name
-			// The import_name structure has either a name, variable, or type child
-			var nameStr string
-			nameNode := pe.child(&itemNode, "name")
-			if nameNode != nil {
-				nameStr = pe.text(nameNode)
-			} else {
-				nameNode = pe.child(&itemNode, "variable")
-				if nameNode != nil {
-					nameStr = pe.text(nameNode)
-				} else {
-					nameNode = pe.child(&itemNode, "type")
-					if nameNode != nil {
-						nameStr = pe.text(nameNode)
-					}
-				}
-			}
-			items[i] = nameStr
+			items[i] = pe.parseEntity(&itemNode)
 		}
 	}
 
@@ -106,37 +89,137 @@ func (pe parseEnv) parseDataCons(nodes []treesitter.Node) []DataCon {
 }
 
 func (pe parseEnv) parseDataCon(node *treesitter.Node) DataCon {
-	// Try to find the constructor name from the first child or named children
+	conNode := pe.child(node, "constructor")
 	var name string
-	nameNode := pe.child(node, "Constructor:name")
-	if nameNode == nil {
-		// If Constructor:name doesn't exist, try getting the first named child
-		for i := uint(0); i < uint(node.NamedChildCount()); i++ {
-			child := node.NamedChild(i)
-			if child.Kind() != "type" && child.Kind() != "Type" {
-				nameNode = child
-				break
-			}
+	var types []Type
+	var fields []FieldDecl
+	switch conNode.Kind() {
+	case "prefix":
+		name = pe.text(pe.child(conNode, "name"))
+		types = pe.parseTypes(pe.children(conNode, "field"))
+	case "infix":
+		// a :+ b
+		name = pe.text(pe.child(conNode, "operator"))
+		types = []Type{
+			pe.parseType(pe.child(conNode, "left_operand")),
+			pe.parseType(pe.child(conNode, "right_operand")),
 		}
+	case "record":
+		name = pe.text(pe.child(conNode, "name"))
+		fieldNodes := pe.children(conNode, "fields:field")
+		fields = make([]FieldDecl, len(fieldNodes))
+		for i, fieldNode := range fieldNodes {
+			fields[i] = pe.parseFieldDecl(&fieldNode)
+		}
+	default:
+		panic("Unknown data constructor: " + conNode.Kind())
 	}
 
-	if nameNode != nil {
-		name = pe.text(nameNode)
-	}
-
-	types := pe.parseTypes(pe.children(node, "Constructor:field"))
 	return DataCon{
 		Name:      name,
 		Canonical: "",
 		Tys:       types,
+		Fields:    fields,
 		Node:      pe.node(node),
 	}
 }
 
-func (pe parseEnv) parseDecls(nodes []treesitter.Node) []Decl {
-	decls := make([]Decl, len(nodes))
+// parseNewtypeCon parses the constructor of a newtype, whose single field may
+// be named.
+func (pe parseEnv) parseNewtypeCon(node *treesitter.Node) DataCon {
+	con := DataCon{
+		Name: pe.text(pe.child(node, "name")),
+		Node: pe.node(node),
+	}
+	field := pe.child(node, "field")
+	switch field.Kind() {
+	case "record":
+		con.Fields = []FieldDecl{pe.parseFieldDecl(pe.child(field, "field"))}
+	case "field":
+		con.Tys = []Type{pe.parseType(field.NamedChild(0))}
+	default:
+		panic("Unknown newtype constructor field: " + field.Kind())
+	}
+	return con
+}
+
+// parseDeriving parses the classes of every deriving clause of a data or
+// newtype declaration.
+func (pe parseEnv) parseDeriving(node *treesitter.Node) []TyCon {
+	var deriving []TyCon
+	for _, derivingNode := range pe.children(node, "deriving") {
+		classesNode := pe.child(&derivingNode, "classes")
+		if classesNode == nil {
+			continue
+		}
+		// Parse the type (could be a single name or a tuple)
+		ty := pe.parseType(classesNode)
+		if tycon, ok := ty.(*TyCon); ok {
+			deriving = append(deriving, *tycon)
+		} else if tytuple, ok := ty.(*TyTuple); ok {
+			// Extract TyCons from the tuple
+			for _, innerTy := range tytuple.Tys {
+				if tycon, ok := innerTy.(*TyCon); ok {
+					deriving = append(deriving, *tycon)
+				}
+			}
+		}
+	}
+	return deriving
+}
+
+func (pe parseEnv) parseFieldDecl(node *treesitter.Node) FieldDecl {
+	nameNodes := pe.children(node, "name")
+	names := make([]string, 0, len(nameNodes))
+	for _, nameNode := range nameNodes {
+		if nameNode.IsNamed() {
+			names = append(names, pe.text(&nameNode))
+		}
+	}
+	return FieldDecl{
+		Names: names,
+		Ty:    pe.parseType(pe.child(node, "type")),
+		Node:  pe.node(node),
+	}
+}
+
+// parseFieldBinds parses the `field = exp` bindings of a record construction
+// or update.
+func (pe parseEnv) parseFieldBinds(nodes []treesitter.Node) []FieldBind {
+	binds := make([]FieldBind, len(nodes))
 	for i, node := range nodes {
-		decls[i] = pe.parseDecl(&node)
+		binds[i] = FieldBind{
+			Field: pe.parseField(pe.child(&node, "field")),
+			Exp:   pe.parseExp(pe.child(&node, "expression")),
+			Node:  pe.node(&node),
+		}
+	}
+	return binds
+}
+
+// parseField parses the name of a field where it is used, which may be
+// qualified.
+func (pe parseEnv) parseField(node *treesitter.Node) ExpVar {
+	module := ""
+	nameNode := node
+	if node.Kind() == "qualified" {
+		module = strings.TrimSuffix(pe.text(pe.child(node, "module")), ".")
+		nameNode = pe.child(node, "id")
+	}
+	return ExpVar{
+		Name:   pe.text(nameNode),
+		Module: module,
+		Node:   pe.node(node),
+	}
+}
+
+func (pe parseEnv) parseDecls(nodes []treesitter.Node) []Decl {
+	decls := make([]Decl, 0, len(nodes))
+	for _, node := range nodes {
+		// Fixity declarations are collected separately
+		if decl := pe.parseDecl(&node); decl != nil {
+			decls = append(decls, decl)
+		}
 	}
 	return decls
 }
@@ -182,32 +265,18 @@ func (pe parseEnv) parseDecl(node *treesitter.Node) Decl {
 		dHead := pe.parseDeclHead(node)
 		constructorNodes := pe.children(node, "constructors:constructor")
 		constructors := pe.parseDataCons(constructorNodes)
-
-		// Parse deriving clause
-		var deriving []TyCon
-		derivingNode := pe.child(node, "deriving")
-		if derivingNode != nil {
-			classesNode := pe.child(derivingNode, "classes")
-			if classesNode != nil {
-				// Parse the type (could be a single name or a tuple)
-				ty := pe.parseType(classesNode)
-				if tycon, ok := ty.(*TyCon); ok {
-					deriving = append(deriving, *tycon)
-				} else if tytuple, ok := ty.(*TyTuple); ok {
-					// Extract TyCons from the tuple
-					for _, innerTy := range tytuple.Tys {
-						if tycon, ok := innerTy.(*TyCon); ok {
-							deriving = append(deriving, *tycon)
-						}
-					}
-				}
-			}
-		}
-
 		return Decl(&DataDecl{
 			DHead:        dHead,
 			Constructors: constructors,
-			Deriving:     deriving,
+			Deriving:     pe.parseDeriving(node),
+			Node:         pe.node(node),
+		})
+	case "newtype":
+		return Decl(&DataDecl{
+			DHead:        pe.parseDeclHead(node),
+			Constructors: []DataCon{pe.parseNewtypeCon(pe.child(node, "constructor"))},
+			Deriving:     pe.parseDeriving(node),
+			NewType:      true,
 			Node:         pe.node(node),
 		})
 	case "class":
@@ -271,9 +340,12 @@ func (pe parseEnv) parseDecl(node *treesitter.Node) Decl {
 				Pats:        pats,
 				Node:        pe.node(node),
 			})
-		} else {
+		} else if nameNode != nil {
 			// Simple pattern binding (no arguments)
 			pat = pe.parsePat(nameNode)
+		} else {
+			// Pattern binding: `(a, b) = ...`
+			pat = pe.parsePat(pe.child(node, "pattern"))
 		}
 
 		rhs := pe.parseRhs(node)
@@ -308,35 +380,84 @@ func (pe parseEnv) parsePats(nodes []treesitter.Node) []Pat {
 	return pats
 }
 
-func (pe parseEnv) parsePat(node *treesitter.Node) Pat {
+// parsePatVar parses a name in a pattern, which may be a variable or the
+// constructor of a constructor pattern.
+func (pe parseEnv) parsePatVar(node *treesitter.Node) PVar {
 	switch node.Kind() {
 	case "qualified":
 		module := strings.TrimSuffix(pe.text(pe.child(node, "module")), ".")
 		name := pe.text(pe.child(node, "id"))
-		return Pat(&PVar{
+		return PVar{
 			Name:      name,
 			Module:    module,
 			Canonical: "",
 			Node:      pe.node(node),
-		})
+		}
 
 	case "prefix_id":
 		operator := node.NamedChild(0)
 		name := pe.text(operator)
-		return Pat(&PVar{
+		return PVar{
 			Name:      name,
 			Canonical: "",
 			Module:    "",
 			Node:      pe.node(node),
-		})
+		}
 
 	case "variable", "constructor_operator", "constructor":
 		name := pe.text(node)
-		return Pat(&PVar{
+		return PVar{
 			Name:      name,
 			Canonical: "",
 			Module:    "",
 			Node:      pe.node(node),
+		}
+
+	default:
+		panic("Unknown pattern name: " + node.Kind())
+	}
+}
+
+func (pe parseEnv) parsePat(node *treesitter.Node) Pat {
+	switch node.Kind() {
+	case "qualified", "prefix_id", "variable", "constructor_operator", "constructor":
+		pvar := pe.parsePatVar(node)
+		if !unicode.IsUpper([]rune(pvar.Name)[0]) {
+			return Pat(&pvar)
+		}
+		// A constructor without arguments, such as `Nothing`
+		return Pat(&PApp{
+			Constructor: pvar,
+			Pats:        []Pat{},
+			Node:        pe.node(node),
+		})
+
+	case "as":
+		return Pat(&PAs{
+			Var:  pe.parsePatVar(pe.child(node, "bind")),
+			Pat:  pe.parsePat(pe.child(node, "pattern")),
+			Node: pe.node(node),
+		})
+
+	case "irrefutable":
+		return Pat(&PIrrefutable{
+			Pat:  pe.parsePat(pe.child(node, "pattern")),
+			Node: pe.node(node),
+		})
+
+	case "strict":
+		return Pat(&PBang{
+			Pat:  pe.parsePat(pe.child(node, "pattern")),
+			Node: pe.node(node),
+		})
+
+	case "negation":
+		// Only numeric literals can be negated in a pattern, as in `f (-1)`
+		number := pe.child(node, "number")
+		return Pat(&Lit{
+			Lit:     number.Kind(),
+			Content: "-" + pe.text(number),
+			Node:    pe.node(node),
 		})
 
 	case "literal":
@@ -345,6 +466,22 @@ func (pe parseEnv) parsePat(node *treesitter.Node) Pat {
 	case "parens":
 		return pe.parsePat(node.ChildByFieldName("pattern"))
 
+	case "record":
+		fieldNodes := pe.children(node, "field")
+		fields := make([]PFieldBind, len(fieldNodes))
+		for i, fieldNode := range fieldNodes {
+			fields[i] = PFieldBind{
+				Field: pe.parseField(pe.child(&fieldNode, "field")),
+				Pat:   pe.parsePat(pe.child(&fieldNode, "pattern")),
+				Node:  pe.node(&fieldNode),
+			}
+		}
+		return Pat(&PRecord{
+			Con:    *pe.parseExp(pe.child(node, "constructor")).(*ExpVar),
+			Fields: fields,
+			Node:   pe.node(node),
+		})
+
 	case "wildcard":
 		return Pat(&PWildcard{
 			Node: pe.node(node),
@@ -374,7 +511,7 @@ func (pe parseEnv) parsePat(node *treesitter.Node) Pat {
 				pats = append([]Pat{h}, pats...)
 				currentNode = currentNode.Child(0)
 			} else {
-				constructor = *(pe.parsePat(currentNode).(*PVar))
+				constructor = pe.parsePatVar(currentNode)
 				break
 			}
 		}
@@ -386,7 +523,7 @@ func (pe parseEnv) parsePat(node *treesitter.Node) Pat {
 		})
 
 	case "infix":
-		op := *(pe.parsePat(pe.child(node, "operator")).(*PVar))
+		op := pe.parsePatVar(pe.child(node, "operator"))
 		pat1 := pe.parsePat(pe.child(node, "left_operand"))
 		pat2 := pe.parsePat(pe.child(node, "right_operand"))
 		return Pat(&PInfix{
@@ -593,6 +730,10 @@ func (pe parseEnv) parseType(node *treesitter.Node) Type {
 			Ty2:  ty2,
 			Node: pe.node(node),
 		})
+	case "strict_field":
+		// Strictness does not change the type of a field
+		return pe.parseType(pe.child(node, "type"))
+
 	case "parens":
 		// Parenthesized type - parse the inner type
 		typeNode := pe.child(node, "type")
@@ -689,6 +830,14 @@ func (pe parseEnv) parseExp(node *treesitter.Node) Exp {
 		return Exp(pe.parseLit(node))
 
 	case "variable", "constructor", "operator", "constructor_operator":
+		// A hole unless renaming finds a binding of it
+		if node.Kind() == "variable" && strings.HasPrefix(pe.text(node), "_") {
+			return Exp(&ExpHole{
+				Name:      pe.text(node),
+				Canonical: "",
+				Node:      pe.node(node),
+			})
+		}
 		return Exp(&ExpVar{
 			Name:      pe.text(node),
 			Module:    "",
@@ -699,6 +848,31 @@ func (pe parseEnv) parseExp(node *treesitter.Node) Exp {
 	case "parens":
 		return pe.parseExp(pe.child(node, "expression"))
 
+	case "signature":
+		return Exp(&ExpTyped{
+			Exp:  pe.parseExp(pe.child(node, "expression")),
+			Ty:   pe.parseType(pe.child(node, "type")),
+			Node: pe.node(node),
+		})
+
+	case "record":
+		exp := pe.parseExp(pe.child(node, "expression"))
+		fields := pe.parseFieldBinds(pe.children(node, "field"))
+		// A constructor, possibly qualified, builds a record; any other
+		// expression is updated
+		if con, ok := exp.(*ExpVar); ok && unicode.IsUpper([]rune(con.Name)[0]) {
+			return Exp(&ExpRecordCon{
+				Con:    *con,
+				Fields: fields,
+				Node:   pe.node(node),
+			})
+		}
+		return Exp(&ExpRecordUpdate{
+			Exp:    exp,
+			Fields: fields,
+			Node:   pe.node(node),
+		})
+
 	case "boolean":
 		// Boolean expressions (used in guards, conditions, etc.)
 		// The actual expression is the child of the boolean node
@@ -896,73 +1070,43 @@ func (pe parseEnv) parseExp(node *treesitter.Node) Exp {
 
 	case "infix":
 		exps, ops := pe.flattenInfix(node)
-		exps, _ = pe.buildInfix(exps, ops)
-		return exps[0]
+		ids := make([]int, len(ops))
+		for i := range ops {
+			ids[i] = pe.id()
+		}
+		exp := associate(exps, ops, pe.fixities, func(i int, left, right Exp) Exp {
+			return Exp(&ExpInfix{
+				Exp1: left,
+				Exp2: right,
+				Op:   ops[i],
+				Node: Node{
+					id:  ids[i],
+					loc: mergeLoc(left.Loc(), right.Loc()),
+				},
+			})
+		})
+		*pe.chains = append(*pe.chains, infixChain{root: exp.(*ExpInfix), exps: exps, ops: ops, ids: ids})
+		return exp
 	}
 	return nil
 }
 
+// flattenInfix returns the operands and operators of an infix expression in
+// source order. tree-sitter nests them without knowing their fixities.
 func (pe parseEnv) flattenInfix(node *treesitter.Node) ([]Exp, []ExpVar) {
-	operatorNode := pe.child(node, "operator")
-	lhs := pe.parseExp(pe.child(node, "left_operand"))
-	rhsNode := pe.child(node, "right_operand")
-	operator, ok := pe.parseExp(operatorNode).(*ExpVar)
+	operand := func(node *treesitter.Node) ([]Exp, []ExpVar) {
+		if node.Kind() == "infix" {
+			return pe.flattenInfix(node)
+		}
+		return []Exp{pe.parseExp(node)}, nil
+	}
+	lhs, lops := operand(pe.child(node, "left_operand"))
+	operator, ok := pe.parseExp(pe.child(node, "operator")).(*ExpVar)
 	if !ok {
 		panic("Operator is not an ExpVar node")
 	}
-	if rhsNode.Kind() == "infix" {
-		exps, ops := pe.flattenInfix(rhsNode)
-		return append([]Exp{lhs}, exps...), append([]ExpVar{*operator}, ops...)
-
-	} else {
-		rhs := pe.parseExp(rhsNode)
-		return []Exp{lhs, rhs}, []ExpVar{*operator}
-	}
-}
-
-func (pe parseEnv) buildInfix(exps []Exp, ops []ExpVar) ([]Exp, []ExpVar) {
-	if len(ops) == 0 {
-		return exps, ops
-	}
-
-	highestIndex := 0
-	for i, op := range ops {
-
-		if i == 0 {
-			// The highest index is already set to 0
-			continue
-		}
-
-		prev := ops[i-1]
-		if op.Name == prev.Name && highestIndex == i-1 && pe.assoc(op.Name) == "r" {
-			// two same operators, use the first unless it's right associative
-			highestIndex = i
-			continue
-		}
-
-		if pe.fix(op.Name) > pe.fix(ops[i-1].Name) {
-			highestIndex = i
-			continue
-		}
-	}
-
-	left := exps[highestIndex]
-	right := exps[highestIndex+1]
-	op := ops[highestIndex]
-
-	exp := Exp(&ExpInfix{
-		Exp1: left,
-		Exp2: right,
-		Op:   op,
-		Node: Node{
-			id:  pe.id(),
-			loc: mergeLoc(left.Loc(), right.Loc()),
-		},
-	})
-
-	ops = slices.Concat(ops[:highestIndex], ops[highestIndex+1:])
-	exps = slices.Concat(exps[:highestIndex], []Exp{exp}, exps[highestIndex+2:])
-	return pe.buildInfix(exps, ops)
+	rhs, rops := operand(pe.child(node, "right_operand"))
+	return slices.Concat(lhs, rhs), slices.Concat(lops, []ExpVar{*operator}, rops)
 }
 
 func (pe parseEnv) parseAlts(nodes []treesitter.Node) []Alt {
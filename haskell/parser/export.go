@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"strings"
+
+	treesitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Entity is an item of an import or export list: a value, such as `foo` or
+// `(+++)`, or a type or class with some of its constructors, fields or
+// methods, such as `T(A, b)` or `T(..)`.
+type Entity struct {
+	// Name is unqualified, and operators are kept without parentheses
+	Name string
+	// Subs are the constructors, fields or methods listed in parentheses
+	Subs []string
+	// All is set for `T(..)`, which lists all of them
+	All bool
+}
+
+func (e Entity) Pretty() string {
+	result := e.Name
+	if isOperator(e.Name) {
+		result = "(" + e.Name + ")"
+	}
+	switch {
+	case e.All:
+		result += "(..)"
+	case e.Subs != nil:
+		subs := make([]string, len(e.Subs))
+		for i, sub := range e.Subs {
+			subs[i] = sub
+			if isOperator(sub) {
+				subs[i] = "(" + sub + ")"
+			}
+		}
+		result += "(" + strings.Join(subs, ", ") + ")"
+	}
+	return result
+}
+
+// Export is an item of a module's export list. `module M` exports everything
+// the module imports unqualified from M, or everything it declares if M is the
+// module itself.
+type Export struct {
+	Entity
+	// Module is set for `module M`, and the Entity is then empty
+	Module string
+	Node
+}
+
+func (e *Export) Pretty() string {
+	if e.Module != "" {
+		return "module " + e.Module
+	}
+	return e.Entity.Pretty()
+}
+func (n *Export) Loc() Loc { return n.Node.loc }
+func (n *Export) Id() int  { return n.Node.id }
+
+// ImportError is a name that an import, an export list or an occurrence gets
+// wrong: it is not exported by the module it is imported from, not in scope,
+// or brought into scope by several imports. It carries what
+// inventory.Identifier does.
+type ImportError struct {
+	Message string
+	Name    string
+	IsType  bool
+	IsTerm  bool
+	NodeId  int
+	Loc     Loc
+}
+
+// parseEntity parses an import_name or export node.
+func (pe parseEnv) parseEntity(node *treesitter.Node) Entity {
+	var entity Entity
+	for _, field := range []string{"name", "variable", "type", "operator"} {
+		nameNode := pe.child(node, field)
+		if nameNode == nil {
+			continue
+		}
+		if nameNode.Kind() == "qualified" {
+			nameNode = pe.child(nameNode, "id")
+		}
+		if nameNode.Kind() == "prefix_id" {
+			// (+++) is imported as +++
+			nameNode = nameNode.NamedChild(0)
+		}
+		entity.Name = pe.text(nameNode)
+		break
+	}
+
+	if children := pe.child(node, "children"); children != nil {
+		entity.Subs = []string{}
+		for _, element := range pe.children(children, "element") {
+			switch {
+			case !element.IsNamed():
+				continue
+			case element.Kind() == "all_names":
+				entity.All = true
+			case element.Kind() == "prefix_id":
+				entity.Subs = append(entity.Subs, pe.text(element.NamedChild(0)))
+			default:
+				entity.Subs = append(entity.Subs, pe.text(&element))
+			}
+		}
+	}
+	return entity
+}
+
+// parseExports parses the export list of a module header. It returns nil if
+// the header has none, so that the module exports everything it declares.
+func (pe parseEnv) parseExports(header *treesitter.Node) []Export {
+	exportsNode := pe.child(header, "exports")
+	if exportsNode == nil {
+		return nil
+	}
+	exports := make([]Export, 0)
+	for _, node := range pe.children(exportsNode, "*") {
+		switch node.Kind() {
+		case "export":
+			exports = append(exports, Export{Entity: pe.parseEntity(&node), Node: pe.node(&node)})
+		case "module_export":
+			exports = append(exports, Export{Module: pe.text(pe.child(&node, "module")), Node: pe.node(&node)})
+		}
+	}
+	return exports
+}
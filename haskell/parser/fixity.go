@@ -1 +1,301 @@
 package parser
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	treesitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Assoc is the associativity of an infix operator.
+type Assoc int
+
+const (
+	InfixL Assoc = iota
+	InfixR
+	// InfixN operators do not associate: `a == b == c` is an error.
+	InfixN
+)
+
+func (a Assoc) String() string {
+	switch a {
+	case InfixL:
+		return "infixl"
+	case InfixR:
+		return "infixr"
+	default:
+		return "infix"
+	}
+}
+
+// Fixity is how tightly an operator binds, from 0 to 9, and which way it
+// associates.
+type Fixity struct {
+	Assoc      Assoc
+	Precedence int
+}
+
+func (f Fixity) String() string {
+	return fmt.Sprintf("%s %d", f.Assoc, f.Precedence)
+}
+
+// DefaultFixity is the fixity of an operator without a declaration.
+var DefaultFixity = Fixity{Assoc: InfixL, Precedence: 9}
+
+// FixityTable maps operators, such as "++" or "elem", to their fixity.
+type FixityTable map[string]Fixity
+
+// Of returns the fixity of op, or DefaultFixity if the table has none.
+func (t FixityTable) Of(op string) Fixity {
+	if f, ok := t[op]; ok {
+		return f
+	}
+	return DefaultFixity
+}
+
+// PreludeFixities are the fixities the Haskell 2010 Prelude declares, plus
+// those of the Functor and Applicative operators it exports since base 4.8.
+var PreludeFixities = FixityTable{
+	"!!": {InfixL, 9},
+	".":  {InfixR, 9},
+
+	"^":  {InfixR, 8},
+	"^^": {InfixR, 8},
+	"**": {InfixR, 8},
+
+	"*":    {InfixL, 7},
+	"/":    {InfixL, 7},
+	"quot": {InfixL, 7},
+	"rem":  {InfixL, 7},
+	"div":  {InfixL, 7},
+	"mod":  {InfixL, 7},
+
+	"+": {InfixL, 6},
+	"-": {InfixL, 6},
+
+	":":  {InfixR, 5},
+	"++": {InfixR, 5},
+
+	"==":      {InfixN, 4},
+	"/=":      {InfixN, 4},
+	"<":       {InfixN, 4},
+	"<=":      {InfixN, 4},
+	">=":      {InfixN, 4},
+	">":       {InfixN, 4},
+	"elem":    {InfixN, 4},
+	"notElem": {InfixN, 4},
+	"<$>":     {InfixL, 4},
+	"<$":      {InfixL, 4},
+	"<*>":     {InfixL, 4},
+	"*>":      {InfixL, 4},
+	"<*":      {InfixL, 4},
+
+	"&&": {InfixR, 3},
+	"||": {InfixR, 2},
+
+	">>":  {InfixL, 1},
+	">>=": {InfixL, 1},
+	"=<<": {InfixR, 1},
+
+	"$":   {InfixR, 0},
+	"$!":  {InfixR, 0},
+	"seq": {InfixR, 0},
+}
+
+// FixityDecl is a fixity declaration, such as `infixr 5 ++`. Declarations
+// inside class bodies are collected too, as they are top-level in Haskell.
+type FixityDecl struct {
+	Fixity
+	Ops []string
+	Node
+}
+
+func (fd *FixityDecl) Pretty() string {
+	return fmt.Sprintf("%s %s", fd.Fixity, strings.Join(fd.Ops, ", "))
+}
+func (n *FixityDecl) Loc() Loc { return n.Node.loc }
+func (n *FixityDecl) Id() int  { return n.Node.id }
+
+// FixityConflict is a fixity declaration, import or infix expression whose
+// fixities contradict each other.
+type FixityConflict struct {
+	Message string
+	Loc     Loc
+}
+
+// infixChain is an infix expression as the source spells it, `e0 op0 e1 op1
+// e2 ...`, kept so that it can be associated again once the fixities of
+// imported operators are known. The node for ops[i] always has ids[i].
+type infixChain struct {
+	root *ExpInfix
+	exps []Exp
+	ops  []ExpVar
+	ids  []int
+}
+
+// LocalFixities returns the fixities m declares.
+func (m *Module) LocalFixities() FixityTable {
+	return fixityTable(m.Fixities)
+}
+
+// fixityTable returns the fixities decls give. The first declaration of an
+// operator wins; the others are conflicts.
+func fixityTable(decls []FixityDecl) FixityTable {
+	table := make(FixityTable)
+	for _, decl := range decls {
+		for _, op := range decl.Ops {
+			if _, ok := table[op]; !ok {
+				table[op] = decl.Fixity
+			}
+		}
+	}
+	return table
+}
+
+// ResolveFixities associates the infix expressions of m again, using the
+// fixities of the Prelude, then those in imported, then those m declares, and
+// recomputes m.FixityConflicts. imported conflicts are reported by the caller.
+func ResolveFixities(m *Module, imported FixityTable) {
+	table := maps.Clone(PreludeFixities)
+	maps.Copy(table, imported)
+	maps.Copy(table, m.LocalFixities())
+
+	m.FixityConflicts = m.declarationConflicts()
+	for _, chain := range m.chains {
+		exp := associate(chain.exps, chain.ops, table, func(i int, left, right Exp) Exp {
+			return &ExpInfix{
+				Exp1: left,
+				Exp2: right,
+				Op:   chain.ops[i],
+				Node: Node{id: chain.ids[i], loc: mergeLoc(left.Loc(), right.Loc())},
+			}
+		})
+		*chain.root = *exp.(*ExpInfix)
+		m.FixityConflicts = append(m.FixityConflicts, chainConflicts(chain.exps, chain.ops, table)...)
+	}
+}
+
+// declarationConflicts reports operators that m gives more than one fixity.
+func (m *Module) declarationConflicts() []FixityConflict {
+	conflicts := make([]FixityConflict, 0)
+	declared := make(map[string]*FixityDecl)
+	for i := range m.Fixities {
+		decl := &m.Fixities[i]
+		for _, op := range decl.Ops {
+			if first, ok := declared[op]; ok {
+				conflicts = append(conflicts, FixityConflict{
+					Message: fmt.Sprintf("conflicting fixity declarations for `%s`: `%s` and `%s`", op, first.Fixity, decl.Fixity),
+					Loc:     decl.Loc(),
+				})
+				continue
+			}
+			declared[op] = decl
+		}
+	}
+	return conflicts
+}
+
+// associate builds the tree of the chain exps[0] ops[0] exps[1] ... under
+// table by precedence climbing. node makes the node for ops[i]. Operators
+// that do not associate are grouped to the left, as chainConflicts reports
+// them anyway.
+func associate(exps []Exp, ops []ExpVar, table FixityTable, node func(i int, left, right Exp) Exp) Exp {
+	next := 0
+	var climb func(min int) Exp
+	climb = func(min int) Exp {
+		left := exps[next]
+		for next < len(ops) {
+			fixity := table.Of(ops[next].Name)
+			if fixity.Precedence < min {
+				break
+			}
+			i := next
+			next++
+			// The right operand of an infixr operator may hold operators of
+			// the same precedence
+			tighter := fixity.Precedence + 1
+			if fixity.Assoc == InfixR {
+				tighter = fixity.Precedence
+			}
+			left = node(i, left, climb(tighter))
+		}
+		return left
+	}
+	return climb(0)
+}
+
+// chainConflicts reports the neighbouring operators of a chain that have the
+// same precedence but cannot be associated, as in `a == b == c` or mixing an
+// infixl and an infixr operator of the same precedence. Operators are
+// neighbours if every operator between them binds more tightly.
+func chainConflicts(exps []Exp, ops []ExpVar, table FixityTable) []FixityConflict {
+	conflicts := make([]FixityConflict, 0)
+	for i, op := range ops {
+		fixity := table.Of(op.Name)
+		for j := i + 1; j < len(ops); j++ {
+			other := table.Of(ops[j].Name)
+			if other.Precedence > fixity.Precedence {
+				continue
+			}
+			if other.Precedence == fixity.Precedence && (other.Assoc != fixity.Assoc || fixity.Assoc == InfixN) {
+				conflicts = append(conflicts, FixityConflict{
+					Message: fmt.Sprintf("cannot mix `%s` [%s] and `%s` [%s] in the same infix expression", op.Name, fixity, ops[j].Name, other),
+					Loc:     mergeLoc(exps[i].Loc(), exps[j+1].Loc()),
+				})
+			}
+			break
+		}
+	}
+	return conflicts
+}
+
+// parseFixity parses a fixity declaration. The precedence defaults to 9.
+func (pe parseEnv) parseFixity(node *treesitter.Node) FixityDecl {
+	fixity := Fixity{Assoc: InfixN, Precedence: 9}
+	switch node.Child(0).Kind() {
+	case "infixl":
+		fixity.Assoc = InfixL
+	case "infixr":
+		fixity.Assoc = InfixR
+	}
+	if precedence := pe.child(node, "precedence"); precedence != nil {
+		n, err := strconv.Atoi(pe.text(precedence))
+		if err != nil {
+			panic("Invalid precedence: " + pe.text(precedence))
+		}
+		fixity.Precedence = n
+	}
+	ops := make([]string, 0)
+	for _, opNode := range pe.children(node, "operator") {
+		if !opNode.IsNamed() {
+			continue
+		}
+		if opNode.Kind() == "infix_id" {
+			// `elem` is declared with backticks
+			opNode = *opNode.NamedChild(0)
+		}
+		ops = append(ops, pe.text(&opNode))
+	}
+	return FixityDecl{Fixity: fixity, Ops: ops, Node: pe.node(node)}
+}
+
+// collectFixities returns the fixity declarations among the top-level
+// declarations and class bodies in dNodes.
+func (pe parseEnv) collectFixities(dNodes []treesitter.Node) []FixityDecl {
+	decls := make([]FixityDecl, 0)
+	for _, d := range dNodes {
+		switch d.Kind() {
+		case "fixity":
+			decls = append(decls, pe.parseFixity(&d))
+		case "class":
+			for _, c := range pe.children(&d, "declarations:*") {
+				if c.Kind() == "fixity" {
+					decls = append(decls, pe.parseFixity(&c))
+				}
+			}
+		}
+	}
+	return decls
+}
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixityDeclarations(t *testing.T) {
+	type testcase struct {
+		input  string
+		expect string
+	}
+
+	cases := []testcase{
+		{"infixr 6 <+>\nx = a <+> b <+> c", "x = (a <+> (b <+> c))"},
+		{"x = a <+> b * c\ninfixl 8 <+>", "x = ((a <+> b) * c)"},        // Declared after use
+		{"infixl 6 `plus`\nx = a `plus` b * c", "x = (a plus (b * c))"}, // Backticks
+		{"x = a <+> b * c", "x = ((a <+> b) * c)"},                      // infixl 9 by default
+		{"infixr 0 +\nx = a + b + c", "x = (a + (b + c))"},              // Shadows the Prelude
+		{"class C a where\n  (<>) :: a -> a -> a\n  infixr 6 <>\nx = a <> b <> c", "x = (a <> (b <> c))"},
+	}
+
+	for _, tc := range cases {
+		module := parse([]byte(tc.input), "Main")
+		assert.Contains(t, module.Pretty(), tc.expect, "Output should contain expected")
+		assert.Empty(t, module.FixityConflicts)
+	}
+}
+
+func TestParseFixity(t *testing.T) {
+	module := parse([]byte("infixl 6 <+>, `plus`\ninfix ==="), "Main")
+	assert.Equal(t, []string{"infixl 6 <+>, plus", "infix 9 ==="}, []string{module.Fixities[0].Pretty(), module.Fixities[1].Pretty()})
+	assert.Equal(t, FixityTable{
+		"<+>":  {InfixL, 6},
+		"plus": {InfixL, 6},
+		"===":  {InfixN, 9},
+	}, module.LocalFixities())
+}
+
+func TestFixityConflicts(t *testing.T) {
+	type testcase struct {
+		input     string
+		conflicts int
+	}
+
+	cases := []testcase{
+		{"x = a == b == c", 1},
+		{"x = a == b + c == d", 1},
+		{"x = a == b && c == d", 0},
+		{"infixr 6 <+>\nx = a + b <+> c", 1},
+		{"infixl 6 <+>\nx = a + b <+> c", 0},
+		{"infixl 6 <+>\ninfixr 6 <+>\nx = a", 1},
+		{"x = (a == b) == c", 0},
+	}
+
+	for _, tc := range cases {
+		module := parse([]byte(tc.input), "Main")
+		assert.Len(t, module.FixityConflicts, tc.conflicts, tc.input)
+	}
+}
+
+func TestResolveFixities(t *testing.T) {
+	module := parse([]byte("x = a <+> b * c"), "Main")
+	ids := make([]int, 0)
+	traverser := NewTraverser(
+		func(v int, ast AST, parent AST) int {
+			if infix, ok := ast.(*ExpInfix); ok {
+				ids = append(ids, infix.Id())
+			}
+			return v
+		},
+		func(_ int, ast AST, parent AST) {},
+		0,
+	)
+	traverser.Visit(module, nil)
+
+	ResolveFixities(module, FixityTable{"<+>": {InfixR, 5}})
+	assert.Contains(t, module.Pretty(), "x = (a <+> (b * c))")
+	resolved := make([]int, 0)
+	traverser = NewTraverser(
+		func(v int, ast AST, parent AST) int {
+			if infix, ok := ast.(*ExpInfix); ok {
+				resolved = append(resolved, infix.Id())
+			}
+			return v
+		},
+		func(_ int, ast AST, parent AST) {},
+		0,
+	)
+	traverser.Visit(module, nil)
+	assert.ElementsMatch(t, ids, resolved, "operators keep their node IDs")
+}
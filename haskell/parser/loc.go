@@ -57,8 +57,8 @@ func (l Loc) Equal(other Loc) bool {
 func mergeLoc(l1 Loc, l2 Loc) Loc {
 	return Loc{
 		fromLine: l1.fromLine,
-		toLine:   l1.toLine,
-		fromCol:  l2.fromCol,
+		toLine:   l2.toLine,
+		fromCol:  l1.fromCol,
 		toCol:    l2.toCol,
 	}
 }
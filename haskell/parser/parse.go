@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,56 +11,12 @@ import (
 	treesitterhaskell "github.com/tree-sitter/tree-sitter-haskell/bindings/go"
 )
 
-var fixity = map[string]int{
-	".":   9,
-	"$":   0,
-	"==":  4,
-	"/=":  4,
-	"<":   4,
-	">":   4,
-	"<=":  4,
-	">=":  4,
-	"&&":  3,
-	"||":  2,
-	"++":  5,
-	"<$>": 4,
-	"<*>": 4,
-	">>":  1,
-	">>=": 1,
-	"+":   6,
-	"-":   6,
-	"*":   7,
-	"/":   7,
-}
-
-var associativity = map[string]string{
-	".":   "r",
-	"$":   "r",
-	"==":  "l",
-	"/=":  "l",
-	"<":   "l",
-	">":   "l",
-	"<=":  "l",
-	">=":  "l",
-	"&&":  "r",
-	"||":  "r",
-	"++":  "r",
-	"<$>": "l",
-	"<*>": "l",
-	">>":  "l",
-	">>=": "l",
-	"+":   "l",
-	"-":   "l",
-	"*":   "l",
-	"/":   "l",
-}
-
 type parseEnv struct {
-	counter       *int
-	source        []byte
-	cursor        *treesitter.TreeCursor
-	fixity        map[string]int
-	associativity map[string]string
+	counter  *int
+	source   []byte
+	cursor   *treesitter.TreeCursor
+	fixities FixityTable
+	chains   *[]infixChain
 }
 
 func (pe parseEnv) id() int {
@@ -131,14 +88,6 @@ func (pe parseEnv) child(node *treesitter.Node, path string) *treesitter.Node {
 	return currentNode
 }
 
-func (pe parseEnv) fix(sym string) int {
-	return pe.fixity[sym]
-}
-
-func (pe parseEnv) assoc(sym string) string {
-	return pe.associativity[sym]
-}
-
 // Parse parses Haskell code and returns the AST as a Module
 // ParseWithCounter parses Haskell source using the given shared node ID counter,
 // so that multiple modules can have globally unique node IDs.
@@ -164,19 +113,20 @@ func parseWithCounter(code []byte, altname string, counter *int) *Module {
 	root := tree.RootNode()
 	cursor := root.Walk()
 	pe := parseEnv{
-		counter:       counter,
-		source:        code,
-		cursor:        cursor,
-		fixity:        fixity,
-		associativity: associativity,
+		counter: counter,
+		source:  code,
+		cursor:  cursor,
+		chains:  &[]infixChain{},
 	}
 	children := root.Children(cursor)
 
 	moduleName := altname
+	var exports []Export
 	for _, child := range children {
 		if child.Kind() == "header" {
 			node := pe.child(&child, "module")
 			moduleName = pe.text(node)
+			exports = pe.parseExports(&child)
 		}
 	}
 
@@ -191,7 +141,12 @@ func parseWithCounter(code []byte, altname string, counter *int) *Module {
 		}
 	}
 
+	// Operators may be used before their fixity is declared
 	dNodes := pe.children(root, "declarations:*")
+	fixities := pe.collectFixities(dNodes)
+	pe.fixities = maps.Clone(PreludeFixities)
+	maps.Copy(pe.fixities, fixityTable(fixities))
+
 	var decls []Decl
 	for _, d := range dNodes {
 		decl := pe.parseDecl(&d)
@@ -200,12 +155,17 @@ func parseWithCounter(code []byte, altname string, counter *int) *Module {
 		}
 	}
 
-	return &Module{
-		Name:    moduleName,
-		Decls:   decls,
-		Imports: imports,
-		Node:    pe.node(root),
+	module := &Module{
+		Name:     moduleName,
+		Exports:  exports,
+		Decls:    decls,
+		Imports:  imports,
+		Fixities: fixities,
+		Node:     pe.node(root),
+		chains:   *pe.chains,
 	}
+	ResolveFixities(module, nil)
+	return module
 }
 
 // GuessModuleName converts a file path to a Haskell module name relative to baseDir.
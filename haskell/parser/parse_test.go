@@ -181,8 +181,11 @@ func TestInfixOperators(t *testing.T) {
 		{"x = a . b $ c", "x = ((a . b) $ c)"},           // Mixed operators
 		{"x = a . b . c $ d", "x = ((a . (b . c)) $ d)"}, // Complex
 		{"x = a ++ b ++ c", "x = (a ++ (b ++ c))"},       // String concat (right associative)
-		{"x = a : b : c", "x = ((a : b) : c)"},           // Cons (left associative in this parser)
-		{"x = 2 ^ 3 ^ 2", "x = ((2 ^ 3) ^ 2)"},           // Exponentiation (left associative in this parser)
+		{"x = a : b : c", "x = (a : (b : c))"},           // Cons (right associative)
+		{"x = 2 ^ 3 ^ 2", "x = (2 ^ (3 ^ 2))"},           // Exponentiation (right associative)
+		{"x = a : b ++ c", "x = (a : (b ++ c))"},         // Same precedence, both right associative
+		{"x = a `div` b + c", "x = ((a div b) + c)"},     // Backticks
+		{"x = f <$> a <*> b", "x = ((f <$> a) <*> b)"},   // Applicative
 	}
 
 	for _, tc := range cases {
@@ -362,6 +365,7 @@ func TestLetExpressions(t *testing.T) {
 		{"x = let y = 1; z = y in z", "x = let {y = 1; z = y} in z"},
 		{"x = let a = 1; b = 2 in a + b", "x = let {a = 1; b = 2} in (a + b)"},
 		// Function binding in let removed due to parsing issue
+		{"x = let (a, b) = (1, 2) in a", "x = let {(a, b) = (1, 2)} in a"},
 	}
 
 	for _, tc := range cases {
@@ -431,14 +435,24 @@ func TestPatterns(t *testing.T) {
 	}
 
 	cases := []testcase{
-		{"f _ = 1", "f _ = 1"},                     // Wildcard pattern
-		{"f [] = 0", "f [] = 0"},                   // Empty list pattern
-		{"f [x] = x", "f [x] = x"},                 // Single element list
-		{"f [x, y] = x", "f [x, y] = x"},           // Multi-element list
-		{"f (x, y) = x", "f (x, y) = x"},           // Tuple pattern
-		{"f (x, y, z) = x", "f (x, y, z) = x"},     // Triple pattern
-		{"f (x:xs) = x", "f (x : xs) = x"},         // Infix cons pattern
-		{"f (x:y:zs) = x", "f (x : (y : zs)) = x"}, // Multiple cons
+		{"f _ = 1", "f _ = 1"},                             // Wildcard pattern
+		{"f [] = 0", "f [] = 0"},                           // Empty list pattern
+		{"f [x] = x", "f [x] = x"},                         // Single element list
+		{"f [x, y] = x", "f [x, y] = x"},                   // Multi-element list
+		{"f (x, y) = x", "f (x, y) = x"},                   // Tuple pattern
+		{"f (x, y, z) = x", "f (x, y, z) = x"},             // Triple pattern
+		{"f (x:xs) = x", "f (x : xs) = x"},                 // Infix cons pattern
+		{"f (x:y:zs) = x", "f (x : (y : zs)) = x"},         // Multiple cons
+		{"f all@(x:xs) = all", "f all@(x : xs) = all"},     // As-pattern
+		{"f ~(a, b) = a", "f ~(a, b) = a"},                 // Irrefutable
+		{"f !n = n", "f !n = n"},                           // Bang
+		{"f (-1) = 0", "f (-1) = 0"},                       // Negative literal
+		{"f (-1.5) = 0", "f (-1.5) = 0"},                   // Negative float
+		{"f \"s\" 'c' = 0", "f \"s\" 'c' = 0"},             // String and char
+		{"f (Just (Left x)) = x", "f (Just (Left x)) = x"}, // Nested constructors
+		{"f (Just Nothing) = 0", "f (Just Nothing) = 0"},   // Constructor without arguments
+		{"f xs@(Just !y) = xs", "f xs@(Just !y) = xs"},     // Nested as and bang
+		{"f = \\ ~(a, b) -> a", "f = (\\~(a, b) -> a)"},    // In a lambda
 	}
 
 	for _, tc := range cases {
@@ -447,6 +461,36 @@ func TestPatterns(t *testing.T) {
 	}
 }
 
+func TestHoles(t *testing.T) {
+	type testcase struct {
+		input  string
+		expect string
+	}
+
+	cases := []testcase{
+		{"x = _", "x = _"},
+		{"x = _foo", "x = _foo"},
+		{"x = f _ 1", "x = ((f _) 1)"},
+		{"f _ = _", "f _ = _"},
+	}
+
+	for _, tc := range cases {
+		output := parse([]byte(tc.input), "Main").Pretty()
+		assert.Equal(t, withModule(tc.expect), output, "Output should equal expected")
+	}
+
+	m := parse([]byte("x = map _f [1]"), "Main")
+	holes := make([]string, 0)
+	visitor := NewTraverser(func(_ int, ast AST, _ AST) int {
+		if hole, ok := ast.(*ExpHole); ok {
+			holes = append(holes, hole.Name)
+		}
+		return 0
+	}, nil, 0)
+	visitor.Visit(m, nil)
+	assert.Equal(t, []string{"_f"}, holes)
+}
+
 func TestGuardedRhs(t *testing.T) {
 	type testcase struct {
 		input  string
@@ -562,6 +606,8 @@ func TestImportStatements(t *testing.T) {
 		{"import qualified Data.Vector as V", "import qualified Data.Vector as V"},       // Qualified with alias
 		{"import Data.Text (Text, pack)", "import Data.Text (Text, pack)"},               // Import specific items
 		{"import Data.Maybe hiding (catMaybes)", "import Data.Maybe hiding (catMaybes)"}, // Import hiding
+		{"import Data.Map (Map(..), (!), lookup)", "import Data.Map (Map(..), (!), lookup)"},
+		{"import Shape (Shape(Circle, area))", "import Shape (Shape(Circle, area))"},
 	}
 
 	for _, tc := range cases {
@@ -570,3 +616,93 @@ func TestImportStatements(t *testing.T) {
 		assert.Contains(t, output, tc.expect, "Output should contain the import statement")
 	}
 }
+
+func TestExports(t *testing.T) {
+	type testcase struct {
+		input  string
+		expect string
+	}
+
+	cases := []testcase{
+		{"module M where\nx = 1", "module M where"},
+		{"module M () where\nx = 1", "module M () where"},
+		{"module M (x, T(..), C(m, (<+>)), module Data.List, (+++)) where\nx = 1", "module M (x, T(..), C(m, (<+>)), module Data.List, (+++)) where"},
+	}
+
+	for _, tc := range cases {
+		output := parse([]byte(tc.input), "M").Pretty()
+		assert.Contains(t, output, tc.expect, "Output should contain the module header")
+	}
+
+	module := parse([]byte("module M (x, T(..), S(A), module M) where\nimport L (U(..), y)\nx = 1"), "M")
+	assert.Equal(t, []Export{
+		{Entity: Entity{Name: "x"}},
+		{Entity: Entity{Name: "T", Subs: []string{}, All: true}},
+		{Entity: Entity{Name: "S", Subs: []string{"A"}}},
+		{Module: "M"},
+	}, clearNodes(module.Exports))
+	assert.Equal(t, []Entity{{Name: "U", Subs: []string{}, All: true}, {Name: "y"}}, module.Imports[0].Items)
+
+	assert.Nil(t, parse([]byte("x = 1"), "M").Exports)
+	assert.Nil(t, parse([]byte("import L\nx = 1"), "M").Imports[0].Items)
+}
+
+// clearNodes drops the nodes of exports, so that they can be compared.
+func clearNodes(exports []Export) []Export {
+	result := make([]Export, len(exports))
+	for i, export := range exports {
+		result[i] = Export{Entity: export.Entity, Module: export.Module}
+	}
+	return result
+}
+
+func TestNewtype(t *testing.T) {
+	type testcase struct {
+		input  string
+		expect string
+	}
+
+	cases := []testcase{
+		{"newtype Age = Age Int", "newtype Age = Age Int"},
+		{"newtype W a = W { unW :: a } deriving Show", "newtype W a = W {unW :: a} deriving (Show)"},
+		{"newtype Age = Age Int deriving (Eq, Ord)", "newtype Age = Age Int deriving (Eq, Ord)"},
+		{"data C = R | G deriving Eq deriving Show", "data C = R | G deriving (Eq, Show)"}, // Several clauses
+	}
+
+	for _, tc := range cases {
+		output := parse([]byte(tc.input), "Main").Pretty()
+		assert.Contains(t, output, tc.expect, "Output should contain expected")
+	}
+
+	module := parse([]byte("newtype Age = Age Int"), "Main")
+	decl := module.Decls[0].(*DataDecl)
+	assert.True(t, decl.NewType)
+	assert.Len(t, decl.Constructors, 1)
+	assert.Len(t, decl.Constructors[0].FieldTypes(), 1)
+}
+
+func TestPatternNodes(t *testing.T) {
+	module := parse([]byte("f Nothing (-1) = 0"), "Main")
+	pats := module.Decls[0].(*PatBind).Pat.(*PApp).Pats
+	assert.IsType(t, &PApp{}, pats[0])
+	assert.Equal(t, &Lit{Lit: "integer", Content: "-1", Node: pats[1].(*Lit).Node}, pats[1])
+}
+
+func TestExpTyped(t *testing.T) {
+	type testcase struct {
+		input  string
+		expect string
+	}
+
+	cases := []testcase{
+		{"x = (1 :: Int)", "x = (1 :: Int)"},
+		{"x = (1 :: Int) + 2", "x = ((1 :: Int) + 2)"},
+		{"x = f (y :: Maybe a)", "x = (f (y :: (Maybe a)))"},
+		{"x = [] :: [Int]", "x = ([] :: [Int])"},
+	}
+
+	for _, tc := range cases {
+		output := parse([]byte(tc.input), "Main").Pretty()
+		assert.Equal(t, withModule(tc.expect), output, "Output should equal expected")
+	}
+}
@@ -29,9 +29,12 @@ func formatNodeLine(ast AST, withCanonical bool) string {
 		inner += ", " + name
 		if withCanonical {
 			var canonical string
-			if _, ok := ast.(*TypeSig); ok {
+			switch node := ast.(type) {
+			case *TypeSig:
 				canonical = getTypeSigCanonicals(ast)
-			} else {
+			case *FieldDecl:
+				canonical = strings.Join(node.Canonicals, ", ")
+			default:
 				canonical = getCanonical(ast)
 			}
 			if canonical != "" && canonical != name {
@@ -54,6 +57,8 @@ func getCanonical(ast AST) string {
 		return node.Canonical
 	case *ExpVar:
 		return node.Canonical
+	case *ExpHole:
+		return node.Canonical
 	case *InstDecl:
 		return node.Canonical
 	case *DataCon:
@@ -100,6 +105,8 @@ func getNodeName(ast AST) string {
 			return fmt.Sprintf("%s.%s", node.Module, node.Name)
 		}
 		return node.Name
+	case *ExpHole:
+		return node.Name
 	case *InstDecl:
 		return node.Name
 	case *DataCon:
@@ -110,6 +117,8 @@ func getNodeName(ast AST) string {
 		return node.Name
 	case *TypeSig:
 		return strings.Join(node.Names, ", ")
+	case *FieldDecl:
+		return strings.Join(node.Names, ", ")
 	case *Assertion:
 		if node.Module != "" {
 			return node.Module + "." + node.Name
@@ -153,6 +162,18 @@ func printChildrenWith(ast AST, indent int, fn printFn) {
 
 	case *DataCon:
 		printList("types", node.Tys, indent, func(_ int, t Type) AST { return t }, fn)
+		printList("fields", node.Fields, indent, func(i int, _ FieldDecl) AST { return &node.Fields[i] }, fn)
+
+	case *FieldDecl:
+		fn(node.Ty, indent)
+
+	case *FieldBind:
+		fn(&node.Field, indent)
+		fn(node.Exp, indent)
+
+	case *PFieldBind:
+		fn(&node.Field, indent)
+		fn(node.Pat, indent)
 
 	case *Assertion:
 		printList("types", node.Types, indent, func(_ int, t Type) AST { return t }, fn)
@@ -190,6 +211,8 @@ func printChildrenWith(ast AST, indent int, fn printFn) {
 
 	case *ExpVar: // leaf
 
+	case *ExpHole: // leaf
+
 	case *ExpApp:
 		fn(node.Exp1, indent)
 		fn(node.Exp2, indent)
@@ -245,6 +268,18 @@ func printChildrenWith(ast AST, indent int, fn printFn) {
 		printList("generators", node.Generators, indent, func(i int, _ Generator) AST { return &node.Generators[i] }, fn)
 		printList("guards", node.Guards, indent, func(_ int, e Exp) AST { return e }, fn)
 
+	case *ExpRecordCon:
+		fn(&node.Con, indent)
+		printList("fields", node.Fields, indent, func(i int, _ FieldBind) AST { return &node.Fields[i] }, fn)
+
+	case *ExpRecordUpdate:
+		fn(node.Exp, indent)
+		printList("fields", node.Fields, indent, func(i int, _ FieldBind) AST { return &node.Fields[i] }, fn)
+
+	case *ExpTyped:
+		fn(node.Exp, indent)
+		fn(node.Ty, indent)
+
 	case *Lit: // leaf
 
 	case *UnguardedRhs:
@@ -285,11 +320,25 @@ func printChildrenWith(ast AST, indent int, fn printFn) {
 
 	case *PVar: // leaf
 
+	case *PRecord:
+		fn(&node.Con, indent)
+		printList("fields", node.Fields, indent, func(i int, _ PFieldBind) AST { return &node.Fields[i] }, fn)
+
 	case *PInfix:
 		fn(node.Pat1, indent)
 		fn(&node.Op, indent)
 		fn(node.Pat2, indent)
 
+	case *PAs:
+		fn(&node.Var, indent)
+		fn(node.Pat, indent)
+
+	case *PIrrefutable:
+		fn(node.Pat, indent)
+
+	case *PBang:
+		fn(node.Pat, indent)
+
 	case *TyCon: // leaf
 
 	case *TyApp:
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRecords(t *testing.T) {
+	type testcase struct {
+		input  string
+		expect string
+	}
+
+	cases := []testcase{
+		{"data P = P { name :: String, age, size :: !Int }", "data P = P {name :: String, age, size :: Int}"},
+		{"data T a = Q Int a | Int :+ a", "data T a = Q Int a | Int :+ a"},
+		{"x = P { name = \"x\", age = 1 }", "x = P {name = \"x\", age = 1}"},
+		{"x = p { age = 3 }", "x = p {age = 3}"},
+		{"x = case p of P { age = a } -> a", "P {age = a} -> a"},
+	}
+
+	for _, tc := range cases {
+		module := parse([]byte(tc.input), "Main")
+		assert.Contains(t, module.Pretty(), tc.expect, "Output should contain expected")
+	}
+}
+
+func TestDataConFields(t *testing.T) {
+	module := parse([]byte("data P a = P { name :: String, age, size :: Int } | Q Int a | Int :+ a"), "Main")
+	cons := module.Decls[0].(*DataDecl).Constructors
+
+	assert.Equal(t, []string{"P", "Q", ":+"}, []string{cons[0].Name, cons[1].Name, cons[2].Name})
+	assert.Len(t, cons[0].Fields, 2)
+	assert.Equal(t, []string{"age", "size"}, cons[0].Fields[1].Names)
+	assert.Len(t, cons[0].FieldTypes(), 3)
+	assert.Len(t, cons[1].FieldTypes(), 2)
+	assert.Len(t, cons[2].FieldTypes(), 2)
+}
+
+func TestParseRecordExps(t *testing.T) {
+	module := parse([]byte("x = P { age = 1 }\ny = p { age = 2 }"), "Main")
+	con, ok := module.Decls[0].(*PatBind).Rhs.(*UnguardedRhs).Exp.(*ExpRecordCon)
+	assert.True(t, ok)
+	assert.Equal(t, "P", con.Con.Name)
+	update, ok := module.Decls[1].(*PatBind).Rhs.(*UnguardedRhs).Exp.(*ExpRecordUpdate)
+	assert.True(t, ok)
+	assert.Equal(t, "age", update.Fields[0].Field.Name)
+}
@@ -39,6 +39,17 @@ func (t Traverser[T]) visit(ast AST, parent AST, data T) {
 		for _, ty := range node.Tys {
 			t.visit(ty, node, childData)
 		}
+		for i := range node.Fields {
+			t.visit(&node.Fields[i], node, childData)
+		}
+	case *FieldDecl:
+		t.visit(node.Ty, node, childData)
+	case *FieldBind:
+		t.visit(&node.Field, node, childData)
+		t.visit(node.Exp, node, childData)
+	case *PFieldBind:
+		t.visit(&node.Field, node, childData)
+		t.visit(node.Pat, node, childData)
 	case *Alt:
 		t.visit(node.Exp, node, childData)
 		t.visit(node.Pat, node, childData)
@@ -86,6 +97,7 @@ func (t Traverser[T]) visit(ast AST, parent AST, data T) {
 
 	// Exp
 	case *ExpVar:
+	case *ExpHole:
 	case *ExpApp:
 		t.visit(node.Exp1, node, childData)
 		t.visit(node.Exp2, node, childData)
@@ -143,6 +155,19 @@ func (t Traverser[T]) visit(ast AST, parent AST, data T) {
 		for _, guard := range node.Guards {
 			t.visit(guard, node, childData)
 		}
+	case *ExpRecordCon:
+		t.visit(&node.Con, node, childData)
+		for i := range node.Fields {
+			t.visit(&node.Fields[i], node, childData)
+		}
+	case *ExpRecordUpdate:
+		t.visit(node.Exp, node, childData)
+		for i := range node.Fields {
+			t.visit(&node.Fields[i], node, childData)
+		}
+	case *ExpTyped:
+		t.visit(node.Exp, node, childData)
+		t.visit(node.Ty, node, childData)
 	case *Lit:
 	// RHS
 	case *GuardedRhs:
@@ -190,10 +215,22 @@ func (t Traverser[T]) visit(ast AST, parent AST, data T) {
 			t.visit(pat, node, childData)
 		}
 	case *PVar:
+	case *PRecord:
+		t.visit(&node.Con, node, childData)
+		for i := range node.Fields {
+			t.visit(&node.Fields[i], node, childData)
+		}
 	case *PInfix:
 		t.visit(node.Pat1, node, childData)
 		t.visit(node.Pat2, node, childData)
 		t.visit(&node.Op, node, childData)
+	case *PAs:
+		t.visit(&node.Var, node, childData)
+		t.visit(node.Pat, node, childData)
+	case *PIrrefutable:
+		t.visit(node.Pat, node, childData)
+	case *PBang:
+		t.visit(node.Pat, node, childData)
 	// Types
 	case *TyCon:
 	case *TyApp:
@@ -3,11 +3,9 @@ package haskell
 import (
 	"fmt"
 	mapset "github.com/deckarep/golang-set/v2"
-	"goanna/inventory"
 	prolog_tool "goanna/prolog-tool"
 	"slices"
 	"strings"
-	"text/template"
 )
 
 type constructorType int
@@ -25,18 +23,21 @@ type Pair struct {
 }
 
 type MetaVar struct {
-	tmpl           string
-	skolem         bool
-	preferredName  string
-	appearedInJobs mapset.Set[int]
-	typeClasses    mapset.Set[string]
-	friendlyName   string
+	skolem        bool
+	preferredName string
+	typeClasses   mapset.Set[string]
+	friendlyName  string
 }
 
+// Printer turns the types Prolog answers with into Types. Types prepared by
+// the same printer share their type variables, which are named once all of
+// them have been prepared.
 type Printer struct {
 	varMapping map[string]*MetaVar
-	classes    map[string][]string
-	currentJob int
+	// Lookup names in the order they were first seen, so that naming does not
+	// depend on map order
+	order   []string
+	classes map[string][]string
 }
 
 func NewPrinter(classes map[string][]string) *Printer {
@@ -44,22 +45,19 @@ func NewPrinter(classes map[string][]string) *Printer {
 	return &Printer{
 		classes:    classes,
 		varMapping: varmapping,
-		currentJob: 0,
+		order:      make([]string, 0),
 	}
 }
 
-func (p *Printer) getOrCreateVarName(lookupName string, preferSameName bool) string {
-	if mv, ok := p.varMapping[lookupName]; ok {
-		p.varMapping[lookupName].appearedInJobs.Add(p.currentJob)
-		return mv.tmpl
-	} else {
+// getOrCreateVar returns a variable named by its lookup name, until
+// CompileType gives it a friendly name.
+func (p *Printer) getOrCreateVar(lookupName string, preferSameName bool) Type {
+	if _, ok := p.varMapping[lookupName]; !ok {
 		newVar := MetaVar{
-			tmpl:           fmt.Sprintf("{{ $.%s }}", lookupName),
-			skolem:         false,
-			preferredName:  "",
-			appearedInJobs: mapset.NewSet(p.currentJob),
-			typeClasses:    mapset.NewSet[string](),
-			friendlyName:   "",
+			skolem:        false,
+			preferredName: "",
+			typeClasses:   mapset.NewSet[string](),
+			friendlyName:  "",
 		}
 
 		if preferSameName {
@@ -67,16 +65,17 @@ func (p *Printer) getOrCreateVarName(lookupName string, preferSameName bool) str
 			newVar.preferredName = strings.Split(lookupName, "__")[0] // Remove the namespace of type var
 		}
 		p.varMapping[lookupName] = &newVar
-		return newVar.tmpl
+		p.order = append(p.order, lookupName)
 	}
+	return VarType(lookupName)
 }
 
-func (p *Printer) printVar(term prolog_tool.Var) string {
-	return p.getOrCreateVarName(term.Value, false)
+func (p *Printer) printVar(term prolog_tool.Var) Type {
+	return p.getOrCreateVar(term.Value, false)
 }
 
-func (p *Printer) printSkolemVar(term prolog_tool.Atom) string {
-	return p.getOrCreateVarName(term.Value, true)
+func (p *Printer) printSkolemVar(term prolog_tool.Atom) Type {
+	return p.getOrCreateVar(term.Value, true)
 }
 
 func makePair(term prolog_tool.Term) Pair {
@@ -118,16 +117,12 @@ func unrollADT(term prolog_tool.Term) []prolog_tool.Term {
 	}
 }
 
-func (p *Printer) printAtom(term prolog_tool.Atom) string {
+func (p *Printer) printAtom(term prolog_tool.Atom) Type {
 	if term.Value == "builtin_Top" {
-		return "()"
+		return ConType("()")
 	}
 	parts := strings.Split(term.Value, "_")
-	if len(parts) == 1 {
-		return parts[0]
-	} else {
-		return parts[len(parts)-1]
-	}
+	return ConType(parts[len(parts)-1])
 }
 
 func adtIsTuple(term prolog_tool.Term) bool {
@@ -162,12 +157,11 @@ func unrollFunction(term prolog_tool.Term) []prolog_tool.Term {
 	return []prolog_tool.Term{term}
 }
 
-func (p *Printer) printCompound(term prolog_tool.Compound) string {
-	//fmt.Printf("Term: %v\n", term)
+func (p *Printer) printCompound(term prolog_tool.Compound) Type {
 	switch {
 	case term.Value == "has":
 		typeClasses := term.Args[0].(prolog_tool.List)
-		var typeVar string
+		var typeVar Type
 		var lookupString string
 		switch arg1 := term.Args[1].(type) {
 		case prolog_tool.Atom:
@@ -190,50 +184,35 @@ func (p *Printer) printCompound(term prolog_tool.Compound) string {
 
 	case makePair(term).conType == list:
 		content := makePair(term).first
-		return "[" + p.PrintTerm(content) + "]"
+		return ListType(p.toType(content))
 
 	case makePair(term).conType == adt:
 		args := unrollADT(term)
 
 		if adtIsTuple(args[0]) {
-			tupleElems := args[1:]
-			argsText := make([]string, len(tupleElems))
-			for i, elem := range tupleElems {
-				argsText[i] = p.PrintTerm(elem)
+			tupleElems := make([]Type, len(args)-1)
+			for i, elem := range args[1:] {
+				tupleElems[i] = p.toType(elem)
 			}
-			return "(" + strings.Join(argsText, ",") + ")"
+			return TupleType(tupleElems...)
 		}
 
 		if isFunction(term) {
-			argStrings := make([]string, len(term.Args))
 			functionArgs := unrollFunction(term)
-			for i, arg := range functionArgs {
-				if isFunction(arg) && i != len(term.Args)-1 {
-					argStrings[i] = "(" + p.PrintTerm(arg) + ")"
-				} else {
-					argStrings[i] = p.PrintTerm(arg)
-				}
-			}
-
-			return strings.Join(argStrings, "->")
-
+			return FunType(p.toType(functionArgs[0]), p.toType(functionArgs[1]))
 		}
 
-		argsText := make([]string, len(args))
-		for i, arg := range args {
-			if makePair(arg).conType == adt {
-				argsText[i] = "(" + p.PrintTerm(arg) + ")"
-			} else {
-				argsText[i] = p.PrintTerm(arg)
-			}
+		typeArgs := make([]Type, len(args)-1)
+		for i, arg := range args[1:] {
+			typeArgs[i] = p.toType(arg)
 		}
-		return strings.Join(argsText, " ")
+		return AppType(p.toType(args[0]), typeArgs...)
 	default:
 		panic("Unknown compound type")
 	}
 }
 
-func (p *Printer) PrintTerm(term prolog_tool.Term) string {
+func (p *Printer) toType(term prolog_tool.Term) Type {
 	switch t := term.(type) {
 	case prolog_tool.Atom:
 		return p.printAtom(t)
@@ -250,48 +229,25 @@ func (p *Printer) PrintTerm(term prolog_tool.Term) string {
 }
 
 func findSuitableTypeVarName(preferredName string, usedNames []string) string {
-	if slices.Contains(usedNames, preferredName) {
-		var finalName string
-		for n := range 100 {
-			name := fmt.Sprintf("%s__%d", preferredName, n)
-			if slices.Contains(usedNames, preferredName) {
-				continue
-			}
-			finalName = name
-			break
-		}
-		return finalName
-	} else {
-		return preferredName
+	name := preferredName
+	for n := 1; slices.Contains(usedNames, name); n++ {
+		name = fmt.Sprintf("%s%d", preferredName, n)
 	}
+	return name
 }
 
 func findAvailableTypeVarName(usedNames []string) string {
-	for _, c := range "abcdefghijklmnopqrstuvwxyz" {
-		letter := string(c)
-		if slices.Contains(usedNames, letter) {
-			continue
-		}
-		return letter
-	}
-
-	for _, c := range "abcdefghijklmnopqrstuvwxyz" {
-		letter := string(c) + "__0"
-		if slices.Contains(usedNames, letter) {
-			continue
-		}
-		return letter
-	}
-
-	for _, c := range "abcdefghijklmnopqrstuvwxyz" {
-		letter := string(c) + "__1"
-		if slices.Contains(usedNames, letter) {
-			continue
+	for n := 0; ; n++ {
+		for _, c := range "abcdefghijklmnopqrstuvwxyz" {
+			name := string(c)
+			if n != 0 {
+				name = fmt.Sprintf("%c%d", c, n)
+			}
+			if !slices.Contains(usedNames, name) {
+				return name
+			}
 		}
-		return letter
 	}
-
-	panic("Could not find available type variable")
 }
 
 func allAssignedNames(varMap map[string]*MetaVar) []string {
@@ -304,48 +260,47 @@ func allAssignedNames(varMap map[string]*MetaVar) []string {
 	return result
 }
 
-func (p *Printer) PrepareType(term prolog_tool.Term, jobId int) string {
-	p.currentJob = jobId
-	typeString := p.PrintTerm(term)
-	return typeString
+// PrepareType converts a term whose variables are named later, by AssignVars.
+func (p *Printer) PrepareType(term prolog_tool.Term) Type {
+	return p.toType(term)
 }
 
+// AssignVars names the variables of every prepared type, in the order they
+// were first seen: variables of signatures keep their names and the others
+// get names after their classes or the first free letter.
 func (p *Printer) AssignVars() {
-	for lookupName, metaVar := range p.varMapping {
-		names := allAssignedNames(p.varMapping)
+	for _, lookupName := range p.order {
+		metaVar := p.varMapping[lookupName]
 		if metaVar.skolem {
-			if slices.Contains(names, metaVar.preferredName) {
-				p.varMapping[lookupName].friendlyName = findSuitableTypeVarName(metaVar.preferredName, names)
-			}
-			metaVar.friendlyName = metaVar.preferredName
-			continue
+			metaVar.friendlyName = findSuitableTypeVarName(metaVar.preferredName, allAssignedNames(p.varMapping))
 		}
 	}
 
-	for lookupName, metaVar := range p.varMapping {
+	for _, lookupName := range p.order {
+		metaVar := p.varMapping[lookupName]
 		names := allAssignedNames(p.varMapping)
 		if metaVar.skolem {
 			continue
 		}
 
 		if metaVar.typeClasses.Contains("p_Monad") {
-			p.varMapping[lookupName].friendlyName = findSuitableTypeVarName("m", names)
+			metaVar.friendlyName = findSuitableTypeVarName("m", names)
 			continue
 		}
 
 		if metaVar.typeClasses.Contains("p_Applicative") ||
 			metaVar.typeClasses.Contains("p_Alternative") ||
 			metaVar.typeClasses.Contains("p_Functor") {
-			p.varMapping[lookupName].friendlyName = findSuitableTypeVarName("f", names)
+			metaVar.friendlyName = findSuitableTypeVarName("f", names)
 			continue
 		}
 
 		if metaVar.typeClasses.Contains("p_Foldable") {
-			p.varMapping[lookupName].friendlyName = findSuitableTypeVarName("t", names)
+			metaVar.friendlyName = findSuitableTypeVarName("t", names)
 			continue
 		}
 
-		p.varMapping[lookupName].friendlyName = findAvailableTypeVarName(names)
+		metaVar.friendlyName = findAvailableTypeVarName(names)
 	}
 }
 
@@ -383,66 +338,25 @@ func removeModulePrefix(class string) string {
 	}
 }
 
-func (p *Printer) CompileType(templateStr string, jobId int) string {
-	classRequirements := make([]string, 0)
-	for _, metaVar := range p.varMapping {
-		classes := metaVar.typeClasses
-		if !metaVar.appearedInJobs.Contains(jobId) {
-			continue
-		}
-		if classes == nil {
-			continue
+// CompileType gives the variables of a prepared type their names and their
+// classes, without the classes implied by others.
+func (p *Printer) CompileType(t Type) Type {
+	return t.Map(func(t Type) Type {
+		if t.Kind != KindVar {
+			return t
 		}
-		reducedClasses := normalizeContext(classes.ToSlice(), p.classes)
-		renamedClasses := make([]string, len(reducedClasses))
-		for i, class := range reducedClasses {
-			renamedClasses[i] = removeModulePrefix(class)
+		metaVar := p.varMapping[t.Name]
+		var classes []string
+		for _, class := range normalizeContext(metaVar.typeClasses.ToSlice(), p.classes) {
+			classes = append(classes, removeModulePrefix(class))
 		}
-
-		for _, c := range renamedClasses {
-			classRequirements = append(classRequirements, c+" "+metaVar.tmpl)
-		}
-	}
-
-	var context string
-	if len(classRequirements) == 0 {
-		context = ""
-	} else if len(classRequirements) == 1 {
-		context = classRequirements[0] + "=>"
-	} else {
-		slices.Sort(classRequirements)
-		context = "(" + strings.Join(classRequirements, ",") + ")=>"
-	}
-
-	var varFriendlyNames = make(map[string]string)
-	for lookupName, metaVar := range p.varMapping {
-		varFriendlyNames[lookupName] = metaVar.friendlyName
-	}
-
-	tmpl := template.Must(template.New("").Parse(context + templateStr))
-	printType := inventory.TemplateToString(tmpl, varFriendlyNames)
-	return printType
+		slices.Sort(classes)
+		return VarType(metaVar.friendlyName, classes...)
+	})
 }
 
-func (p *Printer) GetType(term prolog_tool.Term) string {
-	templateStr := p.PrepareType(term, 0)
+func (p *Printer) GetType(term prolog_tool.Term) Type {
+	t := p.PrepareType(term)
 	p.AssignVars()
-	return p.CompileType(templateStr, 0)
-}
-
-func TestReconstruct() {
-	prologStr := "pair(pair(p_Either,builtin_Int),builtin_Char)"
-	prologTerm, err := prolog_tool.ParseTerm(prologStr)
-	if err != nil {
-		panic(err)
-	}
-	fmt.Printf("prolog: %+v\n", prologTerm)
-	adts := unrollADT(prologTerm)
-	for _, adt := range adts {
-		fmt.Printf("adt: %+v\n", adt)
-	}
-	printer := NewPrinter(make(map[string][]string))
-	s := printer.GetType(prologTerm)
-	fmt.Println(s)
-
+	return p.CompileType(t)
 }
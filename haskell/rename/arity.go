@@ -0,0 +1,68 @@
+package rename
+
+import (
+	"fmt"
+	"goanna/haskell/parser"
+)
+
+// checkArities reports every equation of a function in m that has a
+// different number of arguments than the first equation of the function.
+func checkArities(m *parser.Module, diagnostics *[]Diagnostic) {
+	visitor := parser.NewTraverser(
+		func(_ int, ast parser.AST, parent parser.AST) int {
+			switch node := ast.(type) {
+			case *parser.Module:
+				checkEquations(node.Decls, m, diagnostics)
+			case *parser.UnguardedRhs:
+				checkEquations(node.Wheres, m, diagnostics)
+			case *parser.GuardedRhs:
+				checkEquations(node.Wheres, m, diagnostics)
+			case *parser.ExpLet:
+				checkEquations(node.Binds, m, diagnostics)
+			case *parser.LetStmt:
+				checkEquations(node.Binds, m, diagnostics)
+			case *parser.ClassDecl:
+				checkEquations(node.Decls, m, diagnostics)
+			case *parser.InstDecl:
+				checkEquations(node.Body, m, diagnostics)
+			}
+			return 0
+		},
+		nil,
+		0,
+	)
+	visitor.Visit(m, nil)
+}
+
+// checkEquations reports the equations among decls, a list of declarations
+// in one scope, whose arity differs from that of the first equation of their
+// function.
+func checkEquations(decls []parser.Decl, m *parser.Module, diagnostics *[]Diagnostic) {
+	arities := make(map[string]int)
+	for _, decl := range decls {
+		pb, ok := decl.(*parser.PatBind)
+		if !ok {
+			continue
+		}
+		var name string
+		arity := 0
+		switch p := pb.Pat.(type) {
+		case *parser.PVar:
+			name = p.Name
+		case *parser.PApp:
+			name, arity = p.Constructor.Name, len(p.Pats)
+		default:
+			continue
+		}
+
+		first, ok := arities[name]
+		if !ok {
+			arities[name] = arity
+			continue
+		}
+		if arity != first {
+			message := fmt.Sprintf("equations for `%s` have different numbers of arguments: %d and %d", name, first, arity)
+			report(diagnostics, m, ArityMismatch, message, name, false, pb, nil)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package rename
+
+import (
+	"fmt"
+	"goanna/haskell/parser"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// DiagnosticKind is what is wrong with a name.
+type DiagnosticKind int
+
+const (
+	// UnboundVariable is a variable or constructor that is not in scope.
+	UnboundVariable DiagnosticKind = iota
+	UnknownType
+	UnknownClass
+	// AmbiguousOccurrence is a name that several imported modules export.
+	AmbiguousOccurrence
+	// NotExported is a name imported, or used through an import, from a
+	// module that does not export it.
+	NotExported
+	// ArityMismatch is an equation of a function with a different number of
+	// arguments than its first equation.
+	ArityMismatch
+	// InvalidDoBlock is a do block whose last statement is not an
+	// expression.
+	InvalidDoBlock
+)
+
+func (k DiagnosticKind) String() string {
+	switch k {
+	case UnboundVariable:
+		return "unbound variable"
+	case UnknownType:
+		return "unknown type"
+	case UnknownClass:
+		return "unknown class"
+	case AmbiguousOccurrence:
+		return "ambiguous occurrence"
+	case NotExported:
+		return "not exported"
+	case ArityMismatch:
+		return "arity mismatch"
+	case InvalidDoBlock:
+		return "invalid do block"
+	default:
+		return fmt.Sprintf("DiagnosticKind(%d)", int(k))
+	}
+}
+
+// malformed reports whether diagnostics of kind are about malformed code
+// rather than names.
+func (k DiagnosticKind) malformed() bool {
+	return k == ArityMismatch || k == InvalidDoBlock
+}
+
+// Diagnostic is a name renaming cannot resolve, or a declaration or
+// expression that is malformed, such as an equation whose arity does not
+// match its function. Names are also added to the ImportErrors of their
+// module, and malformed code to its Diagnostics.
+type Diagnostic struct {
+	parser.ImportError
+	Kind DiagnosticKind
+	// Module is the module the name occurs in
+	Module string
+	// Suggestions are the names in scope the name may be a misspelling of,
+	// closest first
+	Suggestions []string
+}
+
+func (d Diagnostic) String() string {
+	if len(d.Suggestions) == 0 {
+		return d.Message
+	}
+	suggestions := make([]string, len(d.Suggestions))
+	for i, s := range d.Suggestions {
+		suggestions[i] = "`" + s + "`"
+	}
+	return fmt.Sprintf("%s (did you mean %s?)", d.Message, strings.Join(suggestions, " or "))
+}
+
+// report adds a diagnostic for name at node, which occurs in m, to
+// diagnostics, and to the ImportErrors or Diagnostics of m.
+func report(diagnostics *[]Diagnostic, m *parser.Module, kind DiagnosticKind, message string, name string, isType bool, node parser.AST, suggestions []string) {
+	importError := parser.ImportError{
+		Message: message,
+		Name:    name,
+		IsType:  isType,
+		IsTerm:  !isType,
+		NodeId:  node.Id(),
+		Loc:     node.Loc(),
+	}
+	if kind.malformed() {
+		m.Diagnostics = append(m.Diagnostics, parser.Diagnostic{Message: message, NodeId: node.Id(), Loc: node.Loc()})
+	} else {
+		m.ImportErrors = append(m.ImportErrors, importError)
+	}
+	*diagnostics = append(*diagnostics, Diagnostic{
+		ImportError: importError,
+		Kind:        kind,
+		Module:      m.Name,
+		Suggestions: suggestions,
+	})
+}
+
+// maxSuggestions is the number of suggestions a diagnostic carries at most.
+const maxSuggestions = 3
+
+// suggest returns the names in scope that name may be a misspelling of: those
+// within an edit distance of a third of its length, closest first. Operators
+// are only suggested for operators, and constructors for constructors.
+func suggest(name string, scope []string) []string {
+	cutoff := (len([]rune(name)) + 2) / 3
+	distances := make(map[string]int)
+	for _, candidate := range scope {
+		if isSymbol(candidate) != isSymbol(name) || isConstructor(candidate) != isConstructor(name) {
+			continue
+		}
+		if d := editDistance(name, candidate); d > 0 && d <= cutoff {
+			distances[candidate] = d
+		}
+	}
+
+	suggestions := make([]string, 0, len(distances))
+	for candidate := range distances {
+		suggestions = append(suggestions, candidate)
+	}
+	slices.SortFunc(suggestions, func(a, b string) int {
+		if distances[a] != distances[b] {
+			return distances[a] - distances[b]
+		}
+		return strings.Compare(a, b)
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
+
+// isSymbol reports whether name is an operator.
+func isSymbol(name string) bool {
+	first := []rune(name)[0]
+	return !unicode.IsLetter(first) && first != '_'
+}
+
+// editDistance returns the restricted Damerau-Levenshtein distance between a
+// and b: the number of insertions, deletions, substitutions and transpositions
+// of adjacent characters that turn one into the other.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+// imports returns the imports of module current, together with the implicit
+// import of the Prelude if it does not import it explicitly.
+func imports(current string, importMap map[string][]parser.Import) []parser.Import {
+	result := importMap[current]
+	if !slices.ContainsFunc(result, func(imp parser.Import) bool { return imp.Module == preludeModule }) {
+		result = append(slices.Clone(result), parser.Import{Module: preludeModule})
+	}
+	return result
+}
+
+// isExternal reports whether name, qualified by qualifier, may be brought
+// into scope in module current by an import of a module that is not part of
+// the program, such as the Prelude.
+func (r RenameResult) isExternal(name string, qualifier string, isType bool, current string, importMap map[string][]parser.Import) bool {
+	for _, imp := range imports(current, importMap) {
+		if _, ok := r.exports[imp.Module]; ok || !qualifies(imp, qualifier) {
+			continue
+		}
+		if imp.Module == preludeModule {
+			n := exportedName{module: preludeModule, name: name, isType: isType}
+			if parent, ok := preludeExports[n]; ok && admits(imp, n, parent) {
+				return true
+			}
+			continue
+		}
+
+		// What other modules export is unknown, so any name they may export
+		// is assumed to be
+		if imp.Items == nil {
+			return true
+		}
+		listed := slices.ContainsFunc(imp.Items, func(item parser.Entity) bool {
+			return item.Name == name || (!imp.Hiding && (item.All || slices.Contains(item.Subs, name)))
+		})
+		if listed != imp.Hiding {
+			return true
+		}
+	}
+	return false
+}
+
+// preludeNames returns the names of the Prelude in scope in module current
+// with qualifier.
+func preludeNames(qualifier string, isType bool, current string, importMap map[string][]parser.Import) []string {
+	names := []string{}
+	for _, imp := range imports(current, importMap) {
+		if imp.Module != preludeModule || !qualifies(imp, qualifier) {
+			continue
+		}
+		for n, parent := range preludeExports {
+			if n.isType == isType && admits(imp, n, parent) {
+				names = append(names, n.name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,181 @@
+package rename
+
+import (
+	"goanna/haskell/parser"
+	"slices"
+	"testing"
+)
+
+// renameDiagnostics parses and renames the modules whose code is given, and
+// returns the diagnostics of renaming.
+func renameDiagnostics(codes ...string) []Diagnostic {
+	counter := 0
+	modules := make([]*parser.Module, len(codes))
+	for i, code := range codes {
+		modules[i] = parser.ParseWithCounter([]byte(code), "Main", &counter)
+	}
+	return RenameAll(modules)
+}
+
+func TestDiagnostics(t *testing.T) {
+	type testcase struct {
+		code        string
+		kind        DiagnosticKind
+		name        string
+		line, col   int
+		suggestions []string
+	}
+
+	cases := []testcase{
+		{"f xs = lenght xs", UnboundVariable, "lenght", 0, 7, []string{"length"}},
+		{"f x = x + y\ny' = 1", UnboundVariable, "y", 0, 10, []string{"f", "x", "y'"}},
+		{"f = Jsut 1", UnboundVariable, "Jsut", 0, 4, []string{"Just"}},
+		{"f (Jsut x) = x", UnboundVariable, "Jsut", 0, 3, []string{"Just"}},
+		{"data Colour = Red\nf :: Colur -> Int\nf _ = 1", UnknownType, "Colur", 1, 5, []string{"Colour"}},
+		{"f :: Shw a => a -> String\nf = show", UnknownClass, "Shw", 0, 5, []string{"Show"}},
+		{"data T = T deriving (Eq, Sho)", UnknownClass, "Sho", 0, 25, []string{"Show"}},
+		{"import Prelude hiding (map)\nf = map", UnboundVariable, "map", 1, 4, []string{"fmap", "mapM", "max"}},
+		{"f = Prelude.mapp", UnboundVariable, "mapp", 0, 4, []string{"map", "mapM", "fmap"}},
+		{"f = do\n  line <- pure line\n  pure line", UnboundVariable, "line", 1, 15, []string{"lines", "min", "sin"}},
+		{"f = do\n  putStrLn s\n  let s = \"\"\n  pure s", UnboundVariable, "s", 1, 11, []string{"f"}},
+		{"f = do\n  x <- getLine", InvalidDoBlock, "x <- getLine", 1, 2, nil},
+		{"f = do\n  pure ()\n  let x = 1", InvalidDoBlock, "let x = 1", 2, 2, nil},
+	}
+
+	for _, tc := range cases {
+		diagnostics := renameDiagnostics(tc.code)
+		if len(diagnostics) != 1 {
+			t.Errorf("%q: expected 1 diagnostic, got %v", tc.code, diagnostics)
+			continue
+		}
+		d := diagnostics[0]
+		if d.Kind != tc.kind || d.Name != tc.name || d.Module != "Main" {
+			t.Errorf("%q: expected %v for '%s', got %v for '%s'", tc.code, tc.kind, tc.name, d.Kind, d.Name)
+		}
+		if d.Loc.FromLine() != tc.line || d.Loc.FromCol() != tc.col {
+			t.Errorf("%q: expected the diagnostic at %d:%d, got %d:%d", tc.code, tc.line, tc.col, d.Loc.FromLine(), d.Loc.FromCol())
+		}
+		if !slices.Equal(d.Suggestions, tc.suggestions) {
+			t.Errorf("%q: expected suggestions %v, got %v", tc.code, tc.suggestions, d.Suggestions)
+		}
+	}
+}
+
+func TestNoDiagnostics(t *testing.T) {
+	codes := []string{
+		"f xs = map show (filter even xs) ++ [\"\"]",
+		"f :: (Ord a, Show a) => Maybe a -> Either String ()\nf Nothing = Left \"\"\nf (Just _) = Right ()",
+		"data T = T Int deriving (Show, Eq)\ng (T n) = n + 1",
+		"import Data.Char (toUpper)\nf = map toUpper",
+		"import qualified Data.Map as M\nf m = M.lookup 1 m",
+		"import Data.List\nf = sortBy compare",
+		"f x = g 1 where\n  g y = x + y + k\n  k = x",
+		"f = a + b where (a, b) = (1, 2)",
+		"f = g 1 where\n  g 0 = 0\n  g n = h n\n  h n = g (n - 1)",
+		"f = do\n  x <- getLine\n  let y = x\n      g z = z\n  putStrLn (g y)",
+	}
+
+	for _, code := range codes {
+		if diagnostics := renameDiagnostics(code); len(diagnostics) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", code, diagnostics)
+		}
+	}
+}
+
+func TestImportDiagnostics(t *testing.T) {
+	diagnostics := renameDiagnostics(
+		"module Lib (lookupAll) where\nlookupAll = 1\nhidden = 2",
+		"module A where\nh = 1",
+		"module B where\nh = 2",
+		"module Main where\nimport Lib (lookupAl)\nimport A\nimport B\nx = (hidden, h)")
+
+	kinds := []DiagnosticKind{}
+	for _, d := range diagnostics {
+		kinds = append(kinds, d.Kind)
+	}
+	if !slices.Equal(kinds, []DiagnosticKind{NotExported, NotExported, AmbiguousOccurrence}) {
+		t.Errorf("Expected two not exported and an ambiguous occurrence, got %v", diagnostics)
+		return
+	}
+	if !slices.Equal(diagnostics[0].Suggestions, []string{"lookupAll"}) {
+		t.Errorf("Expected 'lookupAll' to be suggested, got %v", diagnostics[0].Suggestions)
+	}
+	if diagnostics[0].Loc.FromLine() != 1 || diagnostics[1].Loc.FromLine() != 4 {
+		t.Errorf("Expected the diagnostics at the import and the occurrence, got %v", diagnostics)
+	}
+}
+
+func TestArityMismatch(t *testing.T) {
+	counter := 0
+	module := parser.ParseWithCounter([]byte("f [] = 0\nf (x:xs) y = x\ng = h 1 where\n  h 0 = 0\n  h = id\nk 0 = 1\nk n = n"), "Main", &counter)
+	diagnostics := RenameAll([]*parser.Module{module})
+
+	// Arity mismatches are not import errors
+	if len(module.ImportErrors) != 0 || len(module.Diagnostics) != 2 {
+		t.Errorf("Expected 2 module diagnostics and no import errors, got %v and %v", module.Diagnostics, module.ImportErrors)
+	}
+
+	if len(diagnostics) != 2 {
+		t.Errorf("Expected 2 diagnostics, got %v", diagnostics)
+		return
+	}
+	for i, expect := range []struct {
+		name string
+		line int
+	}{{"f", 1}, {"h", 4}} {
+		d := diagnostics[i]
+		if d.Kind != ArityMismatch || d.Name != expect.name || d.Loc.FromLine() != expect.line {
+			t.Errorf("Expected an arity mismatch for '%s' at line %d, got %v for '%s' at line %d", expect.name, expect.line, d.Kind, d.Name, d.Loc.FromLine())
+		}
+	}
+}
+
+func TestInvalidDoBlock(t *testing.T) {
+	counter := 0
+	module := parser.ParseWithCounter([]byte("f = do\n  x <- getLine"), "Main", &counter)
+	diagnostics := RenameAll([]*parser.Module{module})
+
+	if len(diagnostics) != 1 || diagnostics[0].String() != "the last statement in a do block must be an expression, not `x <- getLine`" {
+		t.Errorf("Expected a diagnostic naming the last statement, got %v", diagnostics)
+	}
+	if len(module.ImportErrors) != 0 || len(module.Diagnostics) != 1 {
+		t.Errorf("Expected 1 module diagnostic and no import errors, got %v and %v", module.Diagnostics, module.ImportErrors)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	type testcase struct {
+		a, b     string
+		distance int
+	}
+
+	cases := []testcase{
+		{"", "", 0},
+		{"map", "map", 0},
+		{"map", "mapp", 1},
+		{"lenght", "length", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+
+	for _, tc := range cases {
+		if d := editDistance(tc.a, tc.b); d != tc.distance {
+			t.Errorf("Expected the distance between '%s' and '%s' to be %d, got %d", tc.a, tc.b, tc.distance, d)
+		}
+	}
+}
+
+func TestDiagnosticKindString(t *testing.T) {
+	cases := map[DiagnosticKind]string{
+		UnboundVariable:    "unbound variable",
+		ArityMismatch:      "arity mismatch",
+		InvalidDoBlock:     "invalid do block",
+		InvalidDoBlock + 1: "DiagnosticKind(7)",
+	}
+
+	for kind, expect := range cases {
+		if s := kind.String(); s != expect {
+			t.Errorf("Expected %s, got %s", expect, s)
+		}
+	}
+}
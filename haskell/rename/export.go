@@ -0,0 +1,290 @@
+package rename
+
+import (
+	"fmt"
+	"goanna/haskell/parser"
+	"maps"
+	"slices"
+	"unicode"
+)
+
+// exportedName is a top-level name together with the module that declares
+// it. Types and classes share a namespace; terms have their own.
+type exportedName struct {
+	module string
+	name   string
+	isType bool
+}
+
+// exportSet maps the names a module exports to the type or class they belong
+// to, if they are constructors, fields or methods, and to "" otherwise.
+type exportSet map[exportedName]string
+
+// declaredNames returns the top-level names m declares.
+func declaredNames(m *parser.Module) exportSet {
+	names := make(exportSet)
+	term := func(name, parent string) {
+		names[exportedName{module: m.Name, name: name}] = parent
+	}
+	typ := func(name string) {
+		names[exportedName{module: m.Name, name: name, isType: true}] = ""
+	}
+
+	for _, decl := range m.Decls {
+		switch d := decl.(type) {
+		case *parser.PatBind:
+			switch p := d.Pat.(type) {
+			case *parser.PVar:
+				term(p.Name, "")
+			case *parser.PApp:
+				term(p.Constructor.Name, "")
+			}
+		case *parser.TypeSig:
+			for _, name := range d.Names {
+				term(name, "")
+			}
+		case *parser.DataDecl:
+			typ(d.DHead.Name)
+			for _, con := range d.Constructors {
+				term(con.Name, d.DHead.Name)
+				for _, field := range con.Fields {
+					for _, name := range field.Names {
+						term(name, d.DHead.Name)
+					}
+				}
+			}
+		case *parser.TypeDecl:
+			typ(d.DHead.Name)
+		case *parser.ClassDecl:
+			typ(d.DHead.Name)
+			for _, inner := range d.Decls {
+				if sig, ok := inner.(*parser.TypeSig); ok {
+					for _, name := range sig.Names {
+						term(name, d.DHead.Name)
+					}
+				}
+			}
+		}
+	}
+
+	// An operator with a fixity declaration is declared by the module even
+	// if its definition is missing
+	for op := range m.LocalFixities() {
+		if _, ok := names[exportedName{module: m.Name, name: op}]; !ok {
+			term(op, "")
+		}
+	}
+	return names
+}
+
+// moduleExports returns the names each module exports. A module without an
+// export list exports everything it declares. Modules may re-export what they
+// import, so the exports grow until none of them changes.
+func moduleExports(modules []*parser.Module) map[string]exportSet {
+	declared := make(map[string]exportSet)
+	exports := make(map[string]exportSet)
+	for _, m := range modules {
+		declared[m.Name] = declaredNames(m)
+		exports[m.Name] = make(exportSet)
+		if m.Exports == nil {
+			exports[m.Name] = declared[m.Name]
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, m := range modules {
+			if m.Exports == nil {
+				continue
+			}
+			next := exportsOf(m, declared[m.Name], exports)
+			if len(next) != len(exports[m.Name]) {
+				changed = true
+			}
+			exports[m.Name] = next
+		}
+	}
+	return exports
+}
+
+// exportsOf returns the names the export list of m exports, given the names m
+// declares and those the modules it imports export.
+func exportsOf(m *parser.Module, declared exportSet, exports map[string]exportSet) exportSet {
+	result := make(exportSet)
+	for _, export := range m.Exports {
+		if export.Module == m.Name {
+			maps.Copy(result, declared)
+			continue
+		}
+		if export.Module != "" {
+			// `module M` exports what is in scope both as x and as M.x
+			for _, imp := range m.Imports {
+				if imp.Qualified || (imp.Module != export.Module && imp.Alias != export.Module) {
+					continue
+				}
+				for n, parent := range exports[imp.Module] {
+					if admits(imp, n, parent) {
+						result[n] = parent
+					}
+				}
+			}
+			continue
+		}
+
+		for n, parent := range inScope(m, declared, exports) {
+			if matches(export.Entity, n, parent) {
+				result[n] = parent
+			}
+		}
+	}
+	return result
+}
+
+// inScope returns the top-level names in scope in m, declared or imported.
+func inScope(m *parser.Module, declared exportSet, exports map[string]exportSet) exportSet {
+	names := maps.Clone(declared)
+	for _, imp := range m.Imports {
+		for n, parent := range exports[imp.Module] {
+			if admits(imp, n, parent) {
+				names[n] = parent
+			}
+		}
+	}
+	return names
+}
+
+// matches reports whether entity names n, whose type or class is parent.
+// Constructors can only be named through their type, as in `T(A)`.
+func matches(entity parser.Entity, n exportedName, parent string) bool {
+	if n.isType {
+		return entity.Name == n.name
+	}
+	if parent != "" && entity.Name == parent && (entity.All || slices.Contains(entity.Subs, n.name)) {
+		return true
+	}
+	return entity.Name == n.name && !isConstructor(n.name)
+}
+
+// admits reports whether imp brings n, whose type or class is parent, into
+// scope. Hiding a name hides the constructor of the same name too.
+func admits(imp parser.Import, n exportedName, parent string) bool {
+	if imp.Items == nil {
+		return true
+	}
+	for _, item := range imp.Items {
+		if matches(item, n, parent) || (imp.Hiding && item.Name == n.name) {
+			return !imp.Hiding
+		}
+	}
+	return imp.Hiding
+}
+
+// qualifies reports whether the names imp brings into scope may be written
+// with qualifier, which is empty for unqualified names.
+func qualifies(imp parser.Import, qualifier string) bool {
+	if qualifier == "" {
+		return !imp.Qualified
+	}
+	if imp.Alias != "" {
+		return qualifier == imp.Alias
+	}
+	return qualifier == imp.Module
+}
+
+// isConstructor reports whether name is a data constructor, which starts with
+// an uppercase letter or, for an operator, with a colon.
+func isConstructor(name string) bool {
+	first := []rune(name)[0]
+	return unicode.IsUpper(first) || first == ':'
+}
+
+// isVisible reports whether n can be written in module current with
+// qualifier, which is empty for unqualified names, through one of its
+// imports.
+func (r RenameResult) isVisible(n exportedName, current string, qualifier string, importMap map[string][]parser.Import) bool {
+	for _, imp := range importMap[current] {
+		if !qualifies(imp, qualifier) {
+			continue
+		}
+		parent, ok := r.exports[imp.Module][n]
+		if ok && admits(imp, n, parent) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkImports reports the items of the imports of m that the modules they
+// name do not export. Modules that are not part of the program, and hidden
+// items, are not checked.
+func checkImports(m *parser.Module, exports map[string]exportSet, diagnostics *[]Diagnostic) {
+	for i := range m.Imports {
+		imp := &m.Imports[i]
+		exported, ok := exports[imp.Module]
+		if !ok || imp.Hiding {
+			continue
+		}
+		for _, item := range imp.Items {
+			found := false
+			names := []string{}
+			subs := make(map[string]bool)
+			for n, parent := range exported {
+				if n.isType || !isConstructor(n.name) {
+					names = append(names, n.name)
+					found = found || n.name == item.Name
+				}
+				if parent == item.Name {
+					subs[n.name] = true
+				}
+			}
+			if !found {
+				report(diagnostics, m, NotExported, fmt.Sprintf("module `%s` does not export `%s`", imp.Module, item.Name),
+					item.Name, isConstructor(item.Name), imp, suggest(item.Name, names))
+				continue
+			}
+			for _, sub := range item.Subs {
+				if !subs[sub] {
+					report(diagnostics, m, NotExported, fmt.Sprintf("module `%s` does not export `%s(%s)`", imp.Module, item.Name, sub),
+						sub, false, imp, suggest(sub, slices.Collect(maps.Keys(subs))))
+				}
+			}
+		}
+	}
+}
+
+// checkExports reports the items of the export list of m that are not in
+// scope in m, unless a module outside the program may export them.
+func checkExports(m *parser.Module, result RenameResult, importMap map[string][]parser.Import, diagnostics *[]Diagnostic) {
+	scope := inScope(m, declaredNames(m), result.exports)
+	for i := range m.Exports {
+		export := &m.Exports[i]
+		if export.Module != "" {
+			imported := slices.ContainsFunc(m.Imports, func(imp parser.Import) bool {
+				return !imp.Qualified && (imp.Module == export.Module || imp.Alias == export.Module)
+			})
+			if export.Module != m.Name && !imported {
+				report(diagnostics, m, NotExported, fmt.Sprintf("`module %s` is exported but not imported", export.Module),
+					export.Module, false, export, nil)
+			}
+			continue
+		}
+
+		found := false
+		isType := isConstructor(export.Name)
+		names := []string{}
+		for n, parent := range scope {
+			found = found || matches(export.Entity, n, parent)
+			if n.isType == isType {
+				names = append(names, n.name)
+			}
+		}
+		if !found && !result.isExternal(export.Name, "", isType, m.Name, importMap) {
+			kind := UnboundVariable
+			if isType {
+				kind = UnknownType
+			}
+			report(diagnostics, m, kind, fmt.Sprintf("`%s` is exported but not in scope", export.Name),
+				export.Name, isType, export, suggest(export.Name, names))
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package rename
+
+import (
+	"goanna/haskell/parser"
+	"strings"
+	"testing"
+)
+
+const exportLib = "module Lib (T(..), S(A), f, C(m)) where\ndata T = T1 | T2\ndata S = A | B\nclass C a where\n  m :: a -> a\n  n :: a -> a\nf = 1\ng = 2"
+
+// renameProgram parses and renames the modules whose code is given, and
+// returns the last one.
+func renameProgram(codes ...string) *parser.Module {
+	counter := 0
+	modules := make([]*parser.Module, len(codes))
+	for i, code := range codes {
+		name := strings.Fields(code)[1]
+		modules[i] = parser.ParseWithCounter([]byte(code), name, &counter)
+	}
+	RenameAll(modules)
+	return modules[len(modules)-1]
+}
+
+// resolvedVars returns, for each variable module uses, whether it resolves to
+// an identifier.
+func resolvedVars(module *parser.Module) map[string]bool {
+	resolved := make(map[string]bool)
+	visitor := parser.NewTraverser(
+		func(_ int, ast parser.AST, parent parser.AST) int {
+			if v, ok := ast.(*parser.ExpVar); ok {
+				name := v.Name
+				if v.Module != "" {
+					name = v.Module + "." + v.Name
+				}
+				resolved[name] = v.Canonical != v.Name
+			}
+			return 0
+		},
+		nil,
+		0,
+	)
+	visitor.Visit(module, nil)
+	return resolved
+}
+
+func expectResolved(t *testing.T, module *parser.Module, expect map[string]bool) {
+	resolved := resolvedVars(module)
+	for name, ok := range expect {
+		if resolved[name] != ok {
+			t.Errorf("Expected '%s' to be resolved: %v, got %v", name, ok, resolved[name])
+		}
+	}
+}
+
+func expectImportErrors(t *testing.T, module *parser.Module, messages ...string) {
+	if len(module.ImportErrors) != len(messages) {
+		t.Errorf("Expected %d import errors, got %v", len(messages), module.ImportErrors)
+		return
+	}
+	for i, message := range messages {
+		if !strings.Contains(module.ImportErrors[i].Message, message) {
+			t.Errorf("Expected import error containing '%s', got '%s'", message, module.ImportErrors[i].Message)
+		}
+	}
+}
+
+func TestExportList(t *testing.T) {
+	main := renameProgram(exportLib, "module Main where\nimport Lib\nx = (f, g, T1, A, B, m, n)")
+
+	expectResolved(t, main, map[string]bool{"f": true, "g": false, "T1": true, "A": true, "B": false, "m": true, "n": false})
+	expectImportErrors(t, main,
+		"module `Lib` does not export `g`",
+		"module `Lib` does not export `B`",
+		"module `Lib` does not export `n`")
+}
+
+func TestImportItems(t *testing.T) {
+	main := renameProgram(exportLib, "module Main where\nimport Lib (T(T1), f)\nx = (f, T1, T2, A)")
+
+	expectResolved(t, main, map[string]bool{"f": true, "T1": true, "T2": false, "A": false})
+	expectImportErrors(t, main,
+		"`T2` is not imported from module `Lib`",
+		"`A` is not imported from module `Lib`")
+
+	main = renameProgram(exportLib, "module Main where\nimport Lib (g, T(T3))")
+	expectImportErrors(t, main,
+		"module `Lib` does not export `g`",
+		"module `Lib` does not export `T(T3)`")
+}
+
+func TestImportHiding(t *testing.T) {
+	main := renameProgram(exportLib, "module Main where\nimport Lib hiding (f, A)\nx = (f, T1, A)")
+
+	expectResolved(t, main, map[string]bool{"f": false, "T1": true, "A": false})
+	expectImportErrors(t, main,
+		"`f` is not imported from module `Lib`",
+		"`A` is not imported from module `Lib`")
+}
+
+func TestImportQualified(t *testing.T) {
+	main := renameProgram(exportLib, "module Main where\nimport qualified Lib as L\nx = (L.f, f, Lib.f)")
+
+	expectResolved(t, main, map[string]bool{"L.f": true, "f": false, "Lib.f": false})
+
+	main = renameProgram(exportLib, "module Main where\nimport qualified Lib\nx = (Lib.f, f)")
+	expectResolved(t, main, map[string]bool{"Lib.f": true, "f": false})
+}
+
+func TestReexport(t *testing.T) {
+	main := renameProgram(
+		exportLib,
+		"module Re (module Lib, h) where\nimport Lib (f, T(..))\nh = 3",
+		"module Main where\nimport Re\nx = (f, T1, h, A)")
+
+	expectResolved(t, main, map[string]bool{"f": true, "T1": true, "h": true, "A": false})
+	expectImportErrors(t, main, "module `Re` does not export `A`")
+}
+
+func TestAmbiguousOccurrence(t *testing.T) {
+	main := renameProgram(
+		"module A where\nh = 1",
+		"module B where\nh = 2",
+		"module Main where\nimport A\nimport B\nx = h\nh' = A.h")
+
+	expectResolved(t, main, map[string]bool{"h": true, "A.h": true})
+	expectImportErrors(t, main, "ambiguous occurrence `h`: it could refer to `h` from A or B")
+
+	// A local declaration shadows the imported ones
+	main = renameProgram(
+		"module A where\nh = 1",
+		"module B where\nh = 2",
+		"module Main where\nimport A\nimport B\nh = 3\nx = h")
+	expectImportErrors(t, main)
+}
+
+func TestExportNotInScope(t *testing.T) {
+	lib := renameProgram("module Lib (f, g, module Data.List) where\nf = 1")
+
+	expectImportErrors(t, lib,
+		"`g` is exported but not in scope",
+		"`module Data.List` is exported but not imported")
+}
@@ -0,0 +1,83 @@
+package rename
+
+import (
+	"cmp"
+	"fmt"
+	"goanna/haskell/parser"
+	"maps"
+	"slices"
+)
+
+// ResolveFixities associates the infix expressions of every module again with
+// the fixities it imports from the other modules. An operator keeps the
+// fixity of the module that defines it, however many modules re-export it.
+// Where two imports give an operator different fixities, every infix
+// expression or pattern that uses it gets a conflict.
+func ResolveFixities(modules []*parser.Module) {
+	importMap := BuildImportMap(modules)
+	exports := moduleExports(modules)
+	declared := make(map[string]parser.FixityTable)
+	for _, module := range modules {
+		declared[module.Name] = module.LocalFixities()
+	}
+
+	for _, module := range modules {
+		imported := make(parser.FixityTable)
+		from := make(map[string]string)
+		conflicting := make(map[string]string)
+		for _, imp := range importMap[module.Name] {
+			if imp.Module == module.Name {
+				continue
+			}
+			names := slices.SortedFunc(maps.Keys(exports[imp.Module]), func(a, b exportedName) int {
+				return cmp.Or(cmp.Compare(a.name, b.name), cmp.Compare(a.module, b.module))
+			})
+			for _, n := range names {
+				fixity, ok := declared[n.module][n.name]
+				if n.isType || !ok || !admits(imp, n, exports[imp.Module][n]) {
+					continue
+				}
+				if other, ok := imported[n.name]; ok && other != fixity {
+					if _, reported := conflicting[n.name]; !reported {
+						conflicting[n.name] = fmt.Sprintf("`%s` is imported with conflicting fixities: `%s` from %s and `%s` from %s", n.name, other, from[n.name], fixity, imp.Module)
+					}
+					continue
+				}
+				imported[n.name] = fixity
+				from[n.name] = imp.Module
+			}
+		}
+		parser.ResolveFixities(module, imported)
+		module.FixityConflicts = append(usedConflicts(module, conflicting), module.FixityConflicts...)
+	}
+}
+
+// usedConflicts returns a conflict for every infix expression or pattern of
+// module whose operator has a message in conflicting. An operator that is
+// imported but not used is not a conflict.
+func usedConflicts(module *parser.Module, conflicting map[string]string) []parser.FixityConflict {
+	conflicts := make([]parser.FixityConflict, 0)
+	if len(conflicting) == 0 {
+		return conflicts
+	}
+	use := func(name string, loc parser.Loc) {
+		if message, ok := conflicting[name]; ok {
+			conflicts = append(conflicts, parser.FixityConflict{Message: message, Loc: loc})
+		}
+	}
+	visitor := parser.NewTraverser(
+		func(_ int, ast parser.AST, _ parser.AST) int {
+			switch node := ast.(type) {
+			case *parser.ExpInfix:
+				use(node.Op.Name, node.Op.Loc())
+			case *parser.PInfix:
+				use(node.Op.Name, node.Op.Loc())
+			}
+			return 0
+		},
+		nil,
+		0,
+	)
+	visitor.Visit(module, nil)
+	return conflicts
+}
@@ -0,0 +1,43 @@
+package rename
+
+import (
+	"goanna/haskell/parser"
+	"strings"
+	"testing"
+)
+
+func TestResolveFixities(t *testing.T) {
+	counter := 0
+	lib := parser.ParseWithCounter([]byte("module Lib where\ninfixr 5 <+>\ninfixl 5 <->"), "Lib", &counter)
+	other := parser.ParseWithCounter([]byte("module Other where\ninfixr 5 <->"), "Other", &counter)
+	main := parser.ParseWithCounter([]byte("module Main where\nimport Lib\nx = a <+> b * c <+> d"), "Main", &counter)
+	hiding := parser.ParseWithCounter([]byte("module Hiding where\nimport Lib hiding ((<+>))\nx = a <+> b <+> c"), "Hiding", &counter)
+	both := parser.ParseWithCounter([]byte("module Both where\nimport Lib\nimport Other\nx = a <-> b\ny = a\nz = (<->)"), "Both", &counter)
+	unused := parser.ParseWithCounter([]byte("module Unused where\nimport Lib\nimport Other\nx = a"), "Unused", &counter)
+	mid := parser.ParseWithCounter([]byte("module Mid (module Lib) where\nimport Lib"), "Mid", &counter)
+	reexported := parser.ParseWithCounter([]byte("module Reexported where\nimport Mid\nx = a <+> b <+> c"), "Reexported", &counter)
+
+	ResolveFixities([]*parser.Module{lib, other, main, hiding, both, unused, mid, reexported})
+
+	if pretty := main.Pretty(); !strings.Contains(pretty, "x = (a <+> ((b * c) <+> d))") {
+		t.Errorf("Expected imported infixr 5 <+>, got %s", pretty)
+	}
+	if pretty := hiding.Pretty(); !strings.Contains(pretty, "x = ((a <+> b) <+> c)") {
+		t.Errorf("Expected the default fixity for a hidden operator, got %s", pretty)
+	}
+	if len(main.FixityConflicts) != 0 {
+		t.Errorf("Expected no conflicts in Main, got %v", main.FixityConflicts)
+	}
+	if pretty := reexported.Pretty(); !strings.Contains(pretty, "x = (a <+> (b <+> c))") {
+		t.Errorf("Expected the fixity of <+> through the re-export of Lib, got %s", pretty)
+	}
+	// Only the infix use of <-> is a conflict
+	if len(both.FixityConflicts) != 1 || !strings.Contains(both.FixityConflicts[0].Message, "<->") {
+		t.Errorf("Expected a conflict for <->, got %v", both.FixityConflicts)
+	} else if loc := both.FixityConflicts[0].Loc; loc.FromLine() != 3 || loc.FromCol() != 6 {
+		t.Errorf("Expected the conflict at the use of <->, got %v", loc)
+	}
+	if len(unused.FixityConflicts) != 0 {
+		t.Errorf("Expected no conflicts for an operator that is not used, got %v", unused.FixityConflicts)
+	}
+}
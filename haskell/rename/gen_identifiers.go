@@ -81,6 +81,10 @@ type RenameResult struct {
 	Terms   []TermIdentifier
 	Types   []TypeIdentifier
 	Classes []ClassIdentifier
+
+	// exports are the names each module exports, set when the identifiers of
+	// a whole program are generated
+	exports map[string]exportSet
 }
 
 // internEntry holds a symbol+effectiveRange pair mapped to an internal name
@@ -125,6 +129,9 @@ type RenameEnv struct {
 	terms   internTable
 	types   internTable
 	classes internTable
+	// letScopes maps each let statement of a do block to the ranges its
+	// bindings are in scope in: itself and the statements after it
+	letScopes map[*parser.LetStmt][]parser.Loc
 }
 
 // InternTerm interns a term-level name, producing identifiers like V0, V1, V2...
@@ -168,10 +175,7 @@ func namesFromPat(pat parser.Pat) []struct {
 			id   int
 		}{p.Name, p.Id()})
 	case *parser.PApp:
-		names = append(names, struct {
-			name string
-			id   int
-		}{p.Constructor.Name, p.Constructor.Id()})
+		// The constructor is a use, not a binding
 		for _, subpat := range p.Pats {
 			names = append(names, namesFromPat(subpat)...)
 		}
@@ -186,11 +190,41 @@ func namesFromPat(pat parser.Pat) []struct {
 	case *parser.PInfix:
 		names = append(names, namesFromPat(p.Pat1)...)
 		names = append(names, namesFromPat(p.Pat2)...)
+	case *parser.PRecord:
+		// The constructor and the fields are uses, not bindings
+		for _, field := range p.Fields {
+			names = append(names, namesFromPat(field.Pat)...)
+		}
+	case *parser.PAs:
+		names = append(names, namesFromPat(&p.Var)...)
+		names = append(names, namesFromPat(p.Pat)...)
+	case *parser.PIrrefutable:
+		names = append(names, namesFromPat(p.Pat)...)
+	case *parser.PBang:
+		names = append(names, namesFromPat(p.Pat)...)
 	}
 
 	return names
 }
 
+// rhsScope returns the ranges of a right-hand side and of its where
+// bindings, which the parameters of the binding and the where bindings
+// themselves are in scope in.
+func rhsScope(rhs parser.Rhs) []parser.Loc {
+	ranges := []parser.Loc{rhs.Loc()}
+	var wheres []parser.Decl
+	switch r := rhs.(type) {
+	case *parser.UnguardedRhs:
+		wheres = r.Wheres
+	case *parser.GuardedRhs:
+		wheres = r.Wheres
+	}
+	for _, where := range wheres {
+		ranges = append(ranges, where.Loc())
+	}
+	return ranges
+}
+
 // GenIdentifiers analyzes an AST and returns identifiers of all three kinds with their scope information
 func (env *RenameEnv) GenIdentifiers(ast parser.Module) RenameResult {
 	result := &RenameResult{
@@ -225,8 +259,10 @@ func (env *RenameEnv) visitNode(ast parser.AST, moduleName string, result *Renam
 		// Process type signature - intern all names as term identifiers
 		var effectiveRange EffectiveRange
 
-		// Check if parent is a module (global scope)
-		if _, isModule := parent.(*parser.Module); isModule || parent == nil {
+		// Check if parent is a module (global scope). Class methods are in
+		// scope wherever their class is
+		_, isMethod := parent.(*parser.ClassDecl)
+		if _, isModule := parent.(*parser.Module); isModule || isMethod || parent == nil {
 			effectiveRange = EffectiveRange{
 				ranges: []parser.Loc{},
 				global: true,
@@ -258,25 +294,43 @@ func (env *RenameEnv) visitNode(ast parser.AST, moduleName string, result *Renam
 	case *parser.PatBind:
 		// Process pattern bindings - extract names from patterns
 		names := namesFromPat(node.Pat)
+		if app, ok := node.Pat.(*parser.PApp); ok {
+			// The head of a function clause, `f x y = ...`, declares f
+			names = append(namesFromPat(&app.Constructor), names...)
+		}
+		// A pattern binding, `(a, b) = ...`, declares all of its names
+		declared := 1
+		switch node.Pat.(type) {
+		case *parser.PVar, *parser.PApp:
+		default:
+			declared = len(names)
+		}
 		for i, nameInfo := range names {
 			var effectiveRange EffectiveRange
 			var isParam bool
 
-			if i == 0 {
+			if i < declared {
 				// First name determines scope based on context
 				// Check if this is a local declaration (where clause or let expression)
 				isLocalDecl := false
 				if parent != nil {
 					switch parent.(type) {
-					case *parser.UnguardedRhs, *parser.GuardedRhs, *parser.Alt, *parser.ExpLet:
+					case *parser.UnguardedRhs, *parser.GuardedRhs, *parser.Alt, *parser.ExpLet, *parser.LetStmt:
 						isLocalDecl = true
 					}
 				}
 
 				if isLocalDecl {
 					// Local declarations (where clauses and let expressions) are local to the parent scope
+					ranges := []parser.Loc{parent.Loc()}
+					if rhs, ok := parent.(parser.Rhs); ok {
+						ranges = rhsScope(rhs)
+					}
+					if let, ok := parent.(*parser.LetStmt); ok && env.letScopes[let] != nil {
+						ranges = env.letScopes[let]
+					}
 					effectiveRange = EffectiveRange{
-						ranges: []parser.Loc{parent.Loc()},
+						ranges: ranges,
 						global: false,
 					}
 					isParam = false
@@ -298,7 +352,7 @@ func (env *RenameEnv) visitNode(ast parser.AST, moduleName string, result *Renam
 			} else {
 				// Other names get RHS scope and are parameters
 				effectiveRange = EffectiveRange{
-					ranges: []parser.Loc{node.Rhs.Loc()},
+					ranges: rhsScope(node.Rhs),
 					global: false,
 				}
 				isParam = true
@@ -336,6 +390,26 @@ func (env *RenameEnv) visitNode(ast parser.AST, moduleName string, result *Renam
 		}
 		result.Terms = append(result.Terms, termId)
 
+	case *parser.FieldDecl:
+		// Record fields are global terms too, as they are selector functions
+		for _, name := range node.Names {
+			internalName := env.InternTerm(name, moduleName, EffectiveRange{global: true})
+			termId := TermIdentifier{
+				Identifier: Identifier{
+					name:   name,
+					module: moduleName,
+					effectiveRange: EffectiveRange{
+						ranges: []parser.Loc{},
+						global: true,
+					},
+					internalName: internalName,
+					isParameter:  false,
+					declaredAt:   []int{node.Id()},
+				},
+			}
+			result.Terms = append(result.Terms, termId)
+		}
+
 	case *parser.DataDecl:
 		// Data type declarations - extract type name from DeclHead
 		dHead := node.DHead
@@ -419,14 +493,25 @@ func (env *RenameEnv) visitNode(ast parser.AST, moduleName string, result *Renam
 		}
 
 	case *parser.ExpDo:
-		// Do block - extract names from generators and let bindings
-		effectiveRange := EffectiveRange{
-			ranges: []parser.Loc{node.Loc()},
-			global: false,
-		}
-
-		for _, stmt := range node.Stmts {
+		// Do block - extract names from generators, which are in scope in
+		// the statements after them. The bindings of a let statement are
+		// declared when its PatBinds are visited.
+		for i, stmt := range node.Stmts {
+			later := make([]parser.Loc, 0, len(node.Stmts)-i)
+			for _, next := range node.Stmts[i+1:] {
+				later = append(later, next.Loc())
+			}
+			if let, ok := stmt.(*parser.LetStmt); ok {
+				if env.letScopes == nil {
+					env.letScopes = make(map[*parser.LetStmt][]parser.Loc)
+				}
+				env.letScopes[let] = append([]parser.Loc{let.Loc()}, later...)
+			}
 			if gen, ok := stmt.(*parser.Generator); ok {
+				effectiveRange := EffectiveRange{
+					ranges: later,
+					global: false,
+				}
 				names := namesFromPat(gen.Pat)
 				for _, nameInfo := range names {
 					internalName := env.InternTerm(nameInfo.name, moduleName, effectiveRange)
@@ -538,9 +623,9 @@ func RenameDecl(module *parser.Module, result RenameResult) {
 	traverser.Visit(module, nil)
 }
 
-// RenameTypeDecl traverses all nodes in module and for every TypeSig sets
-// Canonicals to the internal names of the TermIdentifiers whose declaredAt
-// includes the TypeSig's node ID, ordered to match TypeSig.Names.
+// RenameTypeDecl traverses all nodes in module and for every TypeSig and
+// FieldDecl sets Canonicals to the internal names of the TermIdentifiers whose
+// declaredAt includes its node ID, ordered to match its Names.
 func RenameTypeDecl(module *parser.Module, result RenameResult) {
 	// Build a map from TypeSig node ID -> (name -> internalName)
 	// A TermIdentifier's declaredAt may include a TypeSig node ID.
@@ -557,25 +642,31 @@ func RenameTypeDecl(module *parser.Module, result RenameResult) {
 		}
 	}
 
+	canonicalsOf := func(id int, sourceNames []string) []string {
+		names := typeSigMap[id]
+		canonicals := make([]string, len(sourceNames))
+		for i, name := range sourceNames {
+			if internalName, found := names[name]; found {
+				canonicals[i] = internalName
+			} else {
+				canonicals[i] = name
+			}
+		}
+		return canonicals
+	}
+
 	traverser := parser.NewTraverser(
 		func(_ int, ast parser.AST, _ parser.AST) int {
-			typeSig, ok := ast.(*parser.TypeSig)
-			if !ok {
-				return 0
-			}
-			names, ok := typeSigMap[typeSig.Id()]
-			if !ok {
-				return 0
-			}
-			canonicals := make([]string, len(typeSig.Names))
-			for i, name := range typeSig.Names {
-				if internalName, found := names[name]; found {
-					canonicals[i] = internalName
-				} else {
-					canonicals[i] = name
+			switch node := ast.(type) {
+			case *parser.TypeSig:
+				if _, ok := typeSigMap[node.Id()]; ok {
+					node.Canonicals = canonicalsOf(node.Id(), node.Names)
+				}
+			case *parser.FieldDecl:
+				if _, ok := typeSigMap[node.Id()]; ok {
+					node.Canonicals = canonicalsOf(node.Id(), node.Names)
 				}
 			}
-			typeSig.Canonicals = canonicals
 			return 0
 		},
 		nil,
@@ -642,7 +733,9 @@ func (env *RenameEnv) GenIdentifiersAll(modules []parser.Module) RenameResult {
 		Classes: []ClassIdentifier{},
 	}
 
-	for _, module := range modules {
+	pointers := make([]*parser.Module, len(modules))
+	for i, module := range modules {
+		pointers[i] = &modules[i]
 		result := env.GenIdentifiers(module)
 		allResult.Terms = append(allResult.Terms, result.Terms...)
 		allResult.Types = append(allResult.Types, result.Types...)
@@ -651,6 +744,7 @@ func (env *RenameEnv) GenIdentifiersAll(modules []parser.Module) RenameResult {
 
 	// Merge duplicate TermIdentifiers
 	allResult.Terms = mergeTermIdentifiers(allResult.Terms)
+	allResult.exports = moduleExports(pointers)
 
 	return allResult
 }
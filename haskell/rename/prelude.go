@@ -0,0 +1,68 @@
+package rename
+
+import "strings"
+
+// preludeModule is the module every module imports implicitly, unless it
+// imports it explicitly.
+const preludeModule = "Prelude"
+
+// preludeExports are the names the Haskell 2010 Prelude exports, plus the
+// Functor, Applicative, Foldable, Traversable, Semigroup and Monoid names it
+// exports since base 4.8. The Prelude is not part of the program, so these
+// names have no identifiers and resolve to themselves.
+var preludeExports = func() exportSet {
+	exports := make(exportSet)
+	term := func(parent string, names string) {
+		for _, name := range strings.Fields(names) {
+			exports[exportedName{module: preludeModule, name: name}] = parent
+		}
+	}
+	typ := func(names string) {
+		for _, name := range strings.Fields(names) {
+			exports[exportedName{module: preludeModule, name: name, isType: true}] = ""
+		}
+	}
+
+	typ("Bool Maybe Either Ordering Char String Int Integer Float Double Rational Word IO IOError ShowS ReadS FilePath")
+	term("Bool", "False True")
+	term("Maybe", "Nothing Just")
+	term("Either", "Left Right")
+	term("Ordering", "LT EQ GT")
+	// The parser spells the unit value `unit`
+	typ("()")
+	term("", ": [] unit")
+
+	typ("Eq Ord Enum Bounded Num Real Integral Fractional Floating RealFrac RealFloat")
+	term("Eq", "== /=")
+	term("Ord", "compare < <= >= > max min")
+	term("Enum", "succ pred toEnum fromEnum enumFrom enumFromThen enumFromTo enumFromThenTo")
+	term("Bounded", "minBound maxBound")
+	term("Num", "+ - * negate abs signum fromInteger")
+	term("Real", "toRational")
+	term("Integral", "quot rem div mod quotRem divMod toInteger")
+	term("Fractional", "/ recip fromRational")
+	term("Floating", "pi exp log sqrt ** logBase sin cos tan asin acos atan sinh cosh tanh asinh acosh atanh")
+	term("RealFrac", "properFraction truncate round ceiling floor")
+	term("RealFloat", "floatRadix floatDigits floatRange decodeFloat encodeFloat exponent significand scaleFloat isNaN isInfinite isDenormalized isIEEE isNegativeZero atan2")
+
+	typ("Functor Applicative Monad MonadFail Foldable Traversable Semigroup Monoid Show Read")
+	term("Functor", "fmap <$")
+	term("Applicative", "pure <*> *> <* liftA2")
+	term("Monad", ">>= >> return")
+	term("MonadFail", "fail")
+	term("Foldable", "foldMap foldr foldl foldr1 foldl1 elem maximum minimum sum product null length")
+	term("Traversable", "traverse sequenceA mapM sequence")
+	term("Semigroup", "<>")
+	term("Monoid", "mempty mappend mconcat")
+	term("Show", "showsPrec show showList")
+	term("Read", "readsPrec readList")
+
+	term("", "&& || not otherwise maybe either fst snd curry uncurry subtract even odd gcd lcm ^ ^^ fromIntegral realToFrac")
+	term("", "<$> mapM_ sequence_ =<< id const . flip $ until asTypeOf error errorWithoutStackTrace undefined seq $!")
+	term("", "map ++ filter head last tail init !! reverse and or any all concat concatMap notElem")
+	term("", "scanl scanl1 scanr scanr1 iterate repeat replicate cycle take drop splitAt takeWhile dropWhile span break")
+	term("", "lookup zip zip3 zipWith zipWith3 unzip unzip3 lines words unlines unwords")
+	term("", "shows showChar showString showParen reads readParen read lex")
+	term("", "putChar putStr putStrLn print getChar getLine getContents interact readFile writeFile appendFile readIO readLn ioError userError")
+	return exports
+}()
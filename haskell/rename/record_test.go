@@ -0,0 +1,48 @@
+package rename
+
+import (
+	"goanna/haskell/parser"
+	"testing"
+)
+
+func TestRecordSelectors(t *testing.T) {
+	code := "data P = P { name :: String, age, size :: Int }"
+	hasGlobalTermIdents(t, code, []string{"P", "name", "age", "size"})
+}
+
+func TestResolveRecordFields(t *testing.T) {
+	// The local age shadows the selector, but not the field
+	code := "data P = P { age :: Int }\nf p age = p { age = age }\ng (P { age = a }) = a"
+	module := parser.Parse([]byte(code), "Test")
+	RenameAll([]*parser.Module{module})
+
+	selector := module.Decls[0].(*parser.DataDecl).Constructors[0].Fields[0].Canonicals[0]
+	fields := 0
+	visitor := parser.NewTraverser(
+		func(_ int, ast parser.AST, parent parser.AST) int {
+			switch field := ast.(type) {
+			case *parser.FieldBind:
+				fields++
+				if field.Field.Canonical != selector {
+					t.Errorf("Expected field 'age' to resolve to '%s', got '%s'", selector, field.Field.Canonical)
+				}
+				if v, ok := field.Exp.(*parser.ExpVar); !ok || v.Canonical == selector {
+					t.Errorf("Expected the bound expression to resolve to the local 'age', got %v", field.Exp)
+				}
+			case *parser.PFieldBind:
+				fields++
+				if field.Field.Canonical != selector {
+					t.Errorf("Expected field pattern 'age' to resolve to '%s', got '%s'", selector, field.Field.Canonical)
+				}
+			}
+			return 0
+		},
+		nil,
+		0,
+	)
+	visitor.Visit(module, nil)
+
+	if fields != 2 {
+		t.Errorf("Expected 2 record fields, found %d", fields)
+	}
+}
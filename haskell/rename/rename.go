@@ -2,9 +2,13 @@ package rename
 
 import "goanna/haskell/parser"
 
-// RenameAll generates identifiers for all modules, renames declaration sites,
+// RenameAll associates infix expressions with the fixities each module
+// imports, generates identifiers for all modules, renames declaration sites,
 // and resolves all name references. All operations mutate the modules in place.
-func RenameAll(modules []*parser.Module) {
+// It returns a diagnostic for every name that cannot be resolved.
+func RenameAll(modules []*parser.Module) []Diagnostic {
+	ResolveFixities(modules)
+
 	moduleValues := make([]parser.Module, len(modules))
 	for i, m := range modules {
 		moduleValues[i] = *m
@@ -18,6 +22,7 @@ func RenameAll(modules []*parser.Module) {
 		RenameTypeDecl(m, result)
 	}
 
-	ResolveAll(modules, result)
+	diagnostics := ResolveAll(modules, result)
 	RenameTypeVars(modules)
+	return diagnostics
 }
@@ -1,159 +1,219 @@
 package rename
 
 import (
+	"fmt"
 	"goanna/haskell/parser"
 	"slices"
+	"strings"
 )
 
 // Resolve mutates the module in place, resolving canonical names for all name nodes.
-func Resolve(module *parser.Module, result RenameResult, importMap map[string][]parser.Import) {
+// It returns a diagnostic for every name it cannot resolve, for every
+// equation whose arity does not match its function and for every do block
+// that does not end in an expression, and adds them to the ImportErrors or
+// the Diagnostics of the module.
+func Resolve(module *parser.Module, result RenameResult, importMap map[string][]parser.Import) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	checkArities(module, &diagnostics)
+	checkDoBlocks(module, &diagnostics)
+	if result.exports != nil {
+		checkImports(module, result.exports, &diagnostics)
+		checkExports(module, result, importMap, &diagnostics)
+	}
+
 	visitor := parser.NewTraverser(
 		func(_ int, ast parser.AST, parent parser.AST) int {
-			resolveNode(ast, module.Name, result, importMap)
+			resolveNode(ast, parent, module, result, importMap, &diagnostics)
 			return 0
 		},
 		nil,
 		0,
 	)
 	visitor.Visit(module, nil)
+	return diagnostics
 }
 
 // resolveNode processes individual nodes during resolution
-// Sets canonical names for ExpVar nodes by finding the most specific matching identifier
-func resolveNode(ast parser.AST, moduleName string, result RenameResult, importMap map[string][]parser.Import) {
+// Sets canonical names for name nodes by finding the most specific matching identifier
+func resolveNode(ast parser.AST, parent parser.AST, module *parser.Module, result RenameResult, importMap map[string][]parser.Import, diagnostics *[]Diagnostic) {
 	switch node := ast.(type) {
 	case *parser.ExpVar:
-		// Find all term identifiers that match the ExpVar name
-		candidates := []TermIdentifier{}
-
-		for _, term := range result.Terms {
-			// Check if name matches
-			if term.name != node.Name {
-				continue
-			}
+		// The field of `P { name = name }` is the selector, whatever local
+		// variable shares its name
+		field := false
+		switch p := parent.(type) {
+		case *parser.FieldBind:
+			field = node == &p.Field
+		case *parser.PFieldBind:
+			field = node == &p.Field
+		}
 
-			// Check if the effective range envelopes the ExpVar location
-			if !envelopesLocation(term.effectiveRange, node.Loc()) {
-				continue
-			}
+		node.Canonical = resolve(result.Terms, node.Name, node.Module, node, UnboundVariable, field, module, result, importMap, diagnostics)
 
-			// If ExpVar has a module qualifier, only consider that module
-			if node.Module != "" {
-				if term.module == node.Module {
-					candidates = append(candidates, term)
-				}
-				continue
-			}
+	case *parser.ExpHole:
+		// `_name` is a variable if something binds it, and a hole otherwise,
+		// which is not an error
+		node.Canonical = bound(result.Terms, node.Name, node, module, result, importMap)
 
-			// For unqualified names, consider identifiers from current module
-			if term.module == moduleName {
-				candidates = append(candidates, term)
-				continue
-			}
+	case *parser.PVar:
+		// Variables are named where they are bound, so a PVar without a
+		// canonical name is the constructor of a constructor pattern
+		if node.Canonical == "" {
+			node.Canonical = resolve(result.Terms, node.Name, node.Module, node, UnboundVariable, true, module, result, importMap, diagnostics)
+		}
 
-			// Also consider identifiers from imported modules (must be global)
-			if term.effectiveRange.global && isImported(term.module, moduleName, importMap, node.Name) {
-				candidates = append(candidates, term)
-			}
+	case *parser.TyCon:
+		// The only TyCons directly under a data declaration are the classes
+		// of its deriving clause
+		if _, ok := parent.(*parser.DataDecl); ok {
+			node.Canonical = resolve(result.Classes, node.Name, node.Module, node, UnknownClass, false, module, result, importMap, diagnostics)
+			return
 		}
+		node.Canonical = resolve(result.Types, node.Name, node.Module, node, UnknownType, false, module, result, importMap, diagnostics)
 
-		// Choose the most specific identifier
-		if len(candidates) > 0 {
-			mostSpecific := chooseMostSpecific(candidates, moduleName)
-			node.Canonical = mostSpecific.getIdentifier().internalName
-		} else {
-			// No match found, keep original name
-			node.Canonical = node.Name
+	case *parser.InstDecl:
+		node.Canonical = resolve(result.Classes, node.Name, node.Module, node, UnknownClass, false, module, result, importMap, diagnostics)
+	case *parser.Assertion:
+		node.Canonical = resolve(result.Classes, node.Name, node.Module, node, UnknownClass, false, module, result, importMap, diagnostics)
+	}
+}
+
+// resolve returns the canonical name of the identifier among ids that name,
+// qualified by qualifier if it is not empty, refers to at node. unbound is
+// the kind of diagnostic to report if the name is not in scope at all. If
+// globalOnly is set, local identifiers are ignored. A name that no identifier
+// matches is kept as is, and reported unless a module outside the program,
+// such as the Prelude, may export it.
+func resolve[T HasIdentifier](ids []T, name, qualifier string, node parser.AST, unbound DiagnosticKind, globalOnly bool, module *parser.Module, result RenameResult, importMap map[string][]parser.Import, diagnostics *[]Diagnostic) string {
+	isType := unbound != UnboundVariable
+	candidates := []T{}
+	for _, c := range ids {
+		id := c.getIdentifier()
+		if id.name != name || (globalOnly && !id.effectiveRange.global) {
+			continue
+		}
+		if inScopeAt(id, qualifier, isType, node.Loc(), module.Name, result, importMap) {
+			candidates = append(candidates, c)
 		}
+	}
 
-	case *parser.TyCon:
-		// Find all type identifiers that match the TyCon name
-		candidates := []TypeIdentifier{}
+	if len(candidates) == 0 {
+		scope := namesInScope(ids, qualifier, isType, node.Loc(), module.Name, result, importMap)
+		if message := notImported(ids, name, qualifier, isType, module.Name, result, importMap); message != "" {
+			report(diagnostics, module, NotExported, message, name, isType, node, suggest(name, scope))
+		} else if !result.isExternal(name, qualifier, isType, module.Name, importMap) {
+			report(diagnostics, module, unbound, notInScope(unbound, name, qualifier), name, isType, node, suggest(name, scope))
+		}
+		return name
+	}
 
-		for _, ty := range result.Types {
-			// Check if name matches
-			if ty.name != node.Name {
-				continue
+	mostSpecific := chooseMostSpecific(candidates, module.Name).getIdentifier()
+	if mostSpecific.module != module.Name {
+		modules := []string{}
+		for _, c := range candidates {
+			if m := c.getIdentifier().module; !slices.Contains(modules, m) {
+				modules = append(modules, m)
 			}
+		}
+		if len(modules) > 1 {
+			slices.Sort(modules)
+			report(diagnostics, module, AmbiguousOccurrence,
+				fmt.Sprintf("ambiguous occurrence `%s`: it could refer to `%s` from %s", name, name, strings.Join(modules, " or ")),
+				name, isType, node, nil)
+		}
+	}
+	return mostSpecific.internalName
+}
 
-			// Check if the effective range envelopes the TyCon location
-			if !envelopesLocation(ty.effectiveRange, node.Loc()) {
-				continue
-			}
+// bound returns the canonical name of the identifier among ids that the
+// unqualified name refers to at node, or "" if there is none. Unlike resolve,
+// it reports nothing.
+func bound[T HasIdentifier](ids []T, name string, node parser.AST, module *parser.Module, result RenameResult, importMap map[string][]parser.Import) string {
+	candidates := []T{}
+	for _, c := range ids {
+		if id := c.getIdentifier(); id.name == name && inScopeAt(id, "", false, node.Loc(), module.Name, result, importMap) {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	return chooseMostSpecific(candidates, module.Name).getIdentifier().internalName
+}
 
-			// If TyCon has a module qualifier, only consider that module
-			if node.Module != "" {
-				if ty.module == node.Module {
-					candidates = append(candidates, ty)
-				}
-				continue
-			}
+// inScopeAt reports whether id can be written at loc in module current with
+// qualifier, which is empty for unqualified names. Identifiers of other
+// modules are in scope only if an import of current brings them into scope.
+func inScopeAt(id Identifier, qualifier string, isType bool, loc parser.Loc, current string, result RenameResult, importMap map[string][]parser.Import) bool {
+	if !envelopesLocation(id.effectiveRange, loc) {
+		return false
+	}
 
-			// For unqualified names, consider identifiers from current module
-			if ty.module == moduleName {
-				candidates = append(candidates, ty)
-				continue
-			}
+	// Names of the current module can be qualified by the module itself
+	if id.module == current {
+		return qualifier == "" || (qualifier == current && id.effectiveRange.global)
+	}
 
-			// Also consider identifiers from imported modules (must be global)
-			if ty.effectiveRange.global && isImported(ty.module, moduleName, importMap, node.Name) {
-				candidates = append(candidates, ty)
-			}
-		}
+	n := exportedName{module: id.module, name: id.name, isType: isType}
+	return id.effectiveRange.global && result.isVisible(n, current, qualifier, importMap)
+}
 
-		// Choose the most specific identifier
-		if len(candidates) > 0 {
-			mostSpecific := chooseMostSpecific(candidates, moduleName)
-			node.Canonical = mostSpecific.getIdentifier().internalName
-		} else {
-			// No match found, keep original name
-			node.Canonical = node.Name
+// namesInScope returns the names among ids, and those of the Prelude, that
+// can be written at loc in module current with qualifier.
+func namesInScope[T HasIdentifier](ids []T, qualifier string, isType bool, loc parser.Loc, current string, result RenameResult, importMap map[string][]parser.Import) []string {
+	names := preludeNames(qualifier, isType, current, importMap)
+	for _, c := range ids {
+		if id := c.getIdentifier(); inScopeAt(id, qualifier, isType, loc, current, result, importMap) {
+			names = append(names, id.name)
 		}
+	}
+	return names
+}
 
-	case *parser.InstDecl, *parser.Assertion:
-		// Both InstDecl and Assertion resolve against class identifiers
-		var name, module string
-		switch n := node.(type) {
-		case *parser.InstDecl:
-			name, module = n.Name, n.Module
-		case *parser.Assertion:
-			name, module = n.Name, n.Module
-		}
+// notInScope is the message of a diagnostic of kind for name, qualified by
+// qualifier, which is not in scope.
+func notInScope(kind DiagnosticKind, name string, qualifier string) string {
+	if qualifier != "" {
+		name = qualifier + "." + name
+	}
+	switch {
+	case kind == UnknownType:
+		return fmt.Sprintf("type constructor not in scope: `%s`", name)
+	case kind == UnknownClass:
+		return fmt.Sprintf("class not in scope: `%s`", name)
+	case isConstructor(name):
+		return fmt.Sprintf("data constructor not in scope: `%s`", name)
+	default:
+		return fmt.Sprintf("variable not in scope: `%s`", name)
+	}
+}
 
-		candidates := []ClassIdentifier{}
-		for _, cls := range result.Classes {
-			if cls.name != name {
-				continue
-			}
-			if !envelopesLocation(cls.effectiveRange, ast.Loc()) {
-				continue
-			}
-			if module != "" {
-				if cls.module == module {
-					candidates = append(candidates, cls)
-				}
-				continue
-			}
-			if cls.module == moduleName {
-				candidates = append(candidates, cls)
+// notImported explains why name, qualified by qualifier, is not in scope in
+// module current although a module it imports declares it. It returns "" if
+// none does.
+func notImported[T HasIdentifier](ids []T, name, qualifier string, isType bool, current string, result RenameResult, importMap map[string][]parser.Import) string {
+	for _, imp := range importMap[current] {
+		if _, ok := result.exports[imp.Module]; !ok || !qualifies(imp, qualifier) {
+			continue
+		}
+		for _, c := range ids {
+			// The name may be declared by the module imported or by one it
+			// imports in turn
+			id := c.getIdentifier()
+			declaring := id.module == imp.Module || slices.ContainsFunc(importMap[imp.Module], func(i parser.Import) bool {
+				return i.Module == id.module
+			})
+			if id.name != name || !declaring || !id.effectiveRange.global {
 				continue
 			}
-			if cls.effectiveRange.global && isImported(cls.module, moduleName, importMap, name) {
-				candidates = append(candidates, cls)
+			n := exportedName{module: id.module, name: name, isType: isType}
+			if _, ok := result.exports[imp.Module][n]; ok {
+				return fmt.Sprintf("`%s` is not imported from module `%s`", name, imp.Module)
 			}
-		}
-
-		canonical := name
-		if len(candidates) > 0 {
-			canonical = chooseMostSpecific(candidates, moduleName).getIdentifier().internalName
-		}
-		switch n := node.(type) {
-		case *parser.InstDecl:
-			n.Canonical = canonical
-		case *parser.Assertion:
-			n.Canonical = canonical
+			return fmt.Sprintf("module `%s` does not export `%s`", imp.Module, name)
 		}
 	}
+	return ""
 }
 
 // envelopesLocation checks if an effective range envelopes a location
@@ -173,35 +233,6 @@ func envelopesLocation(effectiveRange EffectiveRange, loc parser.Loc) bool {
 	return false
 }
 
-// isImported checks if a module is imported in the current module
-// and the name is not in the exclude list (hiding clause)
-func isImported(targetModule string, currentModule string, importMap map[string][]parser.Import, name string) bool {
-	imports, ok := importMap[currentModule]
-	if !ok {
-		return false
-	}
-
-	for _, imp := range imports {
-		// Check if this import matches the target module
-		if imp.Module == targetModule || (imp.Alias != "" && imp.Alias == targetModule) {
-			// If hiding is true and name is in Items, it's hidden
-			if imp.Hiding {
-				return !slices.Contains(imp.Items, name)
-			}
-
-			// If not hiding and Items is specified, check if name is in Items
-			if len(imp.Items) > 0 {
-				return slices.Contains(imp.Items, name)
-			}
-
-			// No Items specified and not hiding - everything is imported
-			return true
-		}
-	}
-
-	return false
-}
-
 // chooseMostSpecific selects the most specific identifier from candidates.
 // Priority: local module > foreign module, smallest effective range > larger range.
 func chooseMostSpecific[T HasIdentifier](candidates []T, currentModule string) T {
@@ -266,12 +297,15 @@ func isMoreSpecific(range1, range2 EffectiveRange) bool {
 	return false
 }
 
-// ResolveAll mutates all modules in place, resolving canonical names.
-func ResolveAll(modules []*parser.Module, result RenameResult) {
+// ResolveAll mutates all modules in place, resolving canonical names. It
+// returns the diagnostics of all modules.
+func ResolveAll(modules []*parser.Module, result RenameResult) []Diagnostic {
 	importMap := BuildImportMap(modules)
+	diagnostics := []Diagnostic{}
 	for _, module := range modules {
-		Resolve(module, result, importMap)
+		diagnostics = append(diagnostics, Resolve(module, result, importMap)...)
 	}
+	return diagnostics
 }
 
 // BuildImportMap creates a map from module names to their imports.
@@ -193,3 +193,88 @@ func TestResolveExpVarToInternalName(t *testing.T) {
 		t.Errorf("Expected to find ExpVar 'x' in the resolved module")
 	}
 }
+
+func TestResolveDeriving(t *testing.T) {
+	code := "class Eq a where\n  eq :: a -> a -> Bool\nnewtype Age = Age Int deriving (Eq, Show)"
+	module := parser.Parse([]byte(code), "Test")
+	RenameAll([]*parser.Module{module})
+
+	class := module.Decls[0].(*parser.ClassDecl).DHead.Canonical
+	deriving := module.Decls[1].(*parser.DataDecl).Deriving
+	if deriving[0].Canonical != class {
+		t.Errorf("Expected derived 'Eq' to resolve to class '%s', got '%s'", class, deriving[0].Canonical)
+	}
+	if deriving[1].Canonical != "Show" {
+		t.Errorf("Expected unknown class 'Show' to keep its name, got '%s'", deriving[1].Canonical)
+	}
+}
+
+func TestResolvePatterns(t *testing.T) {
+	code := "data M a = None | Some a\nf all@(Some !x) ~(None, y) = x\ng = \\(Some None) -> None"
+	hasLocalTermIdents(t, code, []string{"all", "x", "y"})
+
+	module := parser.Parse([]byte(code), "Test")
+	RenameAll([]*parser.Module{module})
+	constructors := map[string]string{}
+	for _, con := range module.Decls[0].(*parser.DataDecl).Constructors {
+		constructors[con.Name] = con.Canonical
+	}
+
+	uses := 0
+	visitor := parser.NewTraverser(
+		func(_ int, ast parser.AST, parent parser.AST) int {
+			if app, ok := ast.(*parser.PApp); ok {
+				if canonical, isCon := constructors[app.Constructor.Name]; isCon {
+					uses++
+					if app.Constructor.Canonical != canonical {
+						t.Errorf("Expected constructor '%s' in a pattern to resolve to '%s', got '%s'", app.Constructor.Name, canonical, app.Constructor.Canonical)
+					}
+				}
+			}
+			return 0
+		},
+		nil,
+		0,
+	)
+	visitor.Visit(module, nil)
+
+	if uses != 4 {
+		t.Errorf("Expected 4 constructor patterns, found %d", uses)
+	}
+}
+
+func TestResolveHoles(t *testing.T) {
+	code := "f _x = _x\ng = _x\nh = _y\n  where _y = 1"
+	module := parser.Parse([]byte(code), "Test")
+	diagnostics := RenameAll([]*parser.Module{module})
+	if len(diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics for holes, got %v", diagnostics)
+	}
+
+	// `_x` is a variable in f, where it is bound, and a hole in g
+	bound := map[string]bool{}
+	visitor := parser.NewTraverser(
+		func(_ int, ast parser.AST, parent parser.AST) int {
+			if hole, ok := ast.(*parser.ExpHole); ok {
+				bound[hole.Name] = bound[hole.Name] || hole.Canonical != ""
+				if hole.Canonical == hole.Name {
+					t.Errorf("Expected '%s' to resolve to an internal name, got '%s'", hole.Name, hole.Canonical)
+				}
+			}
+			return 0
+		},
+		nil,
+		0,
+	)
+	visitor.Visit(module.Decls[0], nil)
+	visitor.Visit(module.Decls[2], nil)
+	if !bound["_x"] || !bound["_y"] {
+		t.Errorf("Expected bound holes to resolve, got %v", bound)
+	}
+
+	bound = map[string]bool{}
+	visitor.Visit(module.Decls[1], nil)
+	if bound["_x"] {
+		t.Errorf("Expected the unbound '_x' of g to stay a hole")
+	}
+}
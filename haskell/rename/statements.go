@@ -0,0 +1,30 @@
+package rename
+
+import (
+	"fmt"
+	"goanna/haskell/parser"
+)
+
+// checkDoBlocks reports every do block in m whose last statement is not an
+// expression, such as `do { x <- getLine }`. The diagnostic is at the last
+// statement and names it.
+func checkDoBlocks(m *parser.Module, diagnostics *[]Diagnostic) {
+	visitor := parser.NewTraverser(
+		func(_ int, ast parser.AST, parent parser.AST) int {
+			do, ok := ast.(*parser.ExpDo)
+			if !ok || len(do.Stmts) == 0 {
+				return 0
+			}
+			last := do.Stmts[len(do.Stmts)-1]
+			if _, ok := last.(*parser.Qualifier); !ok {
+				statement := last.Pretty()
+				message := fmt.Sprintf("the last statement in a do block must be an expression, not `%s`", statement)
+				report(diagnostics, m, InvalidDoBlock, message, statement, false, last, nil)
+			}
+			return 0
+		},
+		nil,
+		0,
+	)
+	visitor.Visit(m, nil)
+}
@@ -6,13 +6,15 @@ import (
 )
 
 // RenameTypeVars assigns canonical names P0, P1, P2, ... to every TyVar in
-// every TypeSig across all modules.
+// every TypeSig, data declaration and expression type annotation across all
+// modules.
 //
 // The rules are:
 //   - Within a single TypeSig, TyVars with the same source name share the
 //     same canonical (e.g. both `a` in `f :: a -> a` become the same Pn).
 //   - Across different TypeSigs, TyVars always get distinct canonicals even
 //     if their source names are identical.
+//   - An annotation `(e :: T)` is scoped like a TypeSig of its own.
 //
 // The function mutates TyVar nodes in place by setting their Canonical field.
 func RenameTypeVars(modules []*parser.Module) {
@@ -23,6 +25,9 @@ func RenameTypeVars(modules []*parser.Module) {
 			renameTyVarsInDecl(decl, &counter)
 		}
 	}
+	for _, m := range modules {
+		renameTyVarsInAnnotations(m, &counter)
+	}
 }
 
 // renameTyVarsInDecl recurses into declarations. TypeSigs are handled directly;
@@ -31,6 +36,8 @@ func renameTyVarsInDecl(decl parser.Decl, counter *int) {
 	switch d := decl.(type) {
 	case *parser.TypeSig:
 		renameTyVarsInTypeSig(d, counter)
+	case *parser.DataDecl:
+		renameTyVarsInDataDecl(d, counter)
 	case *parser.ClassDecl:
 		for _, inner := range d.Decls {
 			renameTyVarsInDecl(inner, counter)
@@ -48,7 +55,29 @@ func renameTyVarsInDecl(decl parser.Decl, counter *int) {
 // TypeSig. Same source name → same canonical; the global counter advances
 // once per distinct name in this sig.
 func renameTyVarsInTypeSig(sig *parser.TypeSig, counter *int) {
-	// local map: source name → canonical for this sig
+	renameTyVarsInType(sig.Ty, counter)
+}
+
+// renameTyVarsInAnnotations gives the type of every expression type
+// annotation in m fresh canonicals, as for a TypeSig.
+func renameTyVarsInAnnotations(m *parser.Module, counter *int) {
+	visitor := parser.NewTraverser(
+		func(_ int, ast parser.AST, _ parser.AST) int {
+			if typed, ok := ast.(*parser.ExpTyped); ok {
+				renameTyVarsInType(typed.Ty, counter)
+			}
+			return 0
+		},
+		nil,
+		0,
+	)
+	visitor.Visit(m, nil)
+}
+
+// renameTyVarsInType assigns fresh canonicals to the TyVars of ty, the same
+// for every occurrence of a source name.
+func renameTyVarsInType(ty parser.Type, counter *int) {
+	// local map: source name → canonical for this type
 	local := make(map[string]string)
 
 	assignCanonical := func(tv *parser.TyVar) {
@@ -64,7 +93,34 @@ func renameTyVarsInTypeSig(sig *parser.TypeSig, counter *int) {
 		tv.Canonical = canon
 	}
 
-	walkType(sig.Ty, assignCanonical)
+	walkType(ty, assignCanonical)
+}
+
+// renameTyVarsInDataDecl gives the type variables of a data declaration the
+// same canonicals in its head and in the types of its constructors.
+func renameTyVarsInDataDecl(decl *parser.DataDecl, counter *int) {
+	local := make(map[string]string)
+	assignCanonical := func(tv *parser.TyVar) {
+		canon, ok := local[tv.Name]
+		if !ok {
+			canon = fmt.Sprintf("P%d", *counter)
+			*counter++
+			local[tv.Name] = canon
+		}
+		tv.Canonical = canon
+	}
+
+	for i := range decl.DHead.TypeVars {
+		assignCanonical(&decl.DHead.TypeVars[i])
+	}
+	for i := range decl.Constructors {
+		for _, ty := range decl.Constructors[i].Tys {
+			walkType(ty, assignCanonical)
+		}
+		for _, field := range decl.Constructors[i].Fields {
+			walkType(field.Ty, assignCanonical)
+		}
+	}
 }
 
 // renameTyVarsInRhs handles RHS nodes that can contain type annotations
@@ -0,0 +1,43 @@
+package rename
+
+import (
+	"goanna/haskell/parser"
+	"testing"
+)
+
+func TestRenameTypeVarsInAnnotations(t *testing.T) {
+	code := "f :: a -> a\nf x = (x :: a)\ng = (id :: a -> a) (h :: a)"
+	module := parser.Parse([]byte(code), "Test")
+	RenameAll([]*parser.Module{module})
+
+	var annotations [][]string
+	visitor := parser.NewTraverser(
+		func(_ int, ast parser.AST, _ parser.AST) int {
+			if typed, ok := ast.(*parser.ExpTyped); ok {
+				var canonicals []string
+				walkType(typed.Ty, func(tv *parser.TyVar) {
+					canonicals = append(canonicals, tv.Canonical)
+				})
+				annotations = append(annotations, canonicals)
+			}
+			return 0
+		},
+		nil,
+		0,
+	)
+	visitor.Visit(module, nil)
+
+	if len(annotations) != 3 {
+		t.Fatalf("Expected 3 annotations, found %d", len(annotations))
+	}
+	sig := module.Decls[0].(*parser.TypeSig).Ty.(*parser.TyFunction).Ty1.(*parser.TyVar).Canonical
+	if annotations[0][0] == sig {
+		t.Errorf("Expected the annotation not to share '%s' with the signature", sig)
+	}
+	if annotations[1][0] != annotations[1][1] {
+		t.Errorf("Expected both 'a' in one annotation to share a canonical, got %v", annotations[1])
+	}
+	if annotations[1][0] == annotations[2][0] || annotations[0][0] == annotations[1][0] {
+		t.Errorf("Expected separate annotations to have separate canonicals, got %v", annotations)
+	}
+}
@@ -33,8 +33,16 @@ type Line struct {
 type Fix struct {
 	LocalType  map[int]string
 	GlobalType map[string]string
-	MCS        []int
-	Snapshot   []Line
+	// The same types as LocalType and GlobalType, before they are printed
+	StructuredLocalType  map[int]Type
+	StructuredGlobalType map[string]Type
+	MCS                  []int
+	Snapshot             []Line
+	Holes                []HoleType
+	// Explanation says in words why the expressions in MCS are to blame.
+	Explanation string
+	// Suggestions are verified rewrites, filled in by SuggestEdits.
+	Suggestions []Suggestion
 }
 
 type NodeDetail struct {
@@ -52,40 +60,42 @@ type Report struct {
 	NodeRange  map[int]inventory.Range
 }
 
-func shrinkRangeOnLine(loc inventory.Range, lineNum int, lineLength int) (int, int) {
-	if loc.FromLine == lineNum && loc.ToLine == lineNum {
-		return loc.FromCol, loc.ToCol
-	}
-
+// shrinkRangeOnLine returns the byte columns of the part of loc on line lineNum,
+// clamped to the line.
+func shrinkRangeOnLine(loc inventory.Range, lineNum int, source inventory.Source) (int, int) {
+	lineLength := len(source.Line(lineNum))
+	from, to := 0, lineLength
 	if loc.FromLine == lineNum {
-		return loc.FromCol, lineLength
+		from = loc.FromCol
 	}
-
 	if loc.ToLine == lineNum {
-		return 0, loc.ToCol
+		to = loc.ToCol
 	}
-
-	return 0, lineLength
+	from = source.ByteColumn(lineNum, from)
+	return from, max(from, source.ByteColumn(lineNum, to))
 }
 
+// createSnapshot splits the lines with critical nodes into spans. From and To
+// are byte columns, like the ranges they come from.
 func createSnapshot(criticalNodes []int, mcsNodes []int, nodeRange map[int]inventory.Range, file string) []Line {
 	lineHasNode := make(map[int][]int)
 	for _, node := range criticalNodes {
 		loc := nodeRange[node]
-		lineHasNode[node] = make([]int, 0)
 		for i := loc.FromLine; i <= loc.ToLine; i++ {
 			lineHasNode[i] = append(lineHasNode[i], node)
 		}
 	}
 
+	source := inventory.NewSource(file)
 	lines := make([]Line, 0)
-	for lineNum, line := range strings.Split(file, "\n") {
+	for lineNum := range strings.Split(file, "\n") {
 
 		if len(lineHasNode[lineNum]) == 0 {
 			continue
 		}
 
 		var snapshotLine Line
+		line := source.Line(lineNum)
 		lineLength := len(line)
 		snapshotLine = Line{
 			LineNumber: lineNum,
@@ -94,7 +104,7 @@ func createSnapshot(criticalNodes []int, mcsNodes []int, nodeRange map[int]inven
 		criticalSpans := make([]Span, len(lineHasNode[lineNum]))
 		for i, node := range lineHasNode[lineNum] {
 			loc := nodeRange[node]
-			fromPos, toPos := shrinkRangeOnLine(loc, lineNum, lineLength)
+			fromPos, toPos := shrinkRangeOnLine(loc, lineNum, source)
 			var tag Tag
 			if slices.Contains(mcsNodes, node) {
 				tag = tagError
@@ -149,36 +159,41 @@ func createSnapshot(criticalNodes []int, mcsNodes []int, nodeRange map[int]inven
 	return lines
 }
 
+// displayNameLength is the number of characters kept at each end of a long
+// expression in its display name.
+const displayNameLength = 4
+
 func getDisplayName(loc inventory.Range, file string) string {
-	lines := strings.Split(file, "\n")
+	source := inventory.NewSource(file)
 	if loc.FromLine != loc.ToLine {
-		fromLine := lines[loc.FromLine]
-		toLine := lines[loc.ToLine]
-		startCutOffCol := min(loc.FromCol+4, len(fromLine))
-		start := fromLine[loc.FromCol:startCutOffCol]
-		var end string
-		if loc.ToCol < 4 {
-			end = toLine[0:loc.ToCol]
-
-		} else {
-			end = toLine[loc.ToCol-4 : loc.ToCol]
-		}
-
-		return strings.Join([]string{start, end}, "...")
+		start := []rune(source.Slice(loc.FromLine, loc.FromCol, len(source.Line(loc.FromLine))))
+		end := []rune(source.Slice(loc.ToLine, 0, loc.ToCol))
+		start = start[:min(displayNameLength, len(start))]
+		end = end[max(0, len(end)-displayNameLength):]
+		return strings.Join([]string{string(start), string(end)}, "...")
 
 	} else {
-		line := lines[loc.FromLine]
-		if loc.ToCol-loc.FromCol > 11 {
-			start := line[loc.FromCol : loc.FromCol+4]
-			end := line[loc.ToCol-4 : loc.ToCol]
-			return strings.Join([]string{start, end}, "...")
+		text := []rune(source.Slice(loc.FromLine, loc.FromCol, loc.ToCol))
+		if len(text) > 11 {
+			start := text[:displayNameLength]
+			end := text[len(text)-displayNameLength:]
+			return strings.Join([]string{string(start), string(end)}, "...")
 		}
-		return line[loc.FromCol:loc.ToCol]
+		return string(text)
 
 	}
 }
 
 func InferTypes(inv inventory.Inventory) map[string]string {
+	globalTypeMapping := make(map[string]string)
+	for decl, t := range InferStructuredTypes(inv) {
+		globalTypeMapping[decl] = t.String()
+	}
+	return globalTypeMapping
+}
+
+// InferStructuredTypes is InferTypes before the types are printed.
+func InferStructuredTypes(inv inventory.Inventory) map[string]Type {
 	// Infer global types for a SATISFIABLE set of constraints
 	prologResult := inv.QueryTypes(inv.EffectiveRules, []int{})
 	globals := prologResult["G"]
@@ -186,7 +201,7 @@ func InferTypes(inv inventory.Inventory) map[string]string {
 	if err != nil {
 		panic("Error in parse types")
 	}
-	globalTypeMapping := make(map[string]string)
+	globalTypeMapping := make(map[string]Type)
 	decls := make([]string, 0)
 
 	for _, decl := range inv.Declarations {
@@ -208,13 +223,14 @@ func ReportTypeError(rawError marco.Error, inv inventory.Inventory, file string)
 	fixes := make([]Fix, len(rawError.Causes))
 	for i, cause := range rawError.Causes {
 		localPrinter := NewPrinter(inv.Classes)
-		prologResult := inv.QueryTypes(cause.MSS.ToSlice(), rawError.CriticalNodes)
+		prologResult := inv.QueryTypes(marco.Sorted(cause.MSS), rawError.CriticalNodes)
 		globals := prologResult["G"]
 		locals := prologResult["L"]
-		globalTypes, err := prologtool.ParseTerm(globals)
-		localTypes, err := prologtool.ParseTerm(locals)
+		globalTerms, err := prologtool.ParseTerm(globals)
+		localTerms, err := prologtool.ParseTerm(locals)
 
 		globalTypeMapping := make(map[string]string)
+		globalTypes := make(map[string]Type)
 		decls := make([]string, 0)
 
 		for _, decl := range inv.Declarations {
@@ -223,31 +239,37 @@ func ReportTypeError(rawError marco.Error, inv inventory.Inventory, file string)
 			}
 		}
 
-		for i, v := range globalTypes.(prologtool.List).Values {
+		for i, v := range globalTerms.(prologtool.List).Values {
 			printer := NewPrinter(inv.Classes)
 			decl := decls[i]
-			globalTypeMapping[decl] = printer.GetType(v)
+			globalTypes[decl] = printer.GetType(v)
+			globalTypeMapping[decl] = globalTypes[decl].String()
 		}
 
-		localTypeMapping := make(map[int]string)
-		for i, v := range localTypes.(prologtool.List).Values {
+		localTypes := make(map[int]Type)
+		for i, v := range localTerms.(prologtool.List).Values {
 			nodeId := rawError.CriticalNodes[i]
-			localTypeMapping[nodeId] = localPrinter.PrepareType(v, nodeId)
+			localTypes[nodeId] = localPrinter.PrepareType(v)
 		}
 		localPrinter.AssignVars()
-		for nodeId, v := range localTypeMapping {
-			localTypeMapping[nodeId] = localPrinter.CompileType(v, nodeId)
+		localTypeMapping := make(map[int]string)
+		for nodeId, v := range localTypes {
+			localTypes[nodeId] = localPrinter.CompileType(v)
+			localTypeMapping[nodeId] = localTypes[nodeId].String()
 		}
 
 		if err != nil {
 			panic("Error in parse types")
 		}
-		lines := createSnapshot(rawError.CriticalNodes, cause.MCS.ToSlice(), inv.NodeRange, file)
+		lines := createSnapshot(rawError.CriticalNodes, marco.Sorted(cause.MCS), inv.NodeRange, file)
 		fixes[i] = Fix{
-			LocalType:  localTypeMapping,
-			GlobalType: globalTypeMapping,
-			Snapshot:   lines,
-			MCS:        cause.MCS.ToSlice(),
+			LocalType:            localTypeMapping,
+			GlobalType:           globalTypeMapping,
+			StructuredLocalType:  localTypes,
+			StructuredGlobalType: globalTypes,
+			Snapshot:             lines,
+			MCS:                  marco.Sorted(cause.MCS),
+			Holes:                InferHoles(inv, marco.Sorted(cause.MSS)),
 		}
 	}
 	slices.SortFunc(fixes, func(a, b Fix) int {
@@ -259,9 +281,11 @@ func ReportTypeError(rawError marco.Error, inv inventory.Inventory, file string)
 			return -1
 		} else if loc1.FromLine > loc2.FromLine {
 			return 1
-		} else {
+		} else if loc1.FromCol != loc2.FromCol {
 			return loc1.FromCol - loc2.FromCol
 		}
+		// Fixes that start at the same node go smaller first
+		return slices.Compare(a.MCS, b.MCS)
 	})
 	nodeDetails := make(map[int]NodeDetail)
 	for _, node := range rawError.CriticalNodes {
@@ -270,6 +294,13 @@ func ReportTypeError(rawError marco.Error, inv inventory.Inventory, file string)
 			Range:       inv.NodeRange[node],
 		}
 	}
+	muses := make([][]int, len(rawError.MUSs))
+	for i, mus := range rawError.MUSs {
+		muses[i] = marco.Sorted(mus)
+	}
+	for i := range fixes {
+		fixes[i].Explanation = explainFix(i, fixes, muses, nodeDetails)
+	}
 	return TypeError{
 		Fixes:         fixes,
 		CriticalNodes: nodeDetails,
@@ -281,29 +312,12 @@ func MakeReport(errors []marco.Error, inv inventory.Inventory, srcProgram string
 	for i, e := range errors {
 		tcErrors[i] = ReportTypeError(e, inv, srcProgram)
 	}
+	// By their first critical node in source order, which does not depend on
+	// the order MARCO found them in
 	slices.SortFunc(tcErrors, func(a, b TypeError) int {
-		var minA, minB int = -1, -1
-		for k := range a.CriticalNodes {
-			if minA == -1 || k <= minA {
-				minA = k
-			}
-		}
-
-		for k := range b.CriticalNodes {
-			if minB == -1 || k <= minB {
-				minB = k
-			}
-		}
-		locA := inv.NodeRange[minA]
-		locB := inv.NodeRange[minB]
-		if locA.FromLine < locB.FromLine {
-			return -1
-		} else if locA.FromLine > locB.FromLine {
-			return 1
-		} else {
-			return locA.FromCol - locB.FromCol
-		}
-
+		firstA := a.OrderedCriticalNodes()[0]
+		firstB := b.OrderedCriticalNodes()[0]
+		return compareRanges(inv.NodeRange[firstA], inv.NodeRange[firstB], firstA, firstB)
 	})
 	return Report{
 		TypeErrors: tcErrors,
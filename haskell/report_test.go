@@ -0,0 +1,77 @@
+package haskell
+
+import (
+	"goanna/inventory"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDisplayName(t *testing.T) {
+	source := "main = putStrLn \"héllo wörld, ça va?\"\nf = g\n  ü"
+	testCases := []struct {
+		name   string
+		loc    inventory.Range
+		expect string
+	}{
+		{
+			name:   "short",
+			loc:    inventory.Range{FromLine: 0, ToLine: 0, FromCol: 0, ToCol: 4},
+			expect: "main",
+		},
+		{
+			name:   "long with multi-byte characters",
+			loc:    inventory.Range{FromLine: 0, ToLine: 0, FromCol: 16, ToCol: 42},
+			expect: "\"hél...va?\"",
+		},
+		{
+			name:   "multi-line ending on a short line",
+			loc:    inventory.Range{FromLine: 1, ToLine: 2, FromCol: 4, ToCol: 4},
+			expect: "g...  ü",
+		},
+		{
+			name:   "range past the end of the line",
+			loc:    inventory.Range{FromLine: 1, ToLine: 1, FromCol: 4, ToCol: 40},
+			expect: "g",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, getDisplayName(tc.loc, source))
+		})
+	}
+}
+
+func TestCreateSnapshotMultiByte(t *testing.T) {
+	source := "f = \"λ\" ++ 1\n\tg = 'é'"
+	nodeRange := map[int]inventory.Range{
+		1: {FromLine: 0, ToLine: 0, FromCol: 4, ToCol: 8},
+		2: {FromLine: 0, ToLine: 0, FromCol: 12, ToCol: 13},
+		3: {FromLine: 1, ToLine: 1, FromCol: 5, ToCol: 9},
+	}
+	lines := createSnapshot([]int{1, 2, 3}, []int{2}, nodeRange, source)
+	assert.Len(t, lines, 2)
+
+	texts := make([]string, 0)
+	for _, span := range lines[0].Spans {
+		texts = append(texts, span.Text)
+	}
+	assert.Equal(t, []string{"f = ", "\"λ\"", " ++ ", "1"}, texts)
+	assert.Equal(t, tagCritical, lines[0].Spans[1].Tag)
+	assert.Equal(t, tagError, lines[0].Spans[3].Tag)
+	assert.Equal(t, "'é'", lines[1].Spans[1].Text)
+}
+
+func TestShrinkRangeOnLine(t *testing.T) {
+	source := inventory.NewSource("ab\nλλλ\ncd")
+	loc := inventory.Range{FromLine: 0, ToLine: 2, FromCol: 1, ToCol: 1}
+	from, to := shrinkRangeOnLine(loc, 0, source)
+	assert.Equal(t, []int{1, 2}, []int{from, to})
+	from, to = shrinkRangeOnLine(loc, 1, source)
+	assert.Equal(t, []int{0, 6}, []int{from, to})
+	from, to = shrinkRangeOnLine(loc, 2, source)
+	assert.Equal(t, []int{0, 1}, []int{from, to})
+	// A column inside a character moves to its start
+	from, to = shrinkRangeOnLine(inventory.Range{FromLine: 1, ToLine: 1, FromCol: 1, ToCol: 5}, 1, source)
+	assert.Equal(t, []int{0, 4}, []int{from, to})
+}
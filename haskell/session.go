@@ -0,0 +1,170 @@
+package haskell
+
+import (
+	"goanna/inventory"
+	"goanna/marco"
+	"slices"
+)
+
+// Session keeps the state of one editor buffer between edits, so that an edit
+// only re-analyses the declarations it touched.
+type Session struct {
+	Inv          *inventory.Inventory
+	Localisation Localisation
+	fingerprints map[string]string
+}
+
+// Reset forgets results that no longer match the source, but keeps the
+// interpreter.
+func (s *Session) Reset() {
+	s.Localisation = Localisation{}
+	s.fingerprints = nil
+}
+
+// Edit brings the session up to date with input and returns the declarations
+// that were re-analysed and the number of errors carried over from before.
+func (s *Session) Edit(input inventory.Input) ([]string, int) {
+	if s.Inv == nil || s.fingerprints == nil {
+		return s.checkAll(input), 0
+	}
+
+	// previous is the inventory of the program before the edit, which the
+	// errors of s.Localisation refer to
+	previous := s.Inv.Update(input)
+	fingerprints := s.Inv.Fingerprints()
+	changed := make([]string, 0)
+	for name, fingerprint := range fingerprints {
+		if s.fingerprints[name] != fingerprint {
+			changed = append(changed, name)
+		}
+	}
+	for name := range s.fingerprints {
+		if _, ok := fingerprints[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+
+	for _, name := range changed {
+		if _, isClass := s.Inv.InstanceRules[name]; isClass || previous.InstanceRules[name] != nil {
+			// Instances are shared by every declaration
+			return s.checkAll(input), 0
+		}
+	}
+	affected := s.Inv.Dependants(changed)
+
+	reused := make([]marco.Error, 0)
+	dropped := make([]int, 0)
+	for _, e := range s.Localisation.Errors {
+		moved, ok := moveError(e, previous, s.Inv, affected)
+		if ok {
+			reused = append(reused, moved)
+			dropped = append(dropped, marco.Sorted(moved.Causes[0].MCS)...)
+		}
+	}
+
+	level := s.Localisation.Level
+	s.Inv.Generalize(level)
+	if !s.Inv.AxiomCheck() {
+		return s.checkAll(input), 0
+	}
+	s.Inv.Focus(affected, dropped)
+	step := LevelStep{
+		Level:          level,
+		EffectiveRules: len(s.Inv.EffectiveRules),
+		AxiomaticRules: len(s.Inv.AxiomaticRules),
+		Outcome:        OutcomeWellTyped,
+	}
+	found := make([]marco.Error, 0)
+	if len(s.Inv.EffectiveRules) != 0 && !s.Inv.TypeCheck() {
+		ruleIds := s.Inv.EffectiveRules
+		s.Inv.ConsultAxioms()
+		mc := marco.NewMarco(ruleIds, s.Inv.Satisfiable)
+		mc.Run()
+		found = mc.Analysis()
+		if len(found) == 1 && len(found[0].CriticalNodes) == 0 {
+			// The edit needs a different generalisation level
+			return s.checkAll(input), 0
+		}
+		step.Outcome = OutcomeLocalised
+	}
+
+	// Undo the focus so that reports see the whole program
+	s.Inv.Generalize(level)
+	errors := slices.Concat(reused, found)
+	marco.AssignMSS(errors, marco.NewIntSet(s.Inv.EffectiveRules...))
+	s.Localisation = Localisation{
+		Level:     level,
+		Localised: true,
+		Errors:    errors,
+		Trace:     []LevelStep{step},
+	}
+	s.fingerprints = fingerprints
+	return affected, len(reused)
+}
+
+func (s *Session) checkAll(input inventory.Input) []string {
+	if s.Inv == nil {
+		s.Inv = inventory.NewInventory(input)
+	} else {
+		s.Inv.Update(input)
+	}
+	s.Localisation = Localise(s.Inv, input.MaxLevel)
+	if s.Localisation.Localised {
+		s.fingerprints = s.Inv.Fingerprints()
+	} else {
+		s.fingerprints = nil
+	}
+	return s.Inv.Declarations
+}
+
+// moveError translates the rule IDs of an error found in previous into the
+// rule IDs of current. It fails if the error involves an affected declaration.
+func moveError(e marco.Error, previous, current *inventory.Inventory, affected []string) (marco.Error, bool) {
+	ruleMap := make(map[int]int)
+	for _, decl := range previous.Declarations {
+		if slices.Contains(affected, decl) {
+			continue
+		}
+		oldRules := previous.RulesOf(decl)
+		newRules := current.RulesOf(decl)
+		if len(oldRules) != len(newRules) {
+			continue
+		}
+		for i, ruleId := range oldRules {
+			ruleMap[ruleId] = newRules[i]
+		}
+	}
+	move := func(ids []int) ([]int, bool) {
+		moved := make([]int, len(ids))
+		for i, id := range ids {
+			newId, ok := ruleMap[id]
+			if !ok {
+				return nil, false
+			}
+			moved[i] = newId
+		}
+		return moved, true
+	}
+
+	criticalNodes, ok := move(e.CriticalNodes)
+	if !ok {
+		return marco.Error{}, false
+	}
+	causes := make([]marco.Cause, len(e.Causes))
+	for i, cause := range e.Causes {
+		mcs, ok := move(marco.Sorted(cause.MCS))
+		if !ok {
+			return marco.Error{}, false
+		}
+		causes[i] = marco.Cause{MCS: marco.NewIntSet(mcs...), MSS: marco.NewIntSet()}
+	}
+	muses := make([]marco.IntSet, len(e.MUSs))
+	for i, mus := range e.MUSs {
+		moved, ok := move(marco.Sorted(mus))
+		if !ok {
+			return marco.Error{}, false
+		}
+		muses[i] = marco.NewIntSet(moved...)
+	}
+	return marco.Error{Causes: causes, CriticalNodes: criticalNodes, MUSs: muses}, true
+}
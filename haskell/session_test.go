@@ -0,0 +1,41 @@
+package haskell
+
+import (
+	"goanna/inventory"
+	"goanna/marco"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sessionInput is a program of f and g whose rule IDs start after offset.
+func sessionInput(offset int) inventory.Input {
+	rule := func(id int, decl string) inventory.Rule {
+		return inventory.Rule{Id: offset + id, Head: inventory.RuleHead{Name: decl, Module: "Main", Type: "type"}, Body: "T = int"}
+	}
+	return inventory.Input{
+		Rules:        []inventory.Rule{rule(1, "f"), rule(2, "f"), rule(3, "g")},
+		Declarations: []string{"f", "g"},
+	}
+}
+
+func TestMoveError(t *testing.T) {
+	e := marco.Error{
+		Causes:        []marco.Cause{{MCS: marco.NewIntSet(2), MSS: marco.NewIntSet(1)}},
+		CriticalNodes: []int{1, 2},
+		MUSs:          []marco.IntSet{marco.NewIntSet(1, 2)},
+	}
+
+	// Edit moves errors from the inventory Update replaces to the updated one
+	inv := inventory.NewInventory(sessionInput(0))
+	previous := inv.Update(sessionInput(10))
+
+	moved, ok := moveError(e, previous, inv, []string{"g"})
+	assert.True(t, ok)
+	assert.Equal(t, []int{11, 12}, moved.CriticalNodes)
+	assert.Equal(t, []int{12}, marco.Sorted(moved.Causes[0].MCS))
+	assert.Equal(t, []int{11, 12}, marco.Sorted(moved.MUSs[0]))
+
+	_, ok = moveError(e, previous, inv, []string{"f"})
+	assert.False(t, ok, "errors in affected declarations should not be moved")
+}
@@ -0,0 +1,285 @@
+package haskell
+
+import (
+	"fmt"
+	"goanna/haskell/parser"
+	"goanna/inventory"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// maxSuggestions is the most verified edits attached to one fix.
+const maxSuggestions = 2
+
+// maxRechecks bounds the re-checks spent on one report, as every re-check
+// translates and localises the whole program again.
+const maxRechecks = 24
+
+var (
+	numberLiteral  = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	charLiteral    = regexp.MustCompile(`^'(\\?.)'$`)
+	stringLiteral  = regexp.MustCompile(`^"((?:[^"\\]|\\.)*)"$`)
+	declNamePrefix = regexp.MustCompile(`^m[0-9]+_`)
+)
+
+var integralTypes = []string{"Int", "Integer"}
+var numericTypes = []string{"Int", "Integer", "Float", "Double", "Rational"}
+
+// TextEdit replaces the source in Range, in byte columns, with NewText.
+type TextEdit struct {
+	Range   inventory.Range
+	NewText string
+}
+
+// Suggestion is a concrete rewrite for a fix that has been checked to remove
+// the error.
+type Suggestion struct {
+	Title string
+	Edits []TextEdit
+}
+
+// Checker type checks a variant of the program and returns how many type
+// errors it has. It fails if the variant does not parse, does not import or
+// cannot be localised.
+type Checker func(source string) (int, bool)
+
+// RecheckWith returns a Checker that translates a program with translate and
+// localises it from scratch.
+func RecheckWith(translate func(string) (inventory.Input, error)) Checker {
+	return func(source string) (int, bool) {
+		input, err := translate(source)
+		if err != nil {
+			return 0, false
+		}
+		inv := inventory.NewInventory(input)
+		if len(inv.ParsingErrors) != 0 || len(inv.ImportErrors) != 0 {
+			return 0, false
+		}
+		localisation := Localise(inv, input.MaxLevel)
+		if !localisation.Localised {
+			return 0, false
+		}
+		return len(localisation.Errors), true
+	}
+}
+
+// SuggestEdits looks for rewrites of the nodes each fix changes that match a
+// known mistake, and attaches to the fix those that leave the program with
+// fewer type errors according to check.
+func SuggestEdits(report *Report, source string, check Checker) {
+	program := inventory.NewSource(source)
+	module := parseQuietly(source)
+	errors := len(report.TypeErrors)
+	verdicts := make(map[string]bool)
+	verify := func(edits []TextEdit) bool {
+		edited := applyEdits(program, edits)
+		if verdict, ok := verdicts[edited]; ok {
+			return verdict
+		}
+		if len(verdicts) >= maxRechecks {
+			return false
+		}
+		remaining, ok := check(edited)
+		verdicts[edited] = ok && remaining < errors
+		return verdicts[edited]
+	}
+
+	for i := range report.TypeErrors {
+		typeError := &report.TypeErrors[i]
+		for j := range typeError.Fixes {
+			fix := &typeError.Fixes[j]
+			fix.Suggestions = make([]Suggestion, 0)
+			for _, node := range fix.MCS {
+				detail, ok := typeError.CriticalNodes[node]
+				if !ok {
+					continue
+				}
+				actual, _ := ownType(node, typeError.Fixes)
+				candidates := candidateEdits(detail.Range, program, fix.LocalType[node], actual, *fix, module)
+				for _, candidate := range candidates {
+					if len(fix.Suggestions) == maxSuggestions {
+						break
+					}
+					if verify(candidate.Edits) {
+						fix.Suggestions = append(fix.Suggestions, candidate)
+					}
+				}
+			}
+		}
+	}
+}
+
+// candidateEdits proposes rewrites of the expression at r, which has to
+// have type expected but on its own has type actual.
+func candidateEdits(r inventory.Range, program inventory.Source, expected, actual string, fix Fix, module *parser.Module) []Suggestion {
+	text := program.Text(r)
+	candidates := make([]Suggestion, 0)
+	replace := func(title, newText string) {
+		candidates = append(candidates, Suggestion{
+			Title: title,
+			Edits: []TextEdit{{Range: r, NewText: newText}},
+		})
+	}
+	fractional := strings.Contains(expected, "Fractional") || strings.Contains(expected, "Floating")
+	expected = withoutContext(expected)
+	actual = withoutContext(actual)
+
+	switch text {
+	case "++":
+		replace("Use `:` instead of `++`", ":")
+	case ":":
+		replace("Use `++` instead of `:`", "++")
+	}
+
+	switch {
+	case charLiteral.MatchString(text) && isStringType(expected):
+		newText := `"` + charLiteral.FindStringSubmatch(text)[1] + `"`
+		replace(fmt.Sprintf("Write `%s` instead of `%s`", newText, text), newText)
+	case stringLiteral.MatchString(text) && expected == "Char":
+		content := stringLiteral.FindStringSubmatch(text)[1]
+		if charLiteral.MatchString("'" + content + "'") {
+			newText := "'" + content + "'"
+			replace(fmt.Sprintf("Write `%s` instead of `%s`", newText, text), newText)
+		}
+	case stringLiteral.MatchString(text) && slices.Contains(numericTypes, expected):
+		content := stringLiteral.FindStringSubmatch(text)[1]
+		if numberLiteral.MatchString(content) {
+			replace(fmt.Sprintf("Write `%s` instead of `%s`", content, text), content)
+		}
+	case numberLiteral.MatchString(text) && isStringType(expected):
+		newText := `"` + text + `"`
+		replace(fmt.Sprintf("Write `%s` instead of `%s`", newText, text), newText)
+	}
+
+	if actual != "" && text != "++" && text != ":" {
+		if isStringType(expected) && !isStringType(actual) && !isFunctionType(actual) {
+			replace(fmt.Sprintf("Convert `%s` with `show`", text), "(show "+text+")")
+		}
+		if slices.Contains(integralTypes, actual) && expected != actual && (isNumericType(expected) || fractional) {
+			replace(fmt.Sprintf("Convert `%s` with `fromIntegral`", text), "(fromIntegral "+text+")")
+		}
+	}
+
+	if module != nil {
+		if swap, ok := swapArguments(r, module, program); ok {
+			candidates = append(candidates, swap)
+		}
+		if generalise, ok := generaliseSignature(r, fix, module); ok {
+			candidates = append(candidates, generalise)
+		}
+	}
+	return candidates
+}
+
+// swapArguments swaps the two arguments of the innermost application
+// `f a b` that contains r.
+func swapArguments(r inventory.Range, module *parser.Module, program inventory.Source) (Suggestion, bool) {
+	at := toLoc(r)
+	var found *parser.ExpApp
+	traverser := parser.NewTraverser(
+		func(v int, ast parser.AST, parent parser.AST) int {
+			app, ok := ast.(*parser.ExpApp)
+			if !ok || !at.IsInside(app.Loc()) {
+				return v
+			}
+			if _, ok := app.Exp1.(*parser.ExpApp); ok {
+				found = app
+			}
+			return v
+		},
+		func(_ int, ast parser.AST, parent parser.AST) {},
+		0,
+	)
+	traverser.Visit(module, nil)
+	if found == nil {
+		return Suggestion{}, false
+	}
+	first := fromLoc(found.Exp1.(*parser.ExpApp).Exp2.Loc())
+	second := fromLoc(found.Exp2.Loc())
+	firstText := program.Text(first)
+	secondText := program.Text(second)
+	if firstText == secondText {
+		return Suggestion{}, false
+	}
+	return Suggestion{
+		Title: fmt.Sprintf("Swap the arguments `%s` and `%s`", firstText, secondText),
+		Edits: []TextEdit{
+			{Range: first, NewText: secondText},
+			{Range: second, NewText: firstText},
+		},
+	}, true
+}
+
+// generaliseSignature replaces a type signature that contains r with the type
+// the fix infers for the declaration.
+func generaliseSignature(r inventory.Range, fix Fix, module *parser.Module) (Suggestion, bool) {
+	at := toLoc(r)
+	for _, decl := range module.Decls {
+		sig, ok := decl.(*parser.TypeSig)
+		if !ok || sig.Ty == nil || !at.IsInside(sig.Ty.Loc()) || len(sig.Names) != 1 {
+			continue
+		}
+		for _, name := range sortedKeys(fix.GlobalType) {
+			if declNamePrefix.ReplaceAllString(name, "") != sig.Names[0] {
+				continue
+			}
+			newText := fix.GlobalType[name]
+			return Suggestion{
+				Title: fmt.Sprintf("Change the signature of `%s` to `%s`", sig.Names[0], newText),
+				Edits: []TextEdit{{Range: fromLoc(sig.Ty.Loc()), NewText: newText}},
+			}, true
+		}
+	}
+	return Suggestion{}, false
+}
+
+// parseQuietly parses source with the native parser, which does not cover all
+// of Haskell yet. It returns nil if the parser gives up.
+func parseQuietly(source string) (module *parser.Module) {
+	defer func() {
+		if recover() != nil {
+			module = nil
+		}
+	}()
+	return parser.Parse([]byte(source), "Main")
+}
+
+// applyEdits returns the text of source with edits applied. Edits must not
+// overlap.
+func applyEdits(source inventory.Source, edits []TextEdit) string {
+	edits = slices.Clone(edits)
+	slices.SortFunc(edits, func(a, b TextEdit) int {
+		return compareRanges(b.Range, a.Range, 0, 0)
+	})
+	text := source.String()
+	for _, edit := range edits {
+		from := source.Offset(edit.Range.FromLine, edit.Range.FromCol)
+		to := source.Offset(edit.Range.ToLine, edit.Range.ToCol)
+		text = text[:from] + edit.NewText + text[max(from, to):]
+	}
+	return text
+}
+
+func fromLoc(loc parser.Loc) inventory.Range {
+	return inventory.Range{FromLine: loc.FromLine(), ToLine: loc.ToLine(), FromCol: loc.FromCol(), ToCol: loc.ToCol()}
+}
+
+func withoutContext(t string) string {
+	if i := strings.LastIndex(t, "=>"); i != -1 {
+		return strings.TrimSpace(t[i+2:])
+	}
+	return strings.TrimSpace(t)
+}
+
+func isStringType(t string) bool {
+	return t == "[Char]" || t == "String"
+}
+
+func isFunctionType(t string) bool {
+	return strings.Contains(t, "->")
+}
+
+func isNumericType(t string) bool {
+	return slices.Contains(numericTypes, t)
+}
@@ -0,0 +1,77 @@
+package haskell
+
+import (
+	"goanna/inventory"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestEdits(t *testing.T) {
+	// n :: Int; main = putStrLn n; f xs = take xs 3
+	source := "n :: Int\nn = 1\nmain = putStrLn n\nf xs = take xs 3"
+	n := inventory.Range{FromLine: 2, ToLine: 2, FromCol: 16, ToCol: 17}
+	xs := inventory.Range{FromLine: 3, ToLine: 3, FromCol: 12, ToCol: 14}
+	report := Report{TypeErrors: []TypeError{
+		{
+			CriticalNodes: map[int]NodeDetail{1: {DisplayName: "n", Range: n}, 2: {DisplayName: "putStrLn", Range: n}},
+			Fixes: []Fix{
+				{MCS: []int{1}, LocalType: map[int]string{1: "[Char]", 2: "[Char] -> IO ()"}},
+				{MCS: []int{2}, LocalType: map[int]string{1: "Int", 2: "Int -> IO ()"}},
+			},
+		},
+		{
+			CriticalNodes: map[int]NodeDetail{3: {DisplayName: "xs", Range: xs}},
+			Fixes: []Fix{
+				{MCS: []int{3}, LocalType: map[int]string{3: "Int"}},
+			},
+		},
+	}}
+	checked := make([]string, 0)
+	check := func(edited string) (int, bool) {
+		checked = append(checked, edited)
+		switch edited {
+		case "n :: Int\nn = 1\nmain = putStrLn (show n)\nf xs = take xs 3",
+			"n :: Int\nn = 1\nmain = putStrLn n\nf xs = take 3 xs":
+			return 1, true
+		}
+		return 2, true
+	}
+	SuggestEdits(&report, source, check)
+
+	assert.Equal(t, []Suggestion{{
+		Title: "Convert `n` with `show`",
+		Edits: []TextEdit{{Range: n, NewText: "(show n)"}},
+	}}, report.TypeErrors[0].Fixes[0].Suggestions)
+	// Edits that do not remove an error are not suggested
+	assert.Empty(t, report.TypeErrors[0].Fixes[1].Suggestions)
+	assert.Equal(t, []Suggestion{{
+		Title: "Swap the arguments `xs` and `3`",
+		Edits: []TextEdit{
+			{Range: xs, NewText: "3"},
+			{Range: inventory.Range{FromLine: 3, ToLine: 3, FromCol: 15, ToCol: 16}, NewText: "xs"},
+		},
+	}}, report.TypeErrors[1].Fixes[0].Suggestions)
+	// Every variant is checked once
+	assert.Len(t, checked, len(slices.Compact(slices.Sorted(slices.Values(checked)))))
+}
+
+func TestCandidateLiterals(t *testing.T) {
+	source := inventory.NewSource("a = 'x' ++ \"y\"\nb = \"42\" + 1")
+	titles := func(r inventory.Range, expected, actual string) []string {
+		result := make([]string, 0)
+		for _, candidate := range candidateEdits(r, source, expected, actual, Fix{}, nil) {
+			result = append(result, candidate.Title)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"Write `\"x\"` instead of `'x'`", "Convert `'x'` with `show`"},
+		titles(inventory.Range{FromLine: 0, ToLine: 0, FromCol: 4, ToCol: 7}, "[Char]", "Char"))
+	assert.Equal(t, []string{"Use `:` instead of `++`"},
+		titles(inventory.Range{FromLine: 0, ToLine: 0, FromCol: 8, ToCol: 10}, "Char -> [Char] -> [Char]", "[a] -> [a] -> [a]"))
+	assert.Equal(t, []string{"Write `42` instead of `\"42\"`"},
+		titles(inventory.Range{FromLine: 1, ToLine: 1, FromCol: 4, ToCol: 8}, "Integer", "[Char]"))
+	assert.Equal(t, []string{"Convert `1` with `fromIntegral`"},
+		titles(inventory.Range{FromLine: 1, ToLine: 1, FromCol: 11, ToCol: 12}, "Fractional a => a", "Int"))
+}
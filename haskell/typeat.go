@@ -0,0 +1,92 @@
+package haskell
+
+import (
+	"goanna/haskell/parser"
+	"goanna/inventory"
+	"goanna/marco"
+	prologtool "goanna/prolog-tool"
+	"strings"
+)
+
+// CandidateType is the type of a node under one candidate fix. MCS is empty
+// when the program is well typed.
+type CandidateType struct {
+	MCS  []int
+	Type string
+}
+
+type NodeType struct {
+	Node        int
+	Range       inventory.Range
+	DisplayName string
+	Types       []CandidateType
+}
+
+func toLoc(r inventory.Range) parser.Loc {
+	return parser.NewLoc(r.FromLine, r.ToLine, r.FromCol, r.ToCol)
+}
+
+// FindNodeAt returns the smallest node that has a typing rule and contains the
+// zero-based position (line, col).
+func FindNodeAt(inv inventory.Inventory, line, col int) (int, bool) {
+	position := parser.NewLoc(line, line, col, col)
+	found := false
+	var best int
+	var bestLoc parser.Loc
+	for _, rule := range inv.Rules {
+		if rule.Id == 0 || strings.HasPrefix(rule.Head.Name, "p_") {
+			continue
+		}
+		r, ok := inv.NodeRange[rule.Id]
+		if !ok {
+			continue
+		}
+		loc := toLoc(r)
+		if !position.IsInside(loc) {
+			continue
+		}
+		smaller := loc.IsInside(bestLoc) && !loc.Equal(bestLoc)
+		if !found || smaller || (loc.Equal(bestLoc) && rule.Id < best) {
+			found = true
+			best = rule.Id
+			bestLoc = loc
+		}
+	}
+	return best, found
+}
+
+// TypeAt reports the type of the node at the zero-based position (line, col).
+// For a well-typed program there is a single type; otherwise there is one type
+// for every candidate fix of every type error. If the errors could not be
+// localised, no types are reported.
+func TypeAt(inv inventory.Inventory, localisation Localisation, file string, line, col int) (NodeType, bool) {
+	node, ok := FindNodeAt(inv, line, col)
+	if !ok {
+		return NodeType{}, false
+	}
+	types := make([]CandidateType, 0)
+	if localisation.WellTyped() {
+		types = append(types, CandidateType{MCS: []int{}, Type: queryNodeType(inv, inv.EffectiveRules, node)})
+	}
+	for _, e := range localisation.Errors {
+		for _, cause := range e.Causes {
+			types = append(types, CandidateType{MCS: marco.Sorted(cause.MCS), Type: queryNodeType(inv, marco.Sorted(cause.MSS), node)})
+		}
+	}
+	return NodeType{
+		Node:        node,
+		Range:       inv.NodeRange[node],
+		DisplayName: getDisplayName(inv.NodeRange[node], file),
+		Types:       types,
+	}, true
+}
+
+func queryNodeType(inv inventory.Inventory, rules []int, node int) string {
+	prologResult := inv.QueryTypes(rules, []int{node})
+	localTypes, err := prologtool.ParseTerm(prologResult["L"])
+	if err != nil {
+		panic("Error in parse types")
+	}
+	printer := NewPrinter(inv.Classes)
+	return printer.GetType(localTypes.(prologtool.List).Values[0]).String()
+}
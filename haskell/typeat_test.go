@@ -0,0 +1,97 @@
+package haskell
+
+import (
+	"goanna/inventory"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// plusSource adds a Char to an Int. Its translation, plusInput, is written out
+// so that the tests do not need the translator.
+const plusSource = "f = 'c' + 1\n"
+
+func plusInput() inventory.Input {
+	rule := func(id int, body string) inventory.Rule {
+		return inventory.Rule{Id: id, Head: inventory.RuleHead{Name: "f", Module: "Main", Type: "type"}, Body: body}
+	}
+	return inventory.Input{
+		Declarations: []string{"f"},
+		Rules: []inventory.Rule{
+			rule(1, "_1 = builtin_Char"),
+			rule(2, "_2 = builtin_Int"),
+			rule(3, "_1 = _2, _3 = _1"),
+			rule(4, "T = _1"),
+		},
+		NodeTable: []inventory.NodePair{{Parent: 0, Child: 1}, {Parent: 0, Child: 2}, {Parent: 0, Child: 3}, {Parent: 0, Child: 4}},
+		NodeDepth: map[int]int{1: 1, 2: 1, 3: 1, 4: 1},
+		NodeRange: map[int]inventory.Range{
+			1: {FromLine: 0, FromCol: 4, ToLine: 0, ToCol: 7},
+			2: {FromLine: 0, FromCol: 10, ToLine: 0, ToCol: 11},
+			3: {FromLine: 0, FromCol: 4, ToLine: 0, ToCol: 11},
+			4: {FromLine: 0, FromCol: 0, ToLine: 0, ToCol: 11},
+		},
+		TopLevels: []string{"f"},
+		MaxLevel:  1,
+	}
+}
+
+func TestFindNodeAt(t *testing.T) {
+	inv := inventory.NewInventory(plusInput())
+	testCases := []struct {
+		line, col int
+		node      int
+		found     bool
+	}{
+		// Inside 'c', which is nested in 'c' + 1
+		{0, 5, 1, true},
+		{0, 10, 2, true},
+		// Between the tokens of 'c' + 1
+		{0, 8, 3, true},
+		{0, 9, 3, true},
+		{0, 0, 4, true},
+		// Outside the file
+		{0, 40, 0, false},
+		{5, 0, 0, false},
+		{-1, 0, 0, false},
+	}
+	for _, tc := range testCases {
+		node, found := FindNodeAt(*inv, tc.line, tc.col)
+		assert.Equal(t, tc.found, found, "%d:%d", tc.line, tc.col)
+		assert.Equal(t, tc.node, node, "%d:%d", tc.line, tc.col)
+	}
+}
+
+func TestTypeAt(t *testing.T) {
+	inv := inventory.NewInventory(plusInput())
+	localisation := Localise(inv, 1)
+	assert.Len(t, localisation.Errors, 1)
+
+	nodeType, ok := TypeAt(*inv, localisation, plusSource, 0, 5)
+	assert.True(t, ok)
+	assert.Equal(t, 1, nodeType.Node)
+	assert.Equal(t, "'c'", nodeType.DisplayName)
+	// Each fix leaves 'c' with the type the rest of the program gives it
+	types := make(map[int]string)
+	for _, candidate := range nodeType.Types {
+		assert.Len(t, candidate.MCS, 1)
+		types[candidate.MCS[0]] = candidate.Type
+	}
+	assert.Equal(t, map[int]string{1: "Int", 2: "Char", 3: "Char"}, types)
+
+	_, ok = TypeAt(*inv, localisation, plusSource, 3, 0)
+	assert.False(t, ok)
+}
+
+func TestTypeAtWellTyped(t *testing.T) {
+	input := plusInput()
+	input.Rules[1].Body = "_2 = builtin_Char"
+	inv := inventory.NewInventory(input)
+	localisation := Localise(inv, 1)
+	assert.True(t, localisation.WellTyped())
+
+	nodeType, ok := TypeAt(*inv, localisation, plusSource, 0, 9)
+	assert.True(t, ok)
+	assert.Equal(t, "'c' + 1", nodeType.DisplayName)
+	assert.Equal(t, []CandidateType{{MCS: []int{}, Type: "Char"}}, nodeType.Types)
+}
@@ -0,0 +1,168 @@
+package haskell
+
+import (
+	"slices"
+	"strings"
+)
+
+type TypeKind string
+
+const (
+	// KindCon is a type constructor on its own, such as Int or ().
+	KindCon TypeKind = "con"
+	// KindVar is a type variable, with the classes it has to be an instance of.
+	KindVar TypeKind = "var"
+	// KindApp applies Args[0] to the rest of Args, as in `Maybe a` or `m a`.
+	KindApp TypeKind = "app"
+	// KindFun is a function from Args[0] to Args[1].
+	KindFun TypeKind = "fun"
+	// KindTuple is a tuple of Args.
+	KindTuple TypeKind = "tuple"
+	// KindList is a list of Args[0].
+	KindList TypeKind = "list"
+)
+
+// Type is a printed Haskell type. Its class constraints hang off its
+// variables; Context collects them.
+type Type struct {
+	Kind    TypeKind `json:"kind"`
+	Name    string   `json:"name,omitempty"`
+	Classes []string `json:"classes,omitempty"`
+	Args    []Type   `json:"args,omitempty"`
+}
+
+// Precedences of the forms of a type, loosest first.
+const (
+	precFun = iota
+	precApp
+	precAtom
+)
+
+func ConType(name string) Type {
+	return Type{Kind: KindCon, Name: name}
+}
+
+func VarType(name string, classes ...string) Type {
+	return Type{Kind: KindVar, Name: name, Classes: classes}
+}
+
+func AppType(head Type, args ...Type) Type {
+	return Type{Kind: KindApp, Args: slices.Concat([]Type{head}, args)}
+}
+
+func FunType(from, to Type) Type {
+	return Type{Kind: KindFun, Args: []Type{from, to}}
+}
+
+func TupleType(elems ...Type) Type {
+	return Type{Kind: KindTuple, Args: elems}
+}
+
+func ListType(elem Type) Type {
+	return Type{Kind: KindList, Args: []Type{elem}}
+}
+
+// Variables returns the type variables of t in the order they first appear.
+func (t Type) Variables() []Type {
+	vars := make([]Type, 0)
+	var walk func(t Type)
+	walk = func(t Type) {
+		if t.Kind == KindVar {
+			if !slices.ContainsFunc(vars, func(v Type) bool { return v.Name == t.Name }) {
+				vars = append(vars, t)
+			}
+			return
+		}
+		for _, arg := range t.Args {
+			walk(arg)
+		}
+	}
+	walk(t)
+	return vars
+}
+
+// Context returns the class constraints of t, such as "Eq a", sorted.
+func (t Type) Context() []string {
+	context := make([]string, 0)
+	for _, v := range t.Variables() {
+		for _, class := range v.Classes {
+			context = append(context, class+" "+v.Name)
+		}
+	}
+	slices.Sort(context)
+	return context
+}
+
+// Map rebuilds t bottom up, replacing every node by f of it.
+func (t Type) Map(f func(Type) Type) Type {
+	if len(t.Args) != 0 {
+		args := make([]Type, len(t.Args))
+		for i, arg := range t.Args {
+			args[i] = arg.Map(f)
+		}
+		t.Args = args
+	}
+	return f(t)
+}
+
+// String prints t as Haskell, with its context and no more parentheses than
+// needed: `(Eq a, Show a) => [a] -> (a -> Bool) -> Maybe (a, Int)`.
+func (t Type) String() string {
+	var sb strings.Builder
+	switch context := t.Context(); len(context) {
+	case 0:
+	case 1:
+		sb.WriteString(context[0] + " => ")
+	default:
+		sb.WriteString("(" + strings.Join(context, ", ") + ") => ")
+	}
+	t.write(&sb, precFun)
+	return sb.String()
+}
+
+func (t Type) precedence() int {
+	switch t.Kind {
+	case KindFun:
+		return precFun
+	case KindApp:
+		return precApp
+	default:
+		return precAtom
+	}
+}
+
+// write prints t in a position that binds at least as tightly as prec.
+func (t Type) write(sb *strings.Builder, prec int) {
+	if t.precedence() < prec {
+		sb.WriteString("(")
+		defer sb.WriteString(")")
+	}
+	switch t.Kind {
+	case KindCon, KindVar:
+		sb.WriteString(t.Name)
+	case KindList:
+		sb.WriteString("[")
+		t.Args[0].write(sb, precFun)
+		sb.WriteString("]")
+	case KindTuple:
+		sb.WriteString("(")
+		for i, elem := range t.Args {
+			if i != 0 {
+				sb.WriteString(", ")
+			}
+			elem.write(sb, precFun)
+		}
+		sb.WriteString(")")
+	case KindFun:
+		// Arrows associate to the right
+		t.Args[0].write(sb, precApp)
+		sb.WriteString(" -> ")
+		t.Args[1].write(sb, precFun)
+	case KindApp:
+		t.Args[0].write(sb, precApp)
+		for _, arg := range t.Args[1:] {
+			sb.WriteString(" ")
+			arg.write(sb, precAtom)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package haskell
+
+import (
+	prologtool "goanna/prolog-tool"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeString(t *testing.T) {
+	a := VarType("a", "Eq", "Show")
+	testCases := []struct {
+		ty     Type
+		expect string
+	}{
+		{FunType(FunType(a, ConType("Bool")), FunType(ListType(a), ListType(a))),
+			"(Eq a, Show a) => (a -> Bool) -> [a] -> [a]"},
+		{AppType(ConType("Maybe"), TupleType(VarType("b"), ConType("Int"))), "Maybe (b, Int)"},
+		{AppType(ConType("Either"), AppType(ConType("Maybe"), VarType("a")), FunType(VarType("a"), VarType("b"))),
+			"Either (Maybe a) (a -> b)"},
+		{FunType(AppType(VarType("m", "Monad"), VarType("a")), ListType(ConType("Char"))), "Monad m => m a -> [Char]"},
+		{TupleType(), "()"},
+		{ConType("()"), "()"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expect, tc.ty.String())
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	classes := map[string][]string{"p_Ord": {"p_Eq"}}
+	parse := func(text string) prologtool.Term {
+		term, err := prologtool.ParseTerm(text)
+		assert.NoError(t, err)
+		return term
+	}
+	// (Ord x, Eq x) => x -> [y] -> (y, Maybe x)
+	term := parse("pair(pair(function,has([p_Ord,p_Eq],X)),pair(pair(function,pair(list,Y)),pair(pair(tuple,Y),pair(p_Maybe,X))))")
+	for range 20 {
+		printer := NewPrinter(classes)
+		assert.Equal(t, "Ord a => a -> [b] -> (b, Maybe a)", printer.GetType(term).String())
+	}
+
+	// A variable from a signature keeps its name, and the others avoid it
+	printer := NewPrinter(classes)
+	first := printer.PrepareType(parse("pair(pair(function,Y),has([],a__1))"))
+	second := printer.PrepareType(parse("pair(pair(function,has([],a__2)),has([p_Monad],M))"))
+	printer.AssignVars()
+	assert.Equal(t, "b -> a", printer.CompileType(first).String())
+	assert.Equal(t, "Monad m => a1 -> m", printer.CompileType(second).String())
+}
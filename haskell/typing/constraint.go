@@ -3,7 +3,9 @@ package typing
 import (
 	"fmt"
 	prolog "goanna/prolog-tool"
+	"goanna/haskell/meta"
 	"goanna/haskell/parser"
+	"slices"
 )
 
 // ConstraintGenState holds per-traversal state, wrapping the global TypingEnv.
@@ -12,10 +14,21 @@ type ConstraintGenState struct {
 	freshCounter int
 	module       string
 	global       *TypingEnv
+	// arities maps the canonical name of each data constructor to its number
+	// of arguments
+	arities map[string]int
+	// recursive are the declarations of the binding group whose rules are
+	// being generated; they are monomorphic within the group
+	recursive []string
+	// generated are the local declarations whose rules have been generated
+	generated map[string]bool
+	// rigid is set while generating the type of an annotation that an
+	// expression is checked against, whose variables are skolem constants
+	rigid bool
 }
 
 func NewConstraintGenState(global *TypingEnv) *ConstraintGenState {
-	return &ConstraintGenState{global: global}
+	return &ConstraintGenState{global: global, arities: make(map[string]int), generated: make(map[string]bool)}
 }
 
 func (s *ConstraintGenState) SetModuleName(module string) {
@@ -54,17 +67,25 @@ func (s *ConstraintGenState) headOfInstanceRule(name string, instanceID int) Rul
 }
 
 // typeOf mirrors ConstraintGenState.type_of: returns the rule(s) that look up
-// the type of a named declaration, handling the parent-scope case.
+// the type of a named declaration. A local declaration is called with the
+// variables it captures from the declarations it is nested in as the prefix
+// of its Zeta, so that it is polymorphic in everything but those. Unlike
+// type_of, which passes the caller's Zeta, this also holds for calls from
+// sibling declarations, whose Zeta starts with the same variables.
 func (s *ConstraintGenState) typeOf(name string, v prolog.LVar, head RuleHead) []prolog.LTerm {
 	collector := s.fresh()
 	s.global.AddClassVar(head.Name, collector.Value)
-	if s.global.IsParentOf(head.Name, name) {
+	if captured := s.global.Captured(name); len(captured) > 0 {
 		w := s.fresh()
 		rule1 := prolog.LStruct{Functor: name, Args: []prolog.LTerm{
 			v, prolog.Call_, prolog.Wildcard, w, prolog.Wildcard, collector,
 		}}
+		vars := make([]prolog.LTerm, len(captured))
+		for i, param := range captured {
+			vars[i] = termVar(param)
+		}
 		rule2 := prolog.Once(prolog.LStruct{Functor: "append", Args: []prolog.LTerm{
-			prolog.ZetaVar, prolog.Wildcard, w,
+			prolog.LList{Elements: vars}, prolog.Wildcard, w,
 		}})
 		return []prolog.LTerm{rule1, rule2}
 	}
@@ -74,6 +95,58 @@ func (s *ConstraintGenState) typeOf(name string, v prolog.LVar, head RuleHead) [
 	return []prolog.LTerm{rule}
 }
 
+// primitiveTypes maps the Prelude types of literals to their atoms, which
+// are those of constraint.py. String is a list of Char.
+var primitiveTypes = map[string]string{
+	"Int":   "builtin_Int",
+	"Char":  "builtin_Char",
+	"Float": "builtin_Float",
+	"Bool":  "p_Bool",
+}
+
+// pair mirrors constraint.py's pair: the application of a type constructor
+// to each of terms in turn, so that `Either a b` is pair(pair(either, A), B).
+// Every type with arguments is one, so that any of them unifies with `m a`
+// in do blocks, and the printer can read them back.
+func pair(terms ...prolog.LTerm) prolog.LTerm {
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	return prolog.LStruct{Functor: "pair", Args: []prolog.LTerm{pair(terms[:len(terms)-1]...), terms[len(terms)-1]}}
+}
+
+// listType mirrors constraint.py's list_of: the type of lists of elem.
+func listType(elem prolog.LTerm) prolog.LTerm {
+	return pair(prolog.LAtom{Value: "list"}, elem)
+}
+
+// funType mirrors constraint.py's fun_of: the type of functions from each of
+// terms but the last to the last.
+func funType(terms ...prolog.LTerm) prolog.LTerm {
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	return pair(prolog.LAtom{Value: "function"}, terms[0], funType(terms[1:]...))
+}
+
+// tupleType mirrors constraint.py's tuple_of: the type of tuples of terms.
+func tupleType(terms ...prolog.LTerm) prolog.LTerm {
+	return pair(append([]prolog.LTerm{prolog.LAtom{Value: "tuple"}}, terms...)...)
+}
+
+// hasClass mirrors constraint.py's has_class: v must be an instance of class.
+// The classes a declaration requires are collected in _Classes.
+func (s *ConstraintGenState) hasClass(v prolog.LTerm, class string, head RuleHead) prolog.LTerm {
+	classes := prolog.LVar{Value: "_Classes"}
+	if !slices.Contains(s.global.Collectors[head.Name], classes.Value) {
+		s.global.AddClassVar(head.Name, classes.Value)
+	}
+	return prolog.Once(prolog.LStruct{Functor: "member", Args: []prolog.LTerm{
+		prolog.LStruct{Functor: "with", Args: []prolog.LTerm{prolog.LAtom{Value: class}, v}},
+		classes,
+	}})
+}
+
 // ---------------------------------------------------------------------------
 // generateType: translates a Type AST node into a Prolog term.
 // Mirrors constraint.py's generate_type.
@@ -89,6 +162,13 @@ func (s *ConstraintGenState) generateType(ty parser.Type) prolog.LTerm {
 		if name == "" {
 			name = t.Name
 		}
+		// Prelude types are not renamed; use the atoms literals have
+		if primitive, ok := primitiveTypes[name]; ok {
+			return prolog.LAtom{Value: primitive}
+		}
+		if name == "String" {
+			return listType(prolog.LAtom{Value: primitiveTypes["Char"]})
+		}
 		return prolog.LAtom{Value: name}
 
 	case *parser.TyVar:
@@ -96,28 +176,33 @@ func (s *ConstraintGenState) generateType(ty parser.Type) prolog.LTerm {
 		if name == "" {
 			name = t.Name
 		}
+		// A skolem is named like those of signatures, name__decl, so that
+		// the printer shows it by its name
+		if s.rigid {
+			return prolog.LAtom{Value: t.Name + "__" + name}
+		}
 		return prolog.LVar{Value: name}
 
 	case *parser.TyApp:
 		f := s.generateType(t.Ty1)
 		arg := s.generateType(t.Ty2)
-		return prolog.LStruct{Functor: "tyapp", Args: []prolog.LTerm{f, arg}}
+		return pair(f, arg)
 
 	case *parser.TyFunction:
 		from := s.generateType(t.Ty1)
 		to := s.generateType(t.Ty2)
-		return prolog.LStruct{Functor: "->", Args: []prolog.LTerm{from, to}}
+		return funType(from, to)
 
 	case *parser.TyTuple:
 		parts := make([]prolog.LTerm, len(t.Tys))
 		for i, ty := range t.Tys {
 			parts[i] = s.generateType(ty)
 		}
-		return prolog.LStruct{Functor: "tuple", Args: parts}
+		return tupleType(parts...)
 
 	case *parser.TyList:
 		elem := s.generateType(t.Ty)
-		return prolog.LStruct{Functor: "list", Args: []prolog.LTerm{elem}}
+		return listType(elem)
 
 	case *parser.TyForall:
 		// Strip the forall/context wrapper; constraints are handled separately.
@@ -140,23 +225,21 @@ func (s *ConstraintGenState) generateConstraint(exp parser.Exp, v prolog.LTerm,
 	switch e := exp.(type) {
 
 	case *parser.ExpVar:
-		name := e.Canonical
-		if name == "" {
-			name = e.Name
-		}
-		// check if this decl is in scope
-		for _, d := range s.declarations() {
-			if d == name {
-				return s.typeOf(name, v.(prolog.LVar), head)
-			}
+		return s.variableConstraint(e.Name, e.Canonical, v, head)
+
+	case *parser.ExpHole:
+		// A bound `_name` is a variable
+		if e.Canonical != "" {
+			return s.variableConstraint(e.Name, e.Canonical, v, head)
 		}
-		// Axiom / unknown — unify with a fresh var
+		// A hole places no constraint on its type, so its type is whatever
+		// the surrounding expression demands.
 		w := s.fresh()
 		return []prolog.LTerm{prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, w}}}
 
 	case *parser.ExpApp:
 		argTy := s.fresh()
-		funTy := prolog.LStruct{Functor: "->", Args: []prolog.LTerm{argTy, v}}
+		funTy := funType(argTy, v)
 		funW := s.fresh()
 		c1 := s.generateConstraint(e.Exp1, funW, head)
 		c2 := s.generateConstraint(e.Exp2, argTy, head)
@@ -166,9 +249,7 @@ func (s *ConstraintGenState) generateConstraint(exp parser.Exp, v prolog.LTerm,
 	case *parser.ExpInfix:
 		argTy1 := s.fresh()
 		argTy2 := s.fresh()
-		funTy := prolog.LStruct{Functor: "->", Args: []prolog.LTerm{
-			argTy1, prolog.LStruct{Functor: "->", Args: []prolog.LTerm{argTy2, v}},
-		}}
+		funTy := funType(argTy1, argTy2, v)
 		opW := s.fresh()
 		c1 := s.generateConstraint(e.Exp1, argTy1, head)
 		c2 := s.generateConstraint(e.Exp2, argTy2, head)
@@ -184,27 +265,21 @@ func (s *ConstraintGenState) generateConstraint(exp parser.Exp, v prolog.LTerm,
 		for i := len(e.Pats) - 1; i >= 0; i-- {
 			pt := s.fresh()
 			paramTypes[i] = pt
-			chain = prolog.LStruct{Functor: "->", Args: []prolog.LTerm{pt, chain}}
+			chain = funType(pt, chain)
 		}
 		unify := prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, chain}}
-		bodyC := s.generateConstraint(e.Exp, body, head)
-		return append([]prolog.LTerm{unify}, bodyC...)
+		cs := []prolog.LTerm{unify}
+		for i, pat := range e.Pats {
+			cs = append(cs, s.generatePatConstraint(pat, paramTypes[i], head)...)
+		}
+		return append(cs, s.generateConstraint(e.Exp, body, head)...)
 
 	case *parser.ExpLet:
-		var cs []prolog.LTerm
-		for _, bind := range e.Binds {
-			pb, ok := bind.(*parser.PatBind)
-			if !ok {
-				continue
-			}
-			w := s.fresh()
-			cs = append(cs, s.generateConstraintPatBind(pb, w, head)...)
-		}
-		cs = append(cs, s.generateConstraint(e.Exp, v, head)...)
-		return cs
+		s.generateLocalDecls(e.Binds, head)
+		return s.generateConstraint(e.Exp, v, head)
 
 	case *parser.ExpIf:
-		boolTy := prolog.LAtom{Value: "bool"}
+		boolTy := prolog.LAtom{Value: primitiveTypes["Bool"]}
 		condW := s.fresh()
 		c1 := s.generateConstraint(e.Cond, condW, head)
 		unify := prolog.LStruct{Functor: "=", Args: []prolog.LTerm{condW, boolTy}}
@@ -216,6 +291,7 @@ func (s *ConstraintGenState) generateConstraint(exp parser.Exp, v prolog.LTerm,
 		scrutW := s.fresh()
 		cs := s.generateConstraint(e.Exp, scrutW, head)
 		for _, alt := range e.Alts {
+			cs = append(cs, s.generatePatConstraint(alt.Pat, scrutW, head)...)
 			altW := s.fresh()
 			cs = append(cs, s.generateConstraint(alt.Exp, altW, head)...)
 			cs = append(cs, prolog.LStruct{Functor: "=", Args: []prolog.LTerm{altW, v}})
@@ -230,13 +306,13 @@ func (s *ConstraintGenState) generateConstraint(exp parser.Exp, v prolog.LTerm,
 			parts[i] = w
 			cs = append(cs, s.generateConstraint(ex, w, head)...)
 		}
-		tupleTy := prolog.LStruct{Functor: "tuple", Args: parts}
+		tupleTy := tupleType(parts...)
 		cs = append(cs, prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, tupleTy}})
 		return cs
 
 	case *parser.ExpList:
 		elemTy := s.fresh()
-		listTy := prolog.LStruct{Functor: "list", Args: []prolog.LTerm{elemTy}}
+		listTy := listType(elemTy)
 		cs := []prolog.LTerm{prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, listTy}}}
 		for _, ex := range e.Exps {
 			w := s.fresh()
@@ -246,36 +322,44 @@ func (s *ConstraintGenState) generateConstraint(exp parser.Exp, v prolog.LTerm,
 		return cs
 
 	case *parser.ExpDo:
-		var cs []prolog.LTerm
+		// A do block is m a for a monad m. Every statement is in m, and the
+		// last one, which must be an expression, gives a.
+		m := s.fresh()
+		a := s.fresh()
+		cs := []prolog.LTerm{
+			s.hasClass(m, "p_Monad", head),
+			prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, pair(m, a)}},
+		}
 		for i, stmt := range e.Stmts {
-			isLast := i == len(e.Stmts)-1
+			var result prolog.LTerm = prolog.Wildcard
+			if i == len(e.Stmts)-1 {
+				result = a
+			}
 			switch st := stmt.(type) {
 			case *parser.Generator:
+				// `p <- e`: e is m t, and p matches t
 				w := s.fresh()
+				inner := s.fresh()
 				cs = append(cs, s.generateConstraint(st.Exp, w, head)...)
+				cs = append(cs, prolog.LStruct{Functor: "=", Args: []prolog.LTerm{w, pair(m, inner)}})
+				cs = append(cs, s.generatePatConstraint(st.Pat, inner, head)...)
 			case *parser.Qualifier:
 				w := s.fresh()
-				if isLast {
-					cs = append(cs, s.generateConstraint(st.Exp, v, head)...)
-				} else {
-					cs = append(cs, s.generateConstraint(st.Exp, w, head)...)
-				}
+				cs = append(cs, s.generateConstraint(st.Exp, w, head)...)
+				cs = append(cs, prolog.LStruct{Functor: "=", Args: []prolog.LTerm{w, pair(m, result)}})
 			case *parser.LetStmt:
-				for _, bind := range st.Binds {
-					pb, ok := bind.(*parser.PatBind)
-					if !ok {
-						continue
-					}
-					w := s.fresh()
-					cs = append(cs, s.generateConstraintPatBind(pb, w, head)...)
-				}
+				// A let statement is not in m: its bindings are declarations
+				// over the later statements. A block ending in one has no
+				// result, which the renamer reports as an InvalidDoBlock, so
+				// a is left free rather than tied to the bindings.
+				s.generateLocalDecls(st.Binds, head)
 			}
 		}
 		return cs
 
 	case *parser.ExpComprehension:
 		elemTy := s.fresh()
-		listTy := prolog.LStruct{Functor: "list", Args: []prolog.LTerm{elemTy}}
+		listTy := listType(elemTy)
 		cs := []prolog.LTerm{prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, listTy}}}
 		cs = append(cs, s.generateConstraint(e.Exp, elemTy, head)...)
 		for _, gen := range e.Generators {
@@ -291,7 +375,7 @@ func (s *ConstraintGenState) generateConstraint(exp parser.Exp, v prolog.LTerm,
 	case *parser.ExpLeftSection:
 		argTy := s.fresh()
 		opW := s.fresh()
-		funTy := prolog.LStruct{Functor: "->", Args: []prolog.LTerm{argTy, v}}
+		funTy := funType(argTy, v)
 		c1 := s.generateConstraint(e.Left, argTy, head)
 		c2 := s.generateConstraint(e.Op, opW, head)
 		unify := prolog.LStruct{Functor: "=", Args: []prolog.LTerm{opW, funTy}}
@@ -300,7 +384,7 @@ func (s *ConstraintGenState) generateConstraint(exp parser.Exp, v prolog.LTerm,
 	case *parser.ExpRightSection:
 		argTy := s.fresh()
 		opW := s.fresh()
-		funTy := prolog.LStruct{Functor: "->", Args: []prolog.LTerm{argTy, v}}
+		funTy := funType(argTy, v)
 		c1 := s.generateConstraint(e.Right, argTy, head)
 		c2 := s.generateConstraint(e.Op, opW, head)
 		unify := prolog.LStruct{Functor: "=", Args: []prolog.LTerm{opW, funTy}}
@@ -316,17 +400,44 @@ func (s *ConstraintGenState) generateConstraint(exp parser.Exp, v prolog.LTerm,
 		w := s.fresh()
 		return s.generateConstraint(e.Exp, w, head)
 
+	case *parser.ExpTyped:
+		// The annotation is a rule of its own, tied to the annotation, so that
+		// a wrong annotation can be blamed rather than the expression. Its
+		// variables are rigid, as in a signature: the expression is checked
+		// against the annotation for all of them, and has any instance of it.
+		w := s.fresh()
+		s.rigid = true
+		checked := prolog.LStruct{Functor: "=", Args: []prolog.LTerm{w, s.generateType(e.Ty)}}
+		s.rigid = false
+		instance := prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, s.generateType(e.Ty)}}
+		s.addRules([]prolog.LTerm{checked, instance}, head, e.Id())
+		return s.generateConstraint(e.Exp, w, head)
+
+	case *parser.ExpRecordCon:
+		// Fields left out are undefined, so any arguments will do
+		args := make([]prolog.LTerm, s.arities[e.Con.Canonical])
+		for i := range args {
+			args[i] = s.fresh()
+		}
+		cs := s.constructorConstraint(e.Con.Canonical, args, v, head)
+		return append(cs, s.generateFieldBinds(e.Fields, v, head)...)
+
+	case *parser.ExpRecordUpdate:
+		// Updates keep the type of the record
+		cs := s.generateConstraint(e.Exp, v, head)
+		return append(cs, s.generateFieldBinds(e.Fields, v, head)...)
+
 	case *parser.Lit:
 		var litTy prolog.LTerm
 		switch e.Lit {
 		case "integer":
-			litTy = prolog.LAtom{Value: "int"}
+			litTy = prolog.LAtom{Value: primitiveTypes["Int"]}
 		case "string":
-			litTy = prolog.LAtom{Value: "string"}
+			litTy = listType(prolog.LAtom{Value: primitiveTypes["Char"]})
 		case "char":
-			litTy = prolog.LAtom{Value: "char"}
+			litTy = prolog.LAtom{Value: primitiveTypes["Char"]}
 		case "float":
-			litTy = prolog.LAtom{Value: "float"}
+			litTy = prolog.LAtom{Value: primitiveTypes["Float"]}
 		default:
 			litTy = s.fresh()
 		}
@@ -337,19 +448,154 @@ func (s *ConstraintGenState) generateConstraint(exp parser.Exp, v prolog.LTerm,
 	}
 }
 
-// generateConstraintPatBind generates constraints for a PatBind binding.
-func (s *ConstraintGenState) generateConstraintPatBind(pb *parser.PatBind, v prolog.LTerm, head RuleHead) []prolog.LTerm {
-	var rhsExp parser.Exp
-	switch r := pb.Rhs.(type) {
-	case *parser.UnguardedRhs:
-		rhsExp = r.Exp
-	case *parser.GuardedRhs:
-		// Use first branch for simplicity
-		if len(r.Branches) > 0 {
-			rhsExp = r.Branches[0].Exp
+// generateFieldBinds constrains the fields set by a record construction or
+// update of type record.
+func (s *ConstraintGenState) generateFieldBinds(fields []parser.FieldBind, record prolog.LTerm, head RuleHead) []prolog.LTerm {
+	var cs []prolog.LTerm
+	for i := range fields {
+		fieldW := s.fresh()
+		cs = append(cs, s.fieldConstraint(&fields[i].Field, record, fieldW, head)...)
+		cs = append(cs, s.generateConstraint(fields[i].Exp, fieldW, head)...)
+	}
+	return cs
+}
+
+// fieldConstraint makes fieldTy the type of field in a record of type record,
+// through the type of its selector.
+func (s *ConstraintGenState) fieldConstraint(field *parser.ExpVar, record prolog.LTerm, fieldTy prolog.LTerm, head RuleHead) []prolog.LTerm {
+	selector := field.Canonical
+	if selector == "" || !slices.Contains(s.declarations(), selector) {
+		return nil
+	}
+	selW := s.fresh()
+	selTy := funType(record, fieldTy)
+	cs := s.typeOf(selector, selW, head)
+	return append(cs, prolog.LStruct{Functor: "=", Args: []prolog.LTerm{selW, selTy}})
+}
+
+// constructorConstraint makes the constructor con a function from args to v.
+func (s *ConstraintGenState) constructorConstraint(con string, args []prolog.LTerm, v prolog.LTerm, head RuleHead) []prolog.LTerm {
+	if !slices.Contains(s.declarations(), con) {
+		return nil
+	}
+	conW := s.fresh()
+	conTy := v
+	for i := len(args) - 1; i >= 0; i-- {
+		conTy = funType(args[i], conTy)
+	}
+	cs := s.typeOf(con, conW, head)
+	return append(cs, prolog.LStruct{Functor: "=", Args: []prolog.LTerm{conW, conTy}})
+}
+
+// variableConstraint types the use of the variable name, whose canonical
+// name renaming gave as canonical, as v.
+func (s *ConstraintGenState) variableConstraint(name string, canonical string, v prolog.LTerm, head RuleHead) []prolog.LTerm {
+	resolved := canonical
+	if resolved == "" {
+		resolved = name
+	}
+	// Within its binding group a declaration is monomorphic
+	if slices.Contains(s.recursive, resolved) {
+		return []prolog.LTerm{prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, termVar(resolved)}}}
+	}
+	// check if this decl is in scope
+	for _, d := range s.declarations() {
+		if d == resolved {
+			return s.typeOf(resolved, v.(prolog.LVar), head)
+		}
+	}
+	// A local variable has the type of the pattern that binds it
+	if canonical != "" && canonical != name {
+		return []prolog.LTerm{prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, termVar(canonical)}}}
+	}
+	// Axiom / unknown — unify with a fresh var
+	w := s.fresh()
+	return []prolog.LTerm{prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, w}}}
+}
+
+// termVar is the Prolog variable for the type of the local variable with the
+// given canonical name, shared by the pattern that binds it and its uses.
+func termVar(canonical string) prolog.LVar {
+	return prolog.LVar{Value: "_" + canonical}
+}
+
+// generatePatConstraint constrains v, the type of the values pat matches, and
+// the types of the variables pat binds.
+func (s *ConstraintGenState) generatePatConstraint(pat parser.Pat, v prolog.LTerm, head RuleHead) []prolog.LTerm {
+	switch p := pat.(type) {
+	case *parser.PVar:
+		if p.Canonical == "" {
+			return nil
+		}
+		return []prolog.LTerm{prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, termVar(p.Canonical)}}}
+
+	case *parser.PAs:
+		cs := s.generatePatConstraint(&p.Var, v, head)
+		return append(cs, s.generatePatConstraint(p.Pat, v, head)...)
+
+	case *parser.PIrrefutable:
+		// Laziness and strictness do not change the type
+		return s.generatePatConstraint(p.Pat, v, head)
+
+	case *parser.PBang:
+		return s.generatePatConstraint(p.Pat, v, head)
+
+	case *parser.Lit:
+		return s.generateConstraint(p, v, head)
+
+	case *parser.PApp:
+		args := make([]prolog.LTerm, len(p.Pats))
+		var cs []prolog.LTerm
+		for i, sub := range p.Pats {
+			w := s.fresh()
+			args[i] = w
+			cs = append(cs, s.generatePatConstraint(sub, w, head)...)
+		}
+		return append(cs, s.constructorConstraint(p.Constructor.Canonical, args, v, head)...)
+
+	case *parser.PInfix:
+		left, right := s.fresh(), s.fresh()
+		cs := s.generatePatConstraint(p.Pat1, left, head)
+		cs = append(cs, s.generatePatConstraint(p.Pat2, right, head)...)
+		return append(cs, s.constructorConstraint(p.Op.Canonical, []prolog.LTerm{left, right}, v, head)...)
+
+	case *parser.PRecord:
+		args := make([]prolog.LTerm, s.arities[p.Con.Canonical])
+		for i := range args {
+			args[i] = s.fresh()
+		}
+		cs := s.constructorConstraint(p.Con.Canonical, args, v, head)
+		for i := range p.Fields {
+			fieldW := s.fresh()
+			cs = append(cs, s.fieldConstraint(&p.Fields[i].Field, v, fieldW, head)...)
+			cs = append(cs, s.generatePatConstraint(p.Fields[i].Pat, fieldW, head)...)
 		}
+		return cs
+
+	case *parser.PTuple:
+		parts := make([]prolog.LTerm, len(p.Pats))
+		var cs []prolog.LTerm
+		for i, sub := range p.Pats {
+			w := s.fresh()
+			parts[i] = w
+			cs = append(cs, s.generatePatConstraint(sub, w, head)...)
+		}
+		tupleTy := tupleType(parts...)
+		return append(cs, prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, tupleTy}})
+
+	case *parser.PList:
+		elemTy := s.fresh()
+		listTy := listType(elemTy)
+		cs := []prolog.LTerm{prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, listTy}}}
+		for _, sub := range p.Pats {
+			cs = append(cs, s.generatePatConstraint(sub, elemTy, head)...)
+		}
+		return cs
+
+	default:
+		// Wildcards match anything
+		return nil
 	}
-	return s.generateConstraint(rhsExp, v, head)
 }
 
 // ---------------------------------------------------------------------------
@@ -358,69 +604,114 @@ func (s *ConstraintGenState) generateConstraintPatBind(pb *parser.PatBind, v pro
 // ---------------------------------------------------------------------------
 
 func (s *ConstraintGenState) GetAllConstraints(modules []*parser.Module) {
+	// Local declarations capture the params of those they are nested in
+	graph := meta.GetDeclGraph(modules)
+	s.global.DeclMap = graph
+	s.global.Arguments = meta.InheritParams(meta.GetDeclParams(modules), graph)
+
+	// Constructors and selectors may be used before their data declaration,
+	// and local declarations before they are bound
 	for _, m := range modules {
-		s.module = m.Name
 		for _, decl := range m.Decls {
-			s.generateDeclConstraints(decl)
+			if d, ok := decl.(*parser.DataDecl); ok {
+				s.declareData(d)
+			}
 		}
+		s.declareBindings(m)
+	}
+	for _, m := range modules {
+		s.module = m.Name
+		s.generateDecls(m.Decls)
 	}
 }
 
 func (s *ConstraintGenState) generateDeclConstraints(decl parser.Decl) {
 	switch d := decl.(type) {
-	case *parser.PatBind:
-		name := ""
-		switch p := d.Pat.(type) {
-		case *parser.PVar:
-			name = p.Canonical
-			if name == "" {
-				name = p.Name
-			}
-		case *parser.PApp:
-			name = p.Constructor.Canonical
-			if name == "" {
-				name = p.Constructor.Name
-			}
-		}
-		if name == "" {
-			return
-		}
-		head := s.headOfTypingRule(name)
-		v := prolog.LVar{Value: "T"}
-		cs := s.generateConstraintPatBind(d, v, head)
-		s.addRules(cs, head, d.Id())
-
-		// Recurse into where-clauses
-		var wheres []parser.Decl
-		switch r := d.Rhs.(type) {
-		case *parser.UnguardedRhs:
-			wheres = r.Wheres
-		case *parser.GuardedRhs:
-			wheres = r.Wheres
-		}
-		for _, w := range wheres {
-			s.generateDeclConstraints(w)
-		}
-
 	case *parser.InstDecl:
 		instName := d.Canonical
 		if instName == "" {
 			instName = d.Name
 		}
 		head := s.headOfInstanceRule(instName, d.Id())
-		for _, inner := range d.Body {
-			pb, ok := inner.(*parser.PatBind)
-			if !ok {
-				continue
-			}
+		for _, method := range groupClauses(d.Body) {
 			v := s.fresh()
-			cs := s.generateConstraintPatBind(pb, v, head)
-			s.addRules(cs, head, d.Id())
+			for _, pb := range method {
+				s.generateBinding(pb, v, head)
+			}
 		}
 
 	case *parser.ClassDecl:
-		for _, inner := range d.Decls {
-			s.generateDeclConstraints(inner)
+		s.generateDecls(d.Decls)
+
+	case *parser.DataDecl:
+		s.generateDataConstraints(d)
+	}
+}
+
+// declareData records the constructors and field selectors of a data
+// declaration as declarations.
+func (s *ConstraintGenState) declareData(d *parser.DataDecl) {
+	for i := range d.Constructors {
+		con := &d.Constructors[i]
+		s.arities[con.Canonical] = len(con.FieldTypes())
+		names := []string{con.Canonical}
+		for _, field := range con.Fields {
+			names = append(names, field.Canonicals...)
+		}
+		for _, name := range names {
+			if name != "" && !slices.Contains(s.global.Declarations, name) {
+				s.global.Declarations = append(s.global.Declarations, name)
+			}
+		}
+	}
+}
+
+// generateDataConstraints types the constructors of a data declaration as
+// functions from their fields to the data type, and each field selector as a
+// function from the data type to the field. Each derived class gets an
+// instance for the data type, which requires the class of each of its
+// parameters, as `deriving Eq` on `data T a` gives `instance Eq a => Eq (T a)`.
+// All of these are axioms.
+func (s *ConstraintGenState) generateDataConstraints(d *parser.DataDecl) {
+	var dataTy prolog.LTerm = prolog.LAtom{Value: d.DHead.Canonical}
+	for _, tv := range d.DHead.TypeVars {
+		dataTy = pair(dataTy, s.generateType(&tv))
+	}
+	t := prolog.LVar{Value: "T"}
+
+	selectors := make([]string, 0)
+	for i := range d.Constructors {
+		con := &d.Constructors[i]
+		conTy := dataTy
+		tys := con.FieldTypes()
+		for j := len(tys) - 1; j >= 0; j-- {
+			conTy = funType(s.generateType(tys[j]), conTy)
+		}
+		s.addAxiom(prolog.LStruct{Functor: "=", Args: []prolog.LTerm{t, conTy}}, s.headOfTypingRule(con.Canonical))
+
+		for _, field := range con.Fields {
+			for _, selector := range field.Canonicals {
+				// A field shared by several constructors has one selector
+				if slices.Contains(selectors, selector) {
+					continue
+				}
+				selectors = append(selectors, selector)
+				selTy := funType(dataTy, s.generateType(field.Ty))
+				s.addAxiom(prolog.LStruct{Functor: "=", Args: []prolog.LTerm{t, selTy}}, s.headOfTypingRule(selector))
+			}
+		}
+	}
+
+	for i := range d.Deriving {
+		class := &d.Deriving[i]
+		className := class.Canonical
+		if className == "" {
+			className = class.Name
+		}
+		head := s.headOfInstanceRule(className, class.Id())
+		s.addAxiom(prolog.LStruct{Functor: "=", Args: []prolog.LTerm{t, dataTy}}, head)
+		for _, tv := range d.DHead.TypeVars {
+			s.addAxiom(prolog.LStruct{Functor: className, Args: []prolog.LTerm{s.generateType(&tv)}}, head)
 		}
 	}
 }
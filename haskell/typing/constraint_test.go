@@ -0,0 +1,317 @@
+package typing
+
+import (
+	"fmt"
+	"goanna/haskell/parser"
+	"goanna/haskell/rename"
+	"goanna/inventory"
+	prolog "goanna/prolog-tool"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// generate parses and renames code, and returns the typing rules of its
+// declarations.
+func generate(code string) *TypingEnv {
+	counter := 0
+	modules := []*parser.Module{parser.ParseWithCounter([]byte(code), "Main", &counter)}
+	rename.RenameAll(modules)
+	env := NewTypingEnv()
+	NewConstraintGenState(env).GetAllConstraints(modules)
+	return env
+}
+
+// unifier solves the unifications of the rules of one head. Variables are
+// bound in bindings; every `_` is a variable of its own.
+type unifier struct {
+	bindings  map[string]prolog.LTerm
+	wildcards int
+}
+
+func (u *unifier) walk(term prolog.LTerm) prolog.LTerm {
+	for {
+		v, ok := term.(prolog.LVar)
+		if !ok {
+			return term
+		}
+		bound, ok := u.bindings[v.Value]
+		if !ok {
+			return term
+		}
+		term = bound
+	}
+}
+
+// rename gives every `_` in term a name of its own.
+func (u *unifier) rename(term prolog.LTerm) prolog.LTerm {
+	switch t := term.(type) {
+	case prolog.LVar:
+		if t.Value == "_" {
+			u.wildcards++
+			return prolog.LVar{Value: fmt.Sprintf("_wildcard%d", u.wildcards)}
+		}
+	case prolog.LStruct:
+		args := make([]prolog.LTerm, len(t.Args))
+		for i, arg := range t.Args {
+			args[i] = u.rename(arg)
+		}
+		return prolog.LStruct{Functor: t.Functor, Args: args}
+	case prolog.LList:
+		elements := make([]prolog.LTerm, len(t.Elements))
+		for i, element := range t.Elements {
+			elements[i] = u.rename(element)
+		}
+		return prolog.LList{Elements: elements}
+	}
+	return term
+}
+
+func (u *unifier) occurs(name string, term prolog.LTerm) bool {
+	switch t := u.walk(term).(type) {
+	case prolog.LVar:
+		return t.Value == name
+	case prolog.LStruct:
+		for _, arg := range t.Args {
+			if u.occurs(name, arg) {
+				return true
+			}
+		}
+	case prolog.LList:
+		for _, element := range t.Elements {
+			if u.occurs(name, element) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (u *unifier) unify(a, b prolog.LTerm) bool {
+	a, b = u.walk(a), u.walk(b)
+	if v, ok := a.(prolog.LVar); ok {
+		if w, ok := b.(prolog.LVar); ok && v.Value == w.Value {
+			return true
+		}
+		if u.occurs(v.Value, b) {
+			return false
+		}
+		u.bindings[v.Value] = b
+		return true
+	}
+	if _, ok := b.(prolog.LVar); ok {
+		return u.unify(b, a)
+	}
+	switch s := a.(type) {
+	case prolog.LAtom:
+		t, ok := b.(prolog.LAtom)
+		return ok && s.Value == t.Value
+	case prolog.LStruct:
+		t, ok := b.(prolog.LStruct)
+		if !ok || s.Functor != t.Functor || len(s.Args) != len(t.Args) {
+			return false
+		}
+		for i := range s.Args {
+			if !u.unify(s.Args[i], t.Args[i]) {
+				return false
+			}
+		}
+		return true
+	case prolog.LList:
+		t, ok := b.(prolog.LList)
+		if !ok || len(s.Elements) != len(t.Elements) {
+			return false
+		}
+		for i := range s.Elements {
+			if !u.unify(s.Elements[i], t.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// unifiable reports whether the unifications in the rules of every head of
+// env hold together. Calls of other declarations are not solved.
+func unifiable(env *TypingEnv) bool {
+	heads := make(map[RuleHead]*unifier)
+	for _, rule := range env.Rules {
+		head := rule.Head
+		head.ID = nil
+		if rule.Head.ID != nil {
+			head.Name = fmt.Sprintf("%s/%d", head.Name, *rule.Head.ID)
+		}
+		u, ok := heads[head]
+		if !ok {
+			u = &unifier{bindings: make(map[string]prolog.LTerm)}
+			heads[head] = u
+		}
+		body, ok := u.rename(rule.Body).(prolog.LStruct)
+		if !ok || body.Functor != "=" {
+			continue
+		}
+		if !u.unify(body.Args[0], body.Args[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// predicates renames the calls of declarations in term to the predicates of
+// consult.
+func predicates(term prolog.LTerm, predicate func(string) string) prolog.LTerm {
+	switch t := term.(type) {
+	case prolog.LStruct:
+		args := make([]prolog.LTerm, len(t.Args))
+		for i, arg := range t.Args {
+			args[i] = predicates(arg, predicate)
+		}
+		return prolog.LStruct{Functor: predicate(t.Functor), Args: args}
+	case prolog.LList:
+		elements := make([]prolog.LTerm, len(t.Elements))
+		for i, element := range t.Elements {
+			elements[i] = predicates(element, predicate)
+		}
+		return prolog.LList{Elements: elements}
+	}
+	return term
+}
+
+// consult runs the rules of env through Prolog, as the inventory does for the
+// rules of the translator, with the rules of instances of classes without
+// superclasses, and reports whether every declaration type checks. The canonical names of declarations, V0,
+// V1, ..., are variables in Prolog, so their predicates are v0, v1, ....
+func consult(env *TypingEnv, instances ...inventory.Rule) bool {
+	predicate := func(name string) string {
+		if slices.Contains(env.Declarations, name) {
+			return strings.ToLower(name)
+		}
+		return name
+	}
+	input := inventory.Input{
+		Arguments:  make(map[string][]string),
+		Collectors: make(map[string][]string),
+	}
+	for _, name := range env.Declarations {
+		input.Declarations = append(input.Declarations, predicate(name))
+		input.Arguments[predicate(name)] = env.Arguments[name]
+		input.Collectors[predicate(name)] = env.Collectors[name]
+	}
+	for i, rule := range env.Rules {
+		head := inventory.RuleHead{Name: predicate(rule.Head.Name), Module: rule.Head.Module, Type: string(rule.Head.Kind)}
+		if rule.Head.ID != nil {
+			head.Id = *rule.Head.ID
+		}
+		body := predicates(rule.Body, predicate).String()
+		input.Rules = append(input.Rules, inventory.Rule{Id: i + 1, Head: head, Body: body, IsAxiom: true})
+	}
+	input.Classes = make(map[string][]string)
+	for i, rule := range instances {
+		rule.Id = len(env.Rules) + i + 1
+		input.Rules = append(input.Rules, rule)
+		input.Classes[rule.Head.Name] = nil
+	}
+	inv := inventory.NewInventory(input)
+	inv.Generalize(0)
+	return inv.AxiomCheck()
+}
+
+func expectTyping(t *testing.T, cases map[string]bool) {
+	t.Helper()
+	for code, ok := range cases {
+		if unifiable(generate(code)) != ok {
+			t.Errorf("%q: expected it to type check: %v, got %v", code, ok, !ok)
+		}
+	}
+}
+
+func TestPatternConstraints(t *testing.T) {
+	expectTyping(t, map[string]bool{
+		"f = (\\(a, b) -> 1) (1, 2)":       true,
+		"f = (\\(a, b) -> 1) [1]":          false,
+		"f = (\\[a] -> 1) [1]":             true,
+		"f = (\\[a] -> 1) 'c'":             false,
+		"f = case (1, 'c') of (a, b) -> 1": true,
+		"f = case 1 of (a, b) -> 1":        false,
+		"f = case [1] of [] -> 1":          true,
+		"f = case (1, 2) of [] -> 1":       false,
+	})
+}
+
+func TestDoBlocks(t *testing.T) {
+	expectTyping(t, map[string]bool{
+		// IO
+		"f a b = do\n  x <- (a :: IO Int)\n  (b :: IO Char)":    true,
+		"f a b = do\n  x <- (a :: IO Int)\n  (b :: Maybe Char)": false,
+		// Maybe
+		"f a = do\n  x <- (a :: Maybe Int)\n  a":                 true,
+		"f a = do\n  x <- (a :: Maybe Int)\n  (a :: Maybe Char)": false,
+		// lists
+		"f = do\n  x <- [1, 2]\n  [x, x]":                true,
+		"f = do\n  x <- [1, 2]\n  let y = 'c'\n  [x, 2]": true,
+		"f = do\n  x <- [1, 2]\n  [x, 'c']":              false,
+		"f a = do\n  x <- [1, 2]\n  (a :: Maybe Int)":    false,
+		"f = do\n  x <- \"abc\"\n  [x]":                  true,
+	})
+}
+
+func TestDoBlockMonads(t *testing.T) {
+	// The Prelude's instance of Monad for lists
+	monad := inventory.Rule{Head: inventory.RuleHead{Name: "p_Monad", Type: "instance"}, Body: "T = list"}
+	cases := []struct {
+		code      string
+		instances []inventory.Rule
+		ok        bool
+	}{
+		{"f = do\n  x <- [1, 2]\n  [x, x]", []inventory.Rule{monad}, true},
+		{"f = do\n  x <- [1, 2]\n  [x, 'c']", []inventory.Rule{monad}, false},
+		{"f = do\n  x <- [1, 2]\n  [x, x]", nil, false},
+		// ((,) Int) unifies with m, but is not a Monad
+		{"f = do\n  x <- (1, 'c')\n  (1, x)", []inventory.Rule{monad}, false},
+	}
+	for _, c := range cases {
+		if consult(generate(c.code), c.instances...) != c.ok {
+			t.Errorf("%q with %d instances: expected it to type check: %v, got %v", c.code, len(c.instances), c.ok, !c.ok)
+		}
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	expectTyping(t, map[string]bool{
+		"f = (1 :: Int)":                 true,
+		"f = (1 :: Char)":                false,
+		"f = (1 :: a)":                   false,
+		"f = ([1] :: [a])":               false,
+		"f = ([] :: [a])":                true,
+		"f = ((\\x -> x) :: a -> a)":     true,
+		"f = ((\\x -> 1) :: a -> a)":     false,
+		"f = ((\\x -> x) :: a -> b)":     false,
+		"f = ((\\x -> x) :: a -> a) 'c'": true,
+	})
+}
+
+func TestDerivingContext(t *testing.T) {
+	env := generate("data T a b = T a b deriving (Eq, Show)\n")
+	instances := make(map[string][]string)
+	for _, rule := range env.Rules {
+		if rule.Head.Kind == RuleKindInstance {
+			instances[rule.Head.Name] = append(instances[rule.Head.Name], rule.Body.String())
+		}
+	}
+	for _, class := range []string{"Eq", "Show"} {
+		expect := []string{"T = pair(pair(t0, P0), P1)", class + "(P0)", class + "(P1)"}
+		if !slices.Equal(instances[class], expect) {
+			t.Errorf("Expected the derived %s instance to be %v, got %v", class, expect, instances[class])
+		}
+	}
+}
+
+func TestHoles(t *testing.T) {
+	expectTyping(t, map[string]bool{
+		"f = if _x then 1 else 2":                   true,
+		"f = (\\_x -> if _x then 1 else 2) 'c'":     false,
+		"f = (\\_x -> if _y then 1 else 2) 'c'":     true,
+		"f = case 'c' of _x -> if _x then 1 else 2": false,
+	})
+}
@@ -0,0 +1,98 @@
+package typing
+
+import (
+	"goanna/haskell/parser"
+	prolog "goanna/prolog-tool"
+)
+
+// groupClauses returns the equations of each function or variable declared
+// in decls, in the order of their first equation. Pattern bindings, which
+// declare no function, are left out.
+func groupClauses(decls []parser.Decl) [][]*parser.PatBind {
+	index := make(map[string]int)
+	var functions [][]*parser.PatBind
+	for _, decl := range decls {
+		pb, ok := decl.(*parser.PatBind)
+		if !ok {
+			continue
+		}
+		name := bindingName(pb)
+		if name == "" {
+			continue
+		}
+		if _, ok := index[name]; !ok {
+			index[name] = len(functions)
+			functions = append(functions, nil)
+		}
+		functions[index[name]] = append(functions[index[name]], pb)
+	}
+	return functions
+}
+
+// generateDecls generates the rules of the declarations of a module or class.
+// All equations of a function are typed under one head, as one declaration.
+func (s *ConstraintGenState) generateDecls(decls []parser.Decl) {
+	functions := groupClauses(decls)
+	next := 0
+	for _, decl := range decls {
+		pb, ok := decl.(*parser.PatBind)
+		if !ok {
+			s.generateDeclConstraints(decl)
+			continue
+		}
+		// A function is generated at its first equation
+		if next == len(functions) || functions[next][0] != pb {
+			continue
+		}
+		head := s.headOfTypingRule(bindingName(pb))
+		for _, clause := range functions[next] {
+			s.generateBinding(clause, prolog.LVar{Value: "T"}, head)
+		}
+		next++
+	}
+}
+
+// generateClause adds the rules typing an equation, `f p1 ... pn = e`, as
+// v = t1 -> ... -> tn -> te. The equations of a function share v. The rules
+// of each pattern, guard and body are blamed on it rather than on the whole
+// equation.
+func (s *ConstraintGenState) generateClause(pb *parser.PatBind, v prolog.LTerm, head RuleHead) {
+	if app, ok := pb.Pat.(*parser.PApp); ok && len(app.Pats) > 0 {
+		rhsW := s.fresh()
+		var chain prolog.LTerm = rhsW
+		paramTypes := make([]prolog.LVar, len(app.Pats))
+		for i := len(app.Pats) - 1; i >= 0; i-- {
+			paramTypes[i] = s.fresh()
+			chain = funType(paramTypes[i], chain)
+		}
+		s.addRule(prolog.LStruct{Functor: "=", Args: []prolog.LTerm{v, chain}}, head, pb.Id())
+		for i, pat := range app.Pats {
+			s.addRules(s.generatePatConstraint(pat, paramTypes[i], head), head, pat.Id())
+		}
+		v = rhsW
+	}
+
+	switch r := pb.Rhs.(type) {
+	case *parser.UnguardedRhs:
+		s.generateBody(r.Exp, v, head)
+	case *parser.GuardedRhs:
+		boolTy := prolog.LAtom{Value: primitiveTypes["Bool"]}
+		for _, branch := range r.Branches {
+			for _, guard := range branch.Guards {
+				w := s.fresh()
+				s.generateBody(guard, w, head)
+				s.addRule(prolog.LStruct{Functor: "=", Args: []prolog.LTerm{w, boolTy}}, head, guard.Id())
+			}
+			s.generateBody(branch.Exp, v, head)
+		}
+	}
+}
+
+// generateBody adds the rules typing exp, the body or a guard of an
+// equation, as v, blamed on exp.
+func (s *ConstraintGenState) generateBody(exp parser.Exp, v prolog.LTerm, head RuleHead) {
+	if exp == nil {
+		return
+	}
+	s.addRules(s.generateConstraint(exp, v, head), head, exp.Id())
+}
@@ -0,0 +1,26 @@
+package typing
+
+import "testing"
+
+func TestEquations(t *testing.T) {
+	env := generate("k 0 = 1\nk n = n\n")
+	heads := make(map[string]int)
+	for _, rule := range env.Rules {
+		heads[rule.Head.Name]++
+	}
+	if len(heads) != 1 || len(env.Declarations) != 1 {
+		t.Errorf("Expected the equations of k to be typed under one head, got %v", heads)
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	expectTyping(t, map[string]bool{
+		"k 0 = 1\nk n = n":                    true,
+		"k 0 = 1\nk n = 'c'":                  false,
+		"k 0 'c' = 1\nk n m = n":              true,
+		"k 0 'c' = 1\nk 'c' m = 1":            false,
+		"k n\n  | n = 1\n  | otherwise = 2":   true,
+		"k n\n  | 'c' = 1\n  | otherwise = 2": false,
+		"k n\n  | n = 1\nk 0 = 2":             false,
+	})
+}
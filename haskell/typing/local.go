@@ -0,0 +1,226 @@
+package typing
+
+import (
+	"goanna/haskell/parser"
+	prolog "goanna/prolog-tool"
+	"slices"
+)
+
+// bindingName returns the canonical name of the declaration pb binds, or ""
+// if it is a pattern binding such as `(a, b) = e`, whose variables are bound
+// in the enclosing declaration.
+func bindingName(pb *parser.PatBind) string {
+	switch p := pb.Pat.(type) {
+	case *parser.PVar:
+		if p.Canonical == "" {
+			return p.Name
+		}
+		return p.Canonical
+	case *parser.PApp:
+		if p.Constructor.Canonical == "" {
+			return p.Constructor.Name
+		}
+		return p.Constructor.Canonical
+	}
+	return ""
+}
+
+// wheresOf returns the declarations of the where clause of pb.
+func wheresOf(pb *parser.PatBind) []parser.Decl {
+	switch r := pb.Rhs.(type) {
+	case *parser.UnguardedRhs:
+		return r.Wheres
+	case *parser.GuardedRhs:
+		return r.Wheres
+	}
+	return nil
+}
+
+// declareBindings records every declaration of m, top-level or local, so that
+// it may be used before it is bound. Instance methods are typed as part of
+// their instance, so they are not declarations of their own.
+func (s *ConstraintGenState) declareBindings(m *parser.Module) {
+	traverser := parser.NewTraverser(
+		func(_ int, ast parser.AST, parent parser.AST) int {
+			pb, ok := ast.(*parser.PatBind)
+			if !ok {
+				return 0
+			}
+			if _, ok := parent.(*parser.InstDecl); ok {
+				return 0
+			}
+			if name := bindingName(pb); name != "" && !slices.Contains(s.global.Declarations, name) {
+				s.global.Declarations = append(s.global.Declarations, name)
+			}
+			return 0
+		},
+		nil,
+		0,
+	)
+	traverser.Visit(m, nil)
+}
+
+// generateBinding adds the rules typing pb as v under head, and generates the
+// declarations of its where clause.
+func (s *ConstraintGenState) generateBinding(pb *parser.PatBind, v prolog.LTerm, head RuleHead) {
+	s.generateLocalDecls(wheresOf(pb), head)
+	s.generateClause(pb, v, head)
+}
+
+// generateLocalDecls generates the rules of the declarations of a let or
+// where, nested in the declaration whose rules head heads. Each local
+// function or variable is a declaration of its own, which captures the
+// variables bound by the declarations it is nested in, and which is
+// polymorphic in everything else. The variables of a pattern binding are
+// bound in the enclosing declaration, so its rules are added under head.
+func (s *ConstraintGenState) generateLocalDecls(decls []parser.Decl, head RuleHead) {
+	for _, decl := range decls {
+		pb, ok := decl.(*parser.PatBind)
+		if !ok || bindingName(pb) != "" {
+			continue
+		}
+		w := s.fresh()
+		s.addRules(s.generatePatConstraint(pb.Pat, w, head), head, pb.Pat.Id())
+		s.generateBinding(pb, w, head)
+	}
+	for _, group := range bindingGroups(groupClauses(decls)) {
+		s.generateGroup(group)
+	}
+}
+
+// generateGroup generates the rules of a binding group: local declarations
+// that are mutually recursive. Within the group its members are monomorphic,
+// so each member is typed by the bodies of all of them, sharing one type
+// variable per member. A member that is not recursive is typed by its own
+// body.
+func (s *ConstraintGenState) generateGroup(group []*parser.PatBind) {
+	var names []string
+	for _, pb := range group {
+		if name := bindingName(pb); !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	if s.generated[names[0]] {
+		return
+	}
+	for _, name := range names {
+		s.generated[name] = true
+	}
+
+	outer := s.recursive
+	defer func() { s.recursive = outer }()
+
+	if len(names) == 1 && !slices.ContainsFunc(group, func(pb *parser.PatBind) bool {
+		return slices.Contains(usedNames(pb), names[0])
+	}) {
+		s.recursive = nil
+		head := s.headOfTypingRule(names[0])
+		for _, pb := range group {
+			s.generateBinding(pb, prolog.LVar{Value: "T"}, head)
+		}
+		return
+	}
+
+	s.recursive = names
+	for _, name := range names {
+		head := s.headOfTypingRule(name)
+		s.addAxiom(prolog.LStruct{Functor: "=", Args: []prolog.LTerm{prolog.LVar{Value: "T"}, termVar(name)}}, head)
+		for _, pb := range group {
+			s.generateBinding(pb, termVar(bindingName(pb)), head)
+		}
+	}
+}
+
+// usedNames returns the canonical names of the variables pb uses.
+func usedNames(pb *parser.PatBind) []string {
+	var used []string
+	traverser := parser.NewTraverser(
+		func(_ int, ast parser.AST, parent parser.AST) int {
+			switch v := ast.(type) {
+			case *parser.ExpVar:
+				if v.Canonical != "" {
+					used = append(used, v.Canonical)
+				}
+			case *parser.ExpHole:
+				if v.Canonical != "" {
+					used = append(used, v.Canonical)
+				}
+			}
+			return 0
+		},
+		nil,
+		0,
+	)
+	traverser.Visit(pb, nil)
+	return used
+}
+
+// bindingGroups partitions sibling functions, given by their clauses, into
+// the strongly connected components of their uses of each other, using
+// Tarjan's algorithm. A component comes after every component it uses, and
+// its clauses are in declaration order.
+func bindingGroups(clauses [][]*parser.PatBind) [][]*parser.PatBind {
+	index := make(map[string]int, len(clauses))
+	for i, function := range clauses {
+		index[bindingName(function[0])] = i
+	}
+	uses := make([][]int, len(clauses))
+	for i := range clauses {
+		for _, pb := range clauses[i] {
+			for _, name := range usedNames(pb) {
+				if j, ok := index[name]; ok && !slices.Contains(uses[i], j) {
+					uses[i] = append(uses[i], j)
+				}
+			}
+		}
+	}
+
+	var groups [][]*parser.PatBind
+	order := make([]int, len(clauses))
+	low := make([]int, len(clauses))
+	onStack := make([]bool, len(clauses))
+	var stack []int
+	counter := 0
+
+	var connect func(i int)
+	connect = func(i int) {
+		counter++
+		order[i], low[i] = counter, counter
+		stack = append(stack, i)
+		onStack[i] = true
+		for _, j := range uses[i] {
+			if order[j] == 0 {
+				connect(j)
+				low[i] = min(low[i], low[j])
+			} else if onStack[j] {
+				low[i] = min(low[i], order[j])
+			}
+		}
+		if low[i] != order[i] {
+			return
+		}
+		var members []int
+		for {
+			j := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[j] = false
+			members = append(members, j)
+			if j == i {
+				break
+			}
+		}
+		slices.Sort(members)
+		var group []*parser.PatBind
+		for _, j := range members {
+			group = append(group, clauses[j]...)
+		}
+		groups = append(groups, group)
+	}
+
+	for i := range clauses {
+		if order[i] == 0 {
+			connect(i)
+		}
+	}
+	return groups
+}
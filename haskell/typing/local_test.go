@@ -0,0 +1,102 @@
+package typing
+
+import (
+	prolog "goanna/prolog-tool"
+	"slices"
+	"testing"
+)
+
+// locals returns the local declarations of env, those nested in another.
+func locals(env *TypingEnv) []string {
+	var names []string
+	for name, ancestors := range env.DeclMap {
+		if len(ancestors) > 0 {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestLocalDeclarations(t *testing.T) {
+	cases := []string{
+		"f x = y\n  where y = x",
+		"f x = let y = x in y",
+	}
+
+	for _, code := range cases {
+		env := generate(code)
+		names := locals(env)
+		if len(names) != 1 {
+			t.Errorf("%q: expected one local declaration, got %v", code, names)
+			continue
+		}
+		y := names[0]
+		if !slices.Contains(env.Declarations, y) {
+			t.Errorf("%q: expected '%s' to be declared, got %v", code, y, env.Declarations)
+		}
+		captured := env.Captured(y)
+		if len(captured) != 1 {
+			t.Errorf("%q: expected '%s' to capture x, got %v", code, y, captured)
+			continue
+		}
+		// f calls y, and binds the params y captures to x
+		var zeta prolog.LTerm
+		for _, rule := range env.Rules {
+			if body, ok := rule.Body.(prolog.LStruct); ok && body.Functor == y && rule.Head.Name != y {
+				zeta = body.Args[3]
+			}
+		}
+		if zeta == nil {
+			t.Errorf("%q: expected a call of '%s'", code, y)
+			continue
+		}
+		capture := prolog.Once(prolog.LStruct{Functor: "append", Args: []prolog.LTerm{
+			prolog.LList{Elements: []prolog.LTerm{termVar(captured[0])}}, prolog.Wildcard, zeta,
+		}}).String()
+		if !slices.ContainsFunc(env.Rules, func(rule *Rule) bool { return rule.Body.String() == capture }) {
+			t.Errorf("%q: expected the call of '%s' to capture %s", code, y, captured[0])
+		}
+	}
+}
+
+func TestLocalBindings(t *testing.T) {
+	expectTyping(t, map[string]bool{
+		"f x = y\n  where y = if x then 1 else 2":   true,
+		"f x = y\n  where y = if x then 1 else 'c'": false,
+		"f = a\n  where (a, b) = (1, 'c')":          true,
+		"f = a\n  where (a, b) = [1]":               false,
+		"f = let (a, b) = (1, 'c') in a":            true,
+		"f = let (a, b) = 1 in a":                   false,
+		"f = let g 0 = 1\n        g n = n in g":     true,
+		"f = let g 0 = 1\n        g n = 'c' in g":   false,
+	})
+}
+
+func TestLetPolymorphism(t *testing.T) {
+	cases := map[string]bool{
+		"f = let id x = x in (id 1, id 'c')":               true,
+		"f = (\\id -> (id 1, id 'c')) (\\x -> x)":          false,
+		"f = (id 1, id 'c')\n  where id x = x":             true,
+		"f = let id x = x in (id 1, id 'c') :: (Int, Int)": false,
+	}
+	for code, ok := range cases {
+		if consult(generate(code)) != ok {
+			t.Errorf("%q: expected it to type check: %v, got %v", code, ok, !ok)
+		}
+	}
+}
+
+func TestMutualRecursion(t *testing.T) {
+	cases := map[string]bool{
+		"f = g 1\n  where g x = h x\n        h y = g y":                  true,
+		"f = (g 1, h 'c')\n  where g x = h x\n        h y = g y":         true,
+		"f = g 1\n  where g x = h x\n        h y = (g 1, g 'c')":         false,
+		"f = g 1\n  where g x = h 'c'\n        h y = if y then 1 else 2": false,
+	}
+	for code, ok := range cases {
+		if consult(generate(code)) != ok {
+			t.Errorf("%q: expected it to type check: %v, got %v", code, ok, !ok)
+		}
+	}
+}
@@ -46,6 +46,7 @@ type TypingEnv struct {
 	DeclMap      map[string][]string // canonical decl name → related names (mirrors meta decl maps)
 	Declarations []string
 	Collectors   map[string][]string // head name → list of class-var names (mirrors state.py collectors)
+	Arguments    map[string][]string // decl name → inherited params, bound to Zeta like functionTemplate2's arguments
 }
 
 func NewTypingEnv() *TypingEnv {
@@ -54,6 +55,7 @@ func NewTypingEnv() *TypingEnv {
 		DeclMap:      make(map[string][]string),
 		Declarations: make([]string, 0),
 		Collectors:   make(map[string][]string),
+		Arguments:    make(map[string][]string),
 	}
 }
 
@@ -63,6 +65,17 @@ func (te *TypingEnv) AddClassVar(headName string, classVar string) {
 	te.Collectors[headName] = append(te.Collectors[headName], classVar)
 }
 
+// Captured returns the params that the local declaration name captures from
+// the declarations it is nested in: those its innermost ancestor inherits.
+// DeclMap lists the ancestors outermost first.
+func (te *TypingEnv) Captured(name string) []string {
+	ancestors := te.DeclMap[name]
+	if len(ancestors) == 0 {
+		return nil
+	}
+	return te.Arguments[ancestors[len(ancestors)-1]]
+}
+
 // IsParentOf reports whether parent is an ancestor of child in the decl map
 // (where DeclMap[child] lists the child's ancestors/parents).
 func (te *TypingEnv) IsParentOf(parent string, child string) bool {
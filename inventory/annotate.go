@@ -0,0 +1,81 @@
+package inventory
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var standaloneHeader = `% Generated by goanna.
+% Every goal of a typing rule is followed by a comment with its rule ID,
+% its zero-based source range (from_line:from_col-to_line:to_col) and the
+% source it was generated from.
+%
+% Load with:  swipl <this file>
+% then query: ?- type_check.
+%             ?- main(G, L).
+:- style_check(-singleton).
+:- style_check(-discontiguous).
+`
+
+var standaloneFooter = `
+goanna_report :-
+		( type_check -> writeln('type_check: true') ; writeln('type_check: false') ),
+		( main(G, L) -> print(main(G, L)), nl ; writeln('main(G, L): false') ).
+
+:- initialization(goanna_report).
+`
+
+// maxSnippetLength keeps source snippets in comments on one short line.
+const maxSnippetLength = 40
+
+// RenderAnnotatedProlog renders the same program as RenderProlog, with each
+// goal of a typing rule annotated with where it came from in source. When
+// standalone is set, the program can be loaded into SWI-Prolog directly and
+// reports the results of type_check and main(G, L) when loaded.
+func (inv *Inventory) RenderAnnotatedProlog(source string, standalone bool) string {
+	program := NewSource(source)
+	annotate := func(rule Rule) string {
+		kind := "effective"
+		if slices.Contains(inv.AxiomaticRules, rule.Id) {
+			kind = "axiom"
+		}
+		loc, ok := inv.NodeRange[rule.Id]
+		if rule.Id == 0 || !ok {
+			return fmt.Sprintf("rule %d (%s)", rule.Id, kind)
+		}
+		return fmt.Sprintf("rule %d (%s) %d:%d-%d:%d %s",
+			rule.Id, kind, loc.FromLine, loc.FromCol, loc.ToLine, loc.ToCol, snippet(loc, program))
+	}
+
+	typingRules := inv.renderTypingRules(inv.EffectiveRules, inv.EffectiveRules, annotate)
+	classRules := inv.RenderClassRules()
+	typeCheckPredicate := terminateClause(inv.RenderTypeChecking())
+	mainPredicate := terminateClause(inv.RenderMain(inv.EffectiveRules))
+	terminateClauses(classRules)
+	terminateClauses(typingRules)
+	parts := []string{
+		preamble,
+		strings.Join(typingRules, "\n"),
+		strings.Join(classRules, "\n"),
+		typeCheckPredicate,
+		mainPredicate,
+	}
+	if standalone {
+		parts = slices.Concat([]string{standaloneHeader}, parts, []string{standaloneFooter})
+	}
+	return strings.Join(parts, "\n")
+}
+
+// snippet returns the source text covered by loc on a single line, quoted.
+func snippet(loc Range, source Source) string {
+	lines := strings.Split(source.Text(loc), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text := strings.Join(lines, " ")
+	if runes := []rune(text); len(runes) > maxSnippetLength {
+		text = string(runes[:maxSnippetLength-3]) + "..."
+	}
+	return fmt.Sprintf("%q", text)
+}
@@ -0,0 +1,82 @@
+package inventory
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const annotatedSource = "f = 1\ng = f\nh = [ 1\n    , 2 ]\n"
+
+// annotatedInventory types f, g and h of annotatedSource, generalised so that
+// every rule with a node is effective.
+func annotatedInventory() *Inventory {
+	rule := func(id int, decl string, body string, axiom bool) Rule {
+		return Rule{Id: id, Head: RuleHead{Name: decl, Module: "Main", Type: "type"}, Body: body, IsAxiom: axiom}
+	}
+	inv := NewInventory(Input{
+		Rules: []Rule{
+			rule(1, "f", "_1 = int", false),
+			rule(2, "f", "T = _1", true),
+			rule(3, "g", "f(_3, Calls_, _, _, _, _)", false),
+			rule(4, "h", "T = _4", false),
+		},
+		Declarations: []string{"f", "g", "h"},
+		NodeTable:    []NodePair{{Parent: 10, Child: 1}, {Parent: 10, Child: 3}, {Parent: 10, Child: 4}},
+		NodeDepth:    map[int]int{1: 1, 3: 1, 4: 1},
+		NodeRange: map[int]Range{
+			1: {FromLine: 0, FromCol: 4, ToLine: 0, ToCol: 5},
+			3: {FromLine: 1, FromCol: 4, ToLine: 1, ToCol: 5},
+			4: {FromLine: 2, FromCol: 4, ToLine: 3, ToCol: 9},
+		},
+		MaxLevel: 1,
+	})
+	inv.Generalize(1)
+	return inv
+}
+
+func TestRenderAnnotatedProlog(t *testing.T) {
+	inv := annotatedInventory()
+	program := inv.RenderAnnotatedProlog(annotatedSource, false)
+
+	assert.Contains(t, program, `_1 = int, % rule 1 (effective) 0:4-0:5 "1"`)
+	assert.Contains(t, program, `T = _1, % rule 2 (axiom)`)
+	assert.Contains(t, program, `f(_3, Calls_, _, _, _, _), % rule 3 (effective) 1:4-1:5 "f"`)
+	// Snippets spanning several lines are kept on one
+	assert.Contains(t, program, `T = _4, % rule 4 (effective) 2:4-3:9 "[ 1 , 2 ]"`)
+
+	// Without its comments, the program is the one RenderProlog renders
+	comments := regexp.MustCompile(` % [^\n]*`)
+	assert.Equal(t, inv.RenderProlog(), comments.ReplaceAllString(program, ""))
+	assert.False(t, strings.HasPrefix(program, standaloneHeader))
+}
+
+func TestRenderAnnotatedPrologStandalone(t *testing.T) {
+	program := annotatedInventory().RenderAnnotatedProlog(annotatedSource, true)
+	assert.True(t, strings.HasPrefix(program, standaloneHeader))
+	assert.True(t, strings.HasSuffix(program, standaloneFooter))
+}
+
+func TestSnippet(t *testing.T) {
+	source := NewSource("f = " + strings.Repeat("x", 50) + "\n")
+	text := snippet(Range{FromLine: 0, FromCol: 4, ToLine: 0, ToCol: 54}, source)
+	assert.Equal(t, `"`+strings.Repeat("x", maxSnippetLength-3)+`..."`, text)
+}
+
+func TestGeneralizeWritesNothing(t *testing.T) {
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	os.Stdout = w
+	annotatedInventory().Generalize(0)
+	os.Stdout = stdout
+	assert.NoError(t, w.Close())
+
+	output, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Empty(t, string(output), "Generalize should leave stdout to the output formats")
+}
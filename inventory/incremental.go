@@ -0,0 +1,159 @@
+package inventory
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// positional matches the parts of a rule body that depend on where a
+// declaration sits in the file: node variables (_12), fresh variables (_f3)
+// and the line/column suffixes of local canonical names (m0_go_3_4).
+var positional = regexp.MustCompile(`_f?[0-9]+`)
+
+// Update replaces the input of the inventory with a new translation of the
+// program, and returns the inventory as it was before. The Prolog interpreter
+// is kept, so consulted clauses are reused until they are consulted again.
+func (inv *Inventory) Update(input Input) *Inventory {
+	previous := *inv
+	*inv = *NewInventory(input)
+	inv.logic = previous.logic
+	return &previous
+}
+
+func normalizeRules(bodies []string) string {
+	seen := make(map[string]string)
+	rename := func(tok string) string {
+		if name, ok := seen[tok]; ok {
+			return name
+		}
+		name := "_" + strconv.Itoa(len(seen))
+		seen[tok] = name
+		return name
+	}
+	normalized := make([]string, len(bodies))
+	for i, body := range bodies {
+		normalized[i] = positional.ReplaceAllStringFunc(body, rename)
+	}
+	return strings.Join(normalized, "\n")
+}
+
+// Fingerprints returns, for every declaration and every class with instance
+// rules, a string that only changes when the typing rules of that name change.
+// Node IDs and source positions are normalised away, so moving a declaration
+// around the file keeps its fingerprint.
+func (inv *Inventory) Fingerprints() map[string]string {
+	result := make(map[string]string)
+	for _, decl := range inv.Declarations {
+		bodies := make([]string, 0)
+		for _, rule := range inv.TypingRules[decl] {
+			bodies = append(bodies, rule.Body)
+		}
+		bodies = append(bodies, strings.Join(inv.Arguments[decl], ","))
+		typeVars := make([]string, 0)
+		for varName, classes := range inv.TypeVars[decl] {
+			typeVars = append(typeVars, varName+":"+strings.Join(classes, ","))
+		}
+		slices.Sort(typeVars)
+		bodies = append(bodies, strings.Join(typeVars, ","))
+		result[decl] = normalizeRules(bodies)
+	}
+	for className, instances := range inv.InstanceRules {
+		bodies := make([]string, 0)
+		for _, rules := range instances {
+			bodies = append(bodies, normalizeRules(rules))
+		}
+		slices.Sort(bodies)
+		bodies = append(bodies, strings.Join(inv.Classes[className], ","))
+		result[className] = strings.Join(bodies, "\n")
+	}
+	return result
+}
+
+// RulesOf returns the IDs of the typing rules of decl in the order the
+// translator produced them. Rules without a node are left out.
+func (inv *Inventory) RulesOf(decl string) []int {
+	ids := make([]int, 0)
+	for _, rule := range inv.TypingRules[decl] {
+		if rule.Id != 0 {
+			ids = append(ids, rule.Id)
+		}
+	}
+	return ids
+}
+
+// ruleDeclarations maps the ID of every typing rule to its declaration.
+func (inv *Inventory) ruleDeclarations() map[int]string {
+	result := make(map[int]string)
+	for _, rule := range inv.Rules {
+		if rule.Head.Type == "type" {
+			result[rule.Id] = rule.Head.Name
+		}
+	}
+	return result
+}
+
+// Dependants returns names together with every declaration that is nested in,
+// encloses, or refers to one of them, transitively. Nesting follows Closures,
+// which has the same shape as meta.GetDeclGraph.
+func (inv *Inventory) Dependants(names []string) []string {
+	references := make(map[string]*regexp.Regexp)
+	for _, decl := range inv.Declarations {
+		references[decl] = regexp.MustCompile(`\b` + regexp.QuoteMeta(decl) + `\(`)
+	}
+	result := slices.Clone(names)
+	for changed := true; changed; {
+		changed = false
+		for _, decl := range inv.Declarations {
+			if slices.Contains(result, decl) {
+				continue
+			}
+			if inv.isDependant(decl, result, references) {
+				result = append(result, decl)
+				changed = true
+			}
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+func (inv *Inventory) isDependant(decl string, names []string, references map[string]*regexp.Regexp) bool {
+	for _, name := range names {
+		if slices.Contains(inv.Closures[decl], name) || slices.Contains(inv.Closures[name], decl) {
+			return true
+		}
+		reference, ok := references[name]
+		if !ok {
+			continue
+		}
+		for _, rule := range inv.TypingRules[decl] {
+			if reference.MatchString(rule.Body) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Focus restricts the effective rules to those of decls. Effective rules of
+// other declarations become axioms, except the ones in dropped, which are left
+// out of the program altogether. Focus must be called after Generalize, and a
+// later call to Generalize undoes it.
+func (inv *Inventory) Focus(decls []string, dropped []int) {
+	effectiveRules := make([]int, 0)
+	axiomRules := slices.Clone(inv.AxiomaticRules)
+	ruleDecls := inv.ruleDeclarations()
+	for _, ruleId := range inv.EffectiveRules {
+		switch {
+		case slices.Contains(decls, ruleDecls[ruleId]):
+			effectiveRules = append(effectiveRules, ruleId)
+		case !slices.Contains(dropped, ruleId):
+			axiomRules = append(axiomRules, ruleId)
+		}
+	}
+	inv.AxiomaticRules = axiomRules
+	inv.EffectiveRules = effectiveRules
+	inv.changedNames = inv.findDeclarationsByRules(effectiveRules)
+}
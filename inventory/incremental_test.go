@@ -0,0 +1,97 @@
+package inventory
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// incrementalInput is a program of four declarations whose rule IDs start
+// after offset: g refers to f, and k is nested in h.
+func incrementalInput(offset int, fBody string) Input {
+	rule := func(id int, decl string, body string) Rule {
+		return Rule{Id: offset + id, Head: RuleHead{Name: decl, Module: "Main", Type: "type"}, Body: body}
+	}
+	node := func(id int) string {
+		return fmt.Sprintf("_%d", offset+id)
+	}
+	rules := []Rule{
+		rule(1, "f", node(1)+" = "+fBody),
+		rule(2, "f", "T = "+node(1)),
+		rule(3, "g", "f("+node(3)+", Calls_, _, _, _, _)"),
+		rule(4, "g", "T = "+node(3)),
+		rule(5, "h", "T = char"),
+		rule(6, "k", "T = "+node(6)),
+	}
+	nodeTable := make([]NodePair, 0)
+	nodeDepth := make(map[int]int)
+	for _, r := range rules {
+		nodeTable = append(nodeTable, NodePair{Parent: offset + 100, Child: r.Id})
+		nodeDepth[r.Id] = 1
+	}
+	return Input{
+		Rules:        rules,
+		Declarations: []string{"f", "g", "h", "k"},
+		Closures:     map[string][]string{"k": {"h"}},
+		NodeTable:    nodeTable,
+		NodeDepth:    nodeDepth,
+		MaxLevel:     1,
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	inv := NewInventory(incrementalInput(0, "int"))
+	logic := inv.logic
+	previous := inv.Update(incrementalInput(10, "int"))
+
+	// The previous inventory still describes the program before the edit
+	assert.NotSame(t, inv, previous)
+	assert.Equal(t, []int{1, 2}, previous.RulesOf("f"))
+	assert.Equal(t, []int{11, 12}, inv.RulesOf("f"))
+	assert.Same(t, logic, inv.logic, "the interpreter should be kept")
+}
+
+func TestFingerprints(t *testing.T) {
+	before := NewInventory(incrementalInput(0, "int")).Fingerprints()
+	moved := NewInventory(incrementalInput(10, "int")).Fingerprints()
+	edited := NewInventory(incrementalInput(0, "char")).Fingerprints()
+
+	assert.Equal(t, before, moved, "moving declarations should keep their fingerprints")
+	for _, decl := range []string{"g", "h", "k"} {
+		assert.Equal(t, before[decl], edited[decl], decl)
+	}
+	assert.NotEqual(t, before["f"], edited["f"])
+}
+
+func TestDependants(t *testing.T) {
+	inv := NewInventory(incrementalInput(0, "int"))
+	testCases := []struct {
+		changed []string
+		expect  []string
+	}{
+		{[]string{"f"}, []string{"f", "g"}},
+		{[]string{"g"}, []string{"g"}},
+		{[]string{"h"}, []string{"h", "k"}},
+		{[]string{"k"}, []string{"h", "k"}},
+		{[]string{}, []string{}},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expect, inv.Dependants(tc.changed), "dependants of %v", tc.changed)
+	}
+}
+
+func TestFocus(t *testing.T) {
+	inv := NewInventory(incrementalInput(0, "int"))
+	inv.Generalize(1)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, inv.EffectiveRules)
+
+	inv.Focus([]string{"f"}, []int{4})
+	assert.Equal(t, []int{1, 2}, inv.EffectiveRules)
+	assert.ElementsMatch(t, []int{3, 5, 6}, inv.AxiomaticRules, "rule 4 should be dropped")
+	assert.Equal(t, []string{"f"}, inv.changedNames)
+
+	inv.Generalize(1)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, inv.EffectiveRules)
+	assert.Empty(t, inv.AxiomaticRules)
+}
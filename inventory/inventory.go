@@ -1,9 +1,9 @@
 package inventory
 
 import (
-	"fmt"
 	mapset "github.com/deckarep/golang-set/v2"
 	"goanna/prolog-tool"
+	"maps"
 	"slices"
 	"strings"
 )
@@ -48,6 +48,16 @@ type Identifier struct {
 	IsTerm    bool   `json:"is_term"`
 }
 
+// Hole is a typed hole (`_` or `_name`). The rule with ContextId binds a list
+// of the types of the local bindings named in Locals.
+type Hole struct {
+	NodeId      int      `json:"node_id"`
+	ContextId   int      `json:"context_id"`
+	Name        string   `json:"name"`
+	Declaration string   `json:"decl"`
+	Locals      []string `json:"locals"`
+}
+
 type Input struct {
 	BaseModules   []string                       `json:"base_modules"`
 	ParsingErrors []Range                        `json:"parsing_errors"`
@@ -67,6 +77,9 @@ type Input struct {
 	TopLevels     []string                       `json:"top_levels"`
 	Collectors    map[string][]string            `json:"collectors"`
 	// Collectors may not be necessary here. It is always {Var1: ["_Classes"], ...}
+	Closures      map[string][]string            `json:"closures"`
+	// Closures maps each declaration to its enclosing declarations, like meta.GetDeclGraph
+	Holes         []Hole                         `json:"holes"`
 }
 
 type Inventory struct {
@@ -138,7 +151,6 @@ func NewInventory(input Input) *Inventory {
 }
 
 func (inv *Inventory) Generalize(currentLevel int) {
-	fmt.Printf("Current Level: %d\n", currentLevel)
 	parents := mapset.NewSet[int]()
 	nodes := mapset.NewSet[int]()
 	for _, pair := range inv.NodeTable {
@@ -238,13 +250,16 @@ func (inv *Inventory) RenderMain(captures []int) string {
 
 func (inv *Inventory) RenderClassRules() []string {
 	classRules := make([]string, 0)
-	for className, superClasses := range inv.Classes {
+	for _, className := range slices.Sorted(maps.Keys(inv.Classes)) {
+		superClasses := inv.Classes[className]
 		rule1 := TemplateToString(classRuleTemplate, struct {
 			Name         string
 			SuperClasses []string
 		}{className, superClasses})
 		classRules = append(classRules, rule1)
-		for _, rules := range inv.InstanceRules[className] {
+		instances := inv.InstanceRules[className]
+		for _, id := range slices.Sorted(maps.Keys(instances)) {
+			rules := instances[id]
 			r := TemplateToString(instanceRuleTemp, struct {
 				Name         string
 				SuperClasses []string
@@ -259,6 +274,13 @@ func (inv *Inventory) RenderClassRules() []string {
 }
 
 func (inv *Inventory) RenderTypingRules(rules, captures []int) []string {
+	return inv.renderTypingRules(rules, captures, nil)
+}
+
+// renderTypingRules renders the typing rules of every declaration. When
+// annotate is not nil, each goal of a rule body is followed by a comment
+// produced by annotate.
+func (inv *Inventory) renderTypingRules(rules, captures []int, annotate func(Rule) string) []string {
 	var result []string
 	for _, name := range inv.Declarations {
 		ownTypingRule := inv.TypingRules[name]
@@ -269,9 +291,14 @@ func (inv *Inventory) RenderTypingRules(rules, captures []int) []string {
 		for varName := range inv.TypeVars[name] {
 			owenTypeVars = append(owenTypeVars, varName)
 		}
+		slices.Sort(owenTypeVars)
+		comments := make([]string, 0)
 		for _, rule := range ownTypingRule {
 			if slices.Contains(rules, rule.Id) || slices.Contains(inv.AxiomaticRules, rule.Id) {
 				ownTypingRuleBody = append(ownTypingRuleBody, rule.Body)
+				if annotate != nil {
+					comments = append(comments, annotate(rule))
+				}
 			}
 			if slices.Contains(captures, rule.Id) {
 				capturedNodes = append(capturedNodes, rule.Id)
@@ -287,12 +314,14 @@ func (inv *Inventory) RenderTypingRules(rules, captures []int) []string {
 				RuleBody      []string
 				TypeVars      []string
 				CollectorVars []string
+				Comments      []string
 			}{name,
 				ownArguments,
 				capturedNodes,
 				ownTypingRuleBody,
 				owenTypeVars,
-				inv.Collectors[name]}))
+				inv.Collectors[name],
+				comments}))
 	}
 	return result
 }
@@ -307,6 +336,7 @@ func (inv *Inventory) renderChangedTypingRules(names []string, rules []int) []st
 		for varName := range inv.TypeVars[name] {
 			owenTypeVars = append(owenTypeVars, varName)
 		}
+		slices.Sort(owenTypeVars)
 		for _, rule := range ownTypingRule {
 			if slices.Contains(rules, rule.Id) || slices.Contains(inv.AxiomaticRules, rule.Id) {
 				ownTypingRuleBody = append(ownTypingRuleBody, rule.Body)
@@ -322,28 +352,32 @@ func (inv *Inventory) renderChangedTypingRules(names []string, rules []int) []st
 				RuleBody      []string
 				TypeVars      []string
 				CollectorVars []string
+				Comments      []string
 			}{name,
 				ownArguments,
 				[]int{},
 				ownTypingRuleBody,
 				owenTypeVars,
 				inv.Collectors[name],
+				nil,
 			}))
 	}
 	return result
 }
 
+// findDeclarationsByRules returns the declarations that own any of rules, in
+// the order of their first rule.
 func (inv *Inventory) findDeclarationsByRules(rules []int) []string {
-	var decls = mapset.NewSet[string]()
+	decls := make([]string, 0)
 	for _, rule := range inv.Rules {
 		if rule.Head.Type == "instance" {
 			continue
 		}
-		if slices.Contains(rules, rule.Id) {
-			decls.Add(rule.Head.Name)
+		if slices.Contains(rules, rule.Id) && !slices.Contains(decls, rule.Head.Name) {
+			decls = append(decls, rule.Head.Name)
 		}
 	}
-	return decls.ToSlice()
+	return decls
 }
 
 func (inv *Inventory) RenderProlog() string {
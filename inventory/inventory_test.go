@@ -0,0 +1,38 @@
+package inventory
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// instanceInventory is annotatedInventory with several instances of two
+// classes, so that rendering has maps of classes and instances to walk.
+func instanceInventory() *Inventory {
+	inv := annotatedInventory()
+	input := inv.Input
+	input.Classes = map[string][]string{"p_Eq": {}, "p_Ord": {"p_Eq"}}
+	for i, t := range []string{"int", "char", "float", "bool", "pair(list, _)"} {
+		for _, class := range []string{"p_Eq", "p_Ord"} {
+			input.Rules = append(input.Rules, Rule{
+				Head: RuleHead{Id: i + 1, Name: class, Module: "Main", Type: "instance"},
+				Body: fmt.Sprintf("T = has(_, %s)", t),
+			})
+		}
+	}
+	inv = NewInventory(input)
+	inv.Generalize(1)
+	return inv
+}
+
+func TestRenderIsStable(t *testing.T) {
+	program := instanceInventory().RenderProlog()
+	annotated := instanceInventory().RenderAnnotatedProlog(annotatedSource, true)
+	assert.Contains(t, program, "T = has(_, pair(list, _))")
+	for range 20 {
+		inv := instanceInventory()
+		assert.Equal(t, program, inv.RenderProlog())
+		assert.Equal(t, annotated, inv.RenderAnnotatedProlog(annotatedSource, true))
+	}
+}
@@ -0,0 +1,181 @@
+package inventory
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ColumnUnit says what a column counts. Ranges from the translator, like
+// parser.Loc, count bytes of UTF-8 because tree-sitter does; LSP counts UTF-16
+// code units; people count characters.
+type ColumnUnit int
+
+const (
+	ByteColumns ColumnUnit = iota
+	UTF16Columns
+	CodepointColumns
+)
+
+// TabWidth is the distance between tab stops, as in the Haskell report.
+const TabWidth = 8
+
+// Source is a program split into lines, for turning columns of one unit into
+// another and for slicing ranges out of it safely.
+type Source struct {
+	lines []string
+}
+
+func NewSource(text string) Source {
+	return Source{lines: strings.Split(text, "\n")}
+}
+
+// Line returns line n without its line terminator, or "" if there is no such line.
+func (s Source) Line(n int) string {
+	if n < 0 || n >= len(s.lines) {
+		return ""
+	}
+	return strings.TrimSuffix(s.lines[n], "\r")
+}
+
+// ByteColumn clamps a byte column of line n to the line and moves it back to
+// the start of the character it falls in.
+func (s Source) ByteColumn(n int, col int) int {
+	line := s.Line(n)
+	col = max(0, min(col, len(line)))
+	for col > 0 && col < len(line) && !utf8.RuneStart(line[col]) {
+		col--
+	}
+	return col
+}
+
+// Slice returns the text between two byte columns of line n. It never panics
+// and never splits a character.
+func (s Source) Slice(n int, from, to int) string {
+	from = s.ByteColumn(n, from)
+	to = max(from, s.ByteColumn(n, to))
+	return s.Line(n)[from:to]
+}
+
+// Text returns the source covered by r, with its lines joined by newlines.
+func (s Source) Text(r Range) string {
+	if r.FromLine == r.ToLine {
+		return s.Slice(r.FromLine, r.FromCol, r.ToCol)
+	}
+	parts := make([]string, 0)
+	for n := r.FromLine; n <= r.ToLine; n++ {
+		switch n {
+		case r.FromLine:
+			parts = append(parts, s.Slice(n, r.FromCol, len(s.Line(n))))
+		case r.ToLine:
+			parts = append(parts, s.Slice(n, 0, r.ToCol))
+		default:
+			parts = append(parts, s.Line(n))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// FromBytes converts a byte column of line n to the given unit.
+func (s Source) FromBytes(n int, col int, unit ColumnUnit) int {
+	prefix := s.Line(n)[:s.ByteColumn(n, col)]
+	switch unit {
+	case UTF16Columns:
+		units := 0
+		for _, r := range prefix {
+			units += utf16Length(r)
+		}
+		return units
+	case CodepointColumns:
+		return utf8.RuneCountInString(prefix)
+	default:
+		return len(prefix)
+	}
+}
+
+// ToBytes converts a column of line n in the given unit to a byte column.
+// Columns past the end of the line are clamped to it.
+func (s Source) ToBytes(n int, col int, unit ColumnUnit) int {
+	line := s.Line(n)
+	if unit == ByteColumns {
+		return s.ByteColumn(n, col)
+	}
+	count := 0
+	for i, r := range line {
+		if count >= col {
+			return i
+		}
+		if unit == UTF16Columns {
+			count += utf16Length(r)
+		} else {
+			count++
+		}
+	}
+	return len(line)
+}
+
+// FromBytesRange converts both ends of r to the given unit.
+func (s Source) FromBytesRange(r Range, unit ColumnUnit) Range {
+	return Range{
+		FromLine: r.FromLine,
+		ToLine:   r.ToLine,
+		FromCol:  s.FromBytes(r.FromLine, r.FromCol, unit),
+		ToCol:    s.FromBytes(r.ToLine, r.ToCol, unit),
+	}
+}
+
+// DisplayColumn is where a byte column of line n appears on a terminal, with
+// every character one cell wide and tabs expanded to the next tab stop.
+func (s Source) DisplayColumn(n int, col int) int {
+	display := 0
+	for _, r := range s.Line(n)[:s.ByteColumn(n, col)] {
+		if r == '\t' {
+			display += TabWidth - display%TabWidth
+		} else {
+			display++
+		}
+	}
+	return display
+}
+
+// DisplayLine is line n as it appears on a terminal, with tabs expanded so
+// that it lines up with DisplayColumn.
+func (s Source) DisplayLine(n int) string {
+	var sb strings.Builder
+	display := 0
+	for _, r := range s.Line(n) {
+		if r == '\t' {
+			width := TabWidth - display%TabWidth
+			sb.WriteString(strings.Repeat(" ", width))
+			display += width
+		} else {
+			sb.WriteRune(r)
+			display++
+		}
+	}
+	return sb.String()
+}
+
+func utf16Length(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// Offset is the position in the whole text of a byte column of line n, clamped
+// like ByteColumn. Lines past the end are at the end of the text.
+func (s Source) Offset(n int, col int) int {
+	if n >= len(s.lines) {
+		return len(s.String())
+	}
+	offset := 0
+	for _, line := range s.lines[:max(0, n)] {
+		offset += len(line) + 1
+	}
+	return offset + s.ByteColumn(n, col)
+}
+
+// String returns the text the source was made from.
+func (s Source) String() string {
+	return strings.Join(s.lines, "\n")
+}
@@ -0,0 +1,90 @@
+package inventory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// "λx → x" is 2+1+1+3+1+1 bytes and 6 code points or UTF-16 units.
+// "𝑓" is 4 bytes, 1 code point and 2 UTF-16 units.
+const multiByteSource = "f = \\λx → x\n\tg = \"𝑓\" ++ \"é\"\n-- ü"
+
+func TestColumnConversion(t *testing.T) {
+	source := NewSource(multiByteSource)
+	testCases := []struct {
+		name      string
+		line      int
+		byteCol   int
+		utf16     int
+		codepoint int
+	}{
+		{"start of line", 0, 0, 0, 0},
+		{"before lambda", 0, 5, 5, 5},
+		{"after lambda", 0, 7, 6, 6},
+		{"after arrow", 0, 12, 9, 9},
+		{"end of line", 0, 14, 11, 11},
+		{"after tab", 1, 1, 1, 1},
+		{"after astral character", 1, 10, 8, 7},
+		{"after e acute", 1, 19, 16, 15},
+		{"comment", 2, 5, 4, 4},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.utf16, source.FromBytes(tc.line, tc.byteCol, UTF16Columns))
+			assert.Equal(t, tc.codepoint, source.FromBytes(tc.line, tc.byteCol, CodepointColumns))
+			assert.Equal(t, tc.byteCol, source.FromBytes(tc.line, tc.byteCol, ByteColumns))
+			assert.Equal(t, tc.byteCol, source.ToBytes(tc.line, tc.utf16, UTF16Columns))
+			assert.Equal(t, tc.byteCol, source.ToBytes(tc.line, tc.codepoint, CodepointColumns))
+		})
+	}
+}
+
+func TestByteColumnClamps(t *testing.T) {
+	source := NewSource(multiByteSource)
+	// In the middle of λ
+	assert.Equal(t, 5, source.ByteColumn(0, 6))
+	// Past the end of the line
+	assert.Equal(t, 14, source.ByteColumn(0, 100))
+	assert.Equal(t, 0, source.ByteColumn(0, -1))
+	// No such line
+	assert.Equal(t, 0, source.ByteColumn(10, 3))
+	assert.Equal(t, 14, source.ToBytes(0, 100, UTF16Columns))
+}
+
+func TestSlice(t *testing.T) {
+	source := NewSource(multiByteSource)
+	assert.Equal(t, "λx", source.Slice(0, 5, 8))
+	// Never splits a character
+	assert.Equal(t, "λ", source.Slice(0, 6, 7))
+	assert.Equal(t, "x", source.Slice(0, 13, 100))
+	assert.Equal(t, "", source.Slice(0, 20, 30))
+	assert.Equal(t, "", source.Slice(5, 0, 3))
+	assert.Equal(t, "x\n\tg", source.Text(Range{FromLine: 0, ToLine: 1, FromCol: 13, ToCol: 2}))
+}
+
+func TestDisplayColumn(t *testing.T) {
+	source := NewSource(multiByteSource)
+	assert.Equal(t, 6, source.DisplayColumn(0, 7))
+	// The tab reaches the first tab stop
+	assert.Equal(t, 8, source.DisplayColumn(1, 1))
+	assert.Equal(t, 14, source.DisplayColumn(1, 10))
+	assert.Equal(t, "        g = \"𝑓\" ++ \"é\"", source.DisplayLine(1))
+}
+
+func TestLineTerminators(t *testing.T) {
+	source := NewSource("a = 1\r\nb = 2\r\n")
+	assert.Equal(t, "a = 1", source.Line(0))
+	assert.Equal(t, 5, source.ByteColumn(0, 6))
+	assert.Equal(t, "", source.Line(2))
+}
+
+func TestOffset(t *testing.T) {
+	source := NewSource(multiByteSource)
+	assert.Equal(t, 7, source.Offset(0, 7))
+	assert.Equal(t, 16, source.Offset(1, 1))
+	// Clamped to the line
+	assert.Equal(t, 14, source.Offset(0, 100))
+	assert.Equal(t, len(multiByteSource), source.Offset(5, 0))
+	assert.Equal(t, multiByteSource, source.String())
+}
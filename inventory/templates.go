@@ -68,8 +68,8 @@ var functionTemplate2 = NewTemplate("fun2", `
 		{{- if ne (len .TypeVars) 0 -}}
 	Theta = [{{ joinStr .TypeVars (printf "_%s_" .Name) "," }}],
 		{{ end -}}
-		{{ range .RuleBody   -}}
-		{{ . }},
+		{{ range $i, $body := .RuleBody -}}
+		{{ $body }},{{ if $.Comments }} % {{ index $.Comments $i }}{{ end }}
 		{{ end -}}
 	once(appendAll([{{ joinStr .CollectorVars "" ","}}], Classes))`)
 
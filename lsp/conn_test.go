@@ -0,0 +1,80 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// frame is body as it goes over the wire.
+func frame(body string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func TestConnRead(t *testing.T) {
+	first := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+	second := `{"jsonrpc":"2.0","method":"initialized","params":{"text":"é"}}`
+	stream := frame(first) + "Content-Type: application/vscode-jsonrpc; charset=utf-8\r\n" + frame(second)
+
+	// A pipe may hand over a message a byte at a time
+	c := newConn(iotest.OneByteReader(strings.NewReader(stream)), io.Discard)
+	msg, err := c.read()
+	assert.NoError(t, err)
+	assert.Equal(t, "initialize", msg.Method)
+	assert.Equal(t, json.RawMessage("1"), msg.Id)
+
+	msg, err = c.read()
+	assert.NoError(t, err)
+	assert.Equal(t, "initialized", msg.Method)
+	assert.Nil(t, msg.Id)
+	assert.JSONEq(t, `{"text":"é"}`, string(msg.Params))
+
+	_, err = c.read()
+	assert.True(t, errors.Is(err, io.EOF))
+}
+
+func TestConnReadPartial(t *testing.T) {
+	body := `{"jsonrpc":"2.0","method":"exit"}`
+	stream := frame(body)
+	c := newConn(strings.NewReader(stream[:len(stream)-5]), io.Discard)
+	_, err := c.read()
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+func TestConnReadBadHeader(t *testing.T) {
+	testCases := []string{
+		"Content-Length: ten\r\n\r\n{}",
+		"Content-Type: application/vscode-jsonrpc\r\n\r\n{}",
+		"Content-Length 2\r\n\r\n{}",
+		"Content-Length: 2\r\n\r\n{]",
+	}
+	for _, stream := range testCases {
+		c := newConn(strings.NewReader(stream), io.Discard)
+		_, err := c.read()
+		assert.Error(t, err, "%q", stream)
+	}
+}
+
+func TestConnWrite(t *testing.T) {
+	var out bytes.Buffer
+	c := newConn(strings.NewReader(""), &out)
+	assert.NoError(t, c.reply(json.RawMessage("7"), nil))
+	assert.NoError(t, c.notify("window/showMessage", ShowMessageParams{Type: 1, Message: "é"}))
+
+	reply := `{"jsonrpc":"2.0","id":7,"result":null}`
+	notification := `{"jsonrpc":"2.0","method":"window/showMessage","params":{"type":1,"message":"é"}}`
+	assert.Equal(t, frame(reply)+frame(notification), out.String())
+
+	// What write frames, read reads back
+	c = newConn(&out, io.Discard)
+	msg, err := c.read()
+	assert.NoError(t, err)
+	assert.Equal(t, json.RawMessage("7"), msg.Id)
+}
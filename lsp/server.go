@@ -0,0 +1,404 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goanna/haskell"
+	"goanna/inventory"
+	"goanna/translator"
+	"io"
+	"log"
+	"slices"
+	"strings"
+)
+
+const highlightFixCommand = "goanna.highlightFix"
+
+// document is an open editor buffer. Every buffer has its own session, so
+// that an edit only re-checks the declarations it touched.
+type document struct {
+	uri     string
+	text    string
+	source  inventory.Source
+	session haskell.Session
+	report  haskell.Report
+	// Diagnostics that are not type errors, such as parse errors
+	problems []Diagnostic
+	// The fix whose MCS is highlighted, as [error, fix], if any
+	highlight []int
+	// Whether the report has suggestions yet. They take a re-check per
+	// candidate, so they are only looked for when code actions are asked for.
+	suggested bool
+}
+
+type Server struct {
+	conn      *conn
+	documents map[string]*document
+	shutdown  bool
+}
+
+// Serve runs a language server on r and w until the client asks it to exit.
+func Serve(r io.Reader, w io.Writer) error {
+	s := &Server{
+		conn:      newConn(r, w),
+		documents: make(map[string]*document),
+	}
+	for {
+		msg, err := s.conn.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit before shutdown")
+			}
+			return nil
+		}
+		s.handle(msg)
+	}
+}
+
+func (s *Server) handle(msg message) {
+	defer func() {
+		// The pipeline panics on inconsistent states; keep serving other requests
+		if r := recover(); r != nil {
+			log.Printf("%s: %v", msg.Method, r)
+			if msg.Id != nil {
+				s.must(s.conn.replyError(msg.Id, codeInternalError, fmt.Sprint(r)))
+			}
+		}
+	}()
+	var result any
+	var err error
+	switch msg.Method {
+	case "initialize":
+		result = s.initialize()
+	case "initialized":
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			doc := &document{uri: params.TextDocument.URI}
+			s.documents[doc.uri] = doc
+			s.check(doc, params.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			doc, ok := s.documents[params.TextDocument.URI]
+			if ok && len(params.ContentChanges) != 0 {
+				// Only full document sync is offered
+				s.check(doc, params.ContentChanges[len(params.ContentChanges)-1].Text)
+			}
+		}
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			delete(s.documents, params.TextDocument.URI)
+			s.must(s.conn.notify("textDocument/publishDiagnostics",
+				PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}}))
+		}
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			result = s.hover(params)
+		}
+	case "textDocument/codeAction":
+		var params CodeActionParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			result = s.codeActions(params)
+		}
+	case "workspace/executeCommand":
+		var params ExecuteCommandParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			err = s.executeCommand(params)
+		}
+	default:
+		if msg.Id != nil {
+			s.must(s.conn.replyError(msg.Id, codeMethodNotFound, "method not supported: "+msg.Method))
+		}
+		return
+	}
+	if msg.Id == nil {
+		if err != nil {
+			log.Printf("%s: %v", msg.Method, err)
+		}
+		return
+	}
+	if err != nil {
+		s.must(s.conn.replyError(msg.Id, codeInvalidParams, err.Error()))
+		return
+	}
+	s.must(s.conn.reply(msg.Id, result))
+}
+
+func (s *Server) must(err error) {
+	if err != nil {
+		log.Printf("Error writing to client: %v", err)
+	}
+}
+
+func (s *Server) initialize() any {
+	if !translator.Ready() {
+		s.must(s.conn.notify("window/showMessage", ShowMessageParams{
+			Type:    1,
+			Message: "goanna: translator is not running at " + translator.Address,
+		}))
+	}
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync": map[string]any{
+				"openClose": true,
+				"change":    1,
+			},
+			"hoverProvider":      true,
+			"codeActionProvider": true,
+			"executeCommandProvider": map[string]any{
+				"commands": []string{highlightFixCommand},
+			},
+		},
+		"serverInfo": map[string]any{"name": "goanna"},
+	}
+}
+
+// check re-checks doc with its new text and publishes the diagnostics.
+func (s *Server) check(doc *document, text string) {
+	doc.text = text
+	doc.source = inventory.NewSource(text)
+	doc.report = haskell.Report{}
+	doc.problems = []Diagnostic{}
+	doc.highlight = nil
+	doc.suggested = false
+	defer s.publish(doc)
+
+	input, err := translator.Translate(text)
+	if err != nil {
+		doc.session.Reset()
+		doc.problems = append(doc.problems, Diagnostic{
+			Severity: SeverityError,
+			Source:   "goanna",
+			Message:  "Could not reach the translator: " + err.Error(),
+		})
+		return
+	}
+	if len(input.ParsingErrors) != 0 || len(input.ImportErrors) != 0 {
+		doc.session.Reset()
+		for _, r := range input.ParsingErrors {
+			doc.problems = append(doc.problems, Diagnostic{
+				Range:    doc.toRange(r),
+				Severity: SeverityError,
+				Source:   "goanna",
+				Message:  "Parse error",
+			})
+		}
+		for _, id := range input.ImportErrors {
+			doc.problems = append(doc.problems, Diagnostic{
+				Range:    doc.toRange(id.NodeRange),
+				Severity: SeverityError,
+				Source:   "goanna",
+				Message:  fmt.Sprintf("Not in scope: %s", id.Name),
+			})
+		}
+		return
+	}
+
+	rechecked, reused := doc.session.Edit(input)
+	log.Printf("%s: re-checked %v, reused %d errors", doc.uri, rechecked, reused)
+	if !doc.session.Localisation.Localised {
+		doc.problems = append(doc.problems, Diagnostic{
+			Severity: SeverityError,
+			Source:   "goanna",
+			Message:  "Type error that could not be localised at any generalisation level",
+		})
+		return
+	}
+	if len(doc.session.Localisation.Errors) != 0 {
+		doc.report = haskell.MakeReport(doc.session.Localisation.Errors, *doc.session.Inv, text)
+	}
+}
+
+func (s *Server) publish(doc *document) {
+	diagnostics := slices.Clone(doc.problems)
+	for _, typeError := range doc.report.TypeErrors {
+		diagnostics = append(diagnostics, typeErrorDiagnostic(doc, typeError))
+	}
+	if doc.highlight != nil {
+		fix := doc.report.TypeErrors[doc.highlight[0]].Fixes[doc.highlight[1]]
+		for _, node := range fix.MCS {
+			diagnostics = append(diagnostics, Diagnostic{
+				Range:    doc.toRange(doc.report.NodeRange[node]),
+				Severity: SeverityHint,
+				Source:   "goanna",
+				Message: fmt.Sprintf("Fix %d changes `%s`. %s",
+					doc.highlight[1]+1, doc.sourceText(doc.report.NodeRange[node]), fix.Explanation),
+			})
+		}
+	}
+	s.must(s.conn.notify("textDocument/publishDiagnostics",
+		PublishDiagnosticsParams{URI: doc.uri, Diagnostics: diagnostics}))
+}
+
+func typeErrorDiagnostic(doc *document, typeError haskell.TypeError) Diagnostic {
+	nodes := typeError.OrderedCriticalNodes()
+	names := make([]string, len(nodes))
+	related := make([]DiagnosticRelatedInformation, len(nodes))
+	for i, node := range nodes {
+		detail := typeError.CriticalNodes[node]
+		names[i] = "`" + detail.DisplayName + "`"
+		types := make([]string, len(typeError.Fixes))
+		for j, fix := range typeError.Fixes {
+			types[j] = fmt.Sprintf("%s (fix %d)", fix.LocalType[node], j+1)
+		}
+		related[i] = DiagnosticRelatedInformation{
+			Location: Location{URI: doc.uri, Range: doc.toRange(detail.Range)},
+			Message:  fmt.Sprintf("`%s` :: %s", detail.DisplayName, strings.Join(types, ", ")),
+		}
+	}
+	var first Range
+	if len(nodes) != 0 {
+		first = doc.toRange(typeError.CriticalNodes[nodes[0]].Range)
+	}
+	return Diagnostic{
+		Range:    first,
+		Severity: SeverityError,
+		Source:   "goanna",
+		Message: fmt.Sprintf("Type error involving %s; %d possible fixes",
+			strings.Join(names, ", "), len(typeError.Fixes)),
+		RelatedInformation: related,
+	}
+}
+
+func (s *Server) hover(params TextDocumentPositionParams) any {
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok || doc.session.Inv == nil || !doc.session.Localisation.Localised || len(doc.problems) != 0 {
+		return nil
+	}
+	line := params.Position.Line
+	col := doc.source.ToBytes(line, params.Position.Character, inventory.UTF16Columns)
+	nodeType, ok := haskell.TypeAt(*doc.session.Inv, doc.session.Localisation, doc.text, line, col)
+	if !ok || len(nodeType.Types) == 0 {
+		return nil
+	}
+	var value strings.Builder
+	if len(nodeType.Types) == 1 && len(nodeType.Types[0].MCS) == 0 {
+		fmt.Fprintf(&value, "```haskell\n%s :: %s\n```", nodeType.DisplayName, nodeType.Types[0].Type)
+	} else {
+		fmt.Fprintf(&value, "`%s` has a different type under each fix:\n", nodeType.DisplayName)
+		for i, candidate := range nodeType.Types {
+			fmt.Fprintf(&value, "\n%d. `%s`", i+1, candidate.Type)
+		}
+	}
+	r := doc.toRange(nodeType.Range)
+	return Hover{Contents: MarkupContent{Kind: "markdown", Value: value.String()}, Range: &r}
+}
+
+func (s *Server) codeActions(params CodeActionParams) []CodeAction {
+	actions := make([]CodeAction, 0)
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return actions
+	}
+	if !doc.suggested {
+		haskell.SuggestEdits(&doc.report, doc.text, haskell.RecheckWith(translator.Translate))
+		doc.suggested = true
+	}
+	for i, typeError := range doc.report.TypeErrors {
+		diagnostic := typeErrorDiagnostic(doc, typeError)
+		touched := false
+		for _, detail := range typeError.CriticalNodes {
+			if overlaps(doc.toRange(detail.Range), params.Range) {
+				touched = true
+			}
+		}
+		if !touched {
+			continue
+		}
+		for _, fix := range typeError.Fixes {
+			for _, suggestion := range fix.Suggestions {
+				edits := make([]TextEdit, len(suggestion.Edits))
+				for k, edit := range suggestion.Edits {
+					edits[k] = TextEdit{Range: doc.toRange(edit.Range), NewText: edit.NewText}
+				}
+				actions = append(actions, CodeAction{
+					Title:       suggestion.Title,
+					Kind:        "quickfix",
+					Diagnostics: []Diagnostic{diagnostic},
+					IsPreferred: true,
+					Edit:        &WorkspaceEdit{Changes: map[string][]TextEdit{doc.uri: edits}},
+				})
+			}
+		}
+		for j, fix := range typeError.Fixes {
+			changed := make([]string, len(fix.MCS))
+			for k, node := range fix.MCS {
+				changed[k] = "`" + doc.sourceText(doc.report.NodeRange[node]) + "`"
+			}
+			actions = append(actions, CodeAction{
+				Title:       fmt.Sprintf("Fix %d: change %s", j+1, strings.Join(changed, ", ")),
+				Kind:        "quickfix",
+				Diagnostics: []Diagnostic{diagnostic},
+				Command: &Command{
+					Title:     "Highlight the expressions this fix changes",
+					Command:   highlightFixCommand,
+					Arguments: []any{doc.uri, i, j},
+				},
+			})
+		}
+	}
+	return actions
+}
+
+// executeCommand highlights the MCS of a fix by publishing a hint on every
+// expression the fix would change.
+func (s *Server) executeCommand(params ExecuteCommandParams) error {
+	if params.Command != highlightFixCommand || len(params.Arguments) != 3 {
+		return fmt.Errorf("unknown command %s", params.Command)
+	}
+	var uri string
+	var errorIndex, fixIndex int
+	if err := json.Unmarshal(params.Arguments[0], &uri); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(params.Arguments[1], &errorIndex); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(params.Arguments[2], &fixIndex); err != nil {
+		return err
+	}
+	doc, ok := s.documents[uri]
+	if !ok || errorIndex < 0 || errorIndex >= len(doc.report.TypeErrors) ||
+		fixIndex < 0 || fixIndex >= len(doc.report.TypeErrors[errorIndex].Fixes) {
+		return fmt.Errorf("fix %d of error %d no longer exists", fixIndex, errorIndex)
+	}
+	doc.highlight = []int{errorIndex, fixIndex}
+	s.publish(doc)
+	return nil
+}
+
+// toRange converts a range of the translator, in bytes, to LSP's UTF-16 columns.
+func (doc *document) toRange(r inventory.Range) Range {
+	r = doc.source.FromBytesRange(r, inventory.UTF16Columns)
+	return Range{
+		Start: Position{Line: r.FromLine, Character: r.FromCol},
+		End:   Position{Line: r.ToLine, Character: r.ToCol},
+	}
+}
+
+func before(a, b Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character <= b.Character)
+}
+
+func overlaps(a, b Range) bool {
+	return before(a.Start, b.End) && before(b.Start, a.End)
+}
+
+// sourceText returns the first line of the source covered by r.
+func (doc *document) sourceText(r inventory.Range) string {
+	text, _, _ := strings.Cut(doc.source.Text(r), "\n")
+	return strings.TrimSpace(text)
+}
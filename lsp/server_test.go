@@ -0,0 +1,258 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"goanna/inventory"
+	"goanna/translator"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	wellTyped = "f = 1\n"
+	illTyped  = "f = 'c' + 1\n"
+)
+
+// translations are what the stand-in translator answers for each program.
+var translations = map[string]inventory.Input{
+	wellTyped: {
+		Declarations: []string{"f"},
+		Rules:        []inventory.Rule{rule(1, "_1 = builtin_Int"), rule(2, "T = _1")},
+		NodeTable:    []inventory.NodePair{{Parent: 0, Child: 1}, {Parent: 0, Child: 2}},
+		NodeDepth:    map[int]int{1: 1, 2: 1},
+		NodeRange: map[int]inventory.Range{
+			1: {FromLine: 0, FromCol: 4, ToLine: 0, ToCol: 5},
+			2: {FromLine: 0, FromCol: 0, ToLine: 0, ToCol: 5},
+		},
+		TopLevels: []string{"f"},
+		MaxLevel:  1,
+	},
+	// 'c' is a Char, 1 is an Int, and + needs both to have the same type
+	illTyped: {
+		Declarations: []string{"f"},
+		Rules:        []inventory.Rule{rule(1, "_1 = builtin_Char"), rule(2, "_2 = builtin_Int"), rule(3, "_1 = _2"), rule(4, "T = _1")},
+		NodeTable:    []inventory.NodePair{{Parent: 0, Child: 1}, {Parent: 0, Child: 2}, {Parent: 0, Child: 3}, {Parent: 0, Child: 4}},
+		NodeDepth:    map[int]int{1: 1, 2: 1, 3: 1, 4: 1},
+		NodeRange: map[int]inventory.Range{
+			1: {FromLine: 0, FromCol: 4, ToLine: 0, ToCol: 7},
+			2: {FromLine: 0, FromCol: 10, ToLine: 0, ToCol: 11},
+			3: {FromLine: 0, FromCol: 4, ToLine: 0, ToCol: 11},
+			4: {FromLine: 0, FromCol: 0, ToLine: 0, ToCol: 11},
+		},
+		TopLevels: []string{"f"},
+		MaxLevel:  1,
+	},
+}
+
+func rule(id int, body string) inventory.Rule {
+	return inventory.Rule{Id: id, Head: inventory.RuleHead{Name: "f", Module: "Main", Type: "type"}, Body: body}
+}
+
+// standInTranslator points the translator at a server that answers with
+// translations, and with a parse error on the first line for any other program.
+func standInTranslator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate" {
+			return
+		}
+		text, _ := io.ReadAll(r.Body)
+		input, ok := translations[string(text)]
+		if !ok {
+			input = inventory.Input{ParsingErrors: []inventory.Range{{FromLine: 0, FromCol: 0, ToLine: 0, ToCol: 1}}}
+		}
+		_ = json.NewEncoder(w).Encode(input)
+	}))
+	address := translator.Address
+	translator.Address = server.URL
+	t.Cleanup(func() {
+		translator.Address = address
+		server.Close()
+	})
+}
+
+// client is the editor end of a server running over in-memory pipes.
+type client struct {
+	*conn
+	t    *testing.T
+	done chan error
+	id   int
+}
+
+func startServer(t *testing.T) *client {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	c := &client{conn: newConn(clientReader, clientWriter), t: t, done: make(chan error, 1)}
+	go func() {
+		c.done <- Serve(serverReader, serverWriter)
+		_ = serverWriter.Close()
+	}()
+	return c
+}
+
+func (c *client) send(method string, params any) {
+	c.t.Helper()
+	if err := c.notify(method, params); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// request sends a request and returns the raw result of its response.
+func (c *client) request(method string, params any) json.RawMessage {
+	c.t.Helper()
+	c.id++
+	raw, err := json.Marshal(params)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	id := json.RawMessage(fmt.Sprint(c.id))
+	if err := c.write(message{Id: id, Method: method, Params: raw}); err != nil {
+		c.t.Fatal(err)
+	}
+	msg := c.receive()
+	assert.Equal(c.t, id, msg.Id, "response to %s", method)
+	assert.Nil(c.t, msg.Error, "response to %s", method)
+	result, _ := json.Marshal(msg.Result)
+	return result
+}
+
+func (c *client) receive() message {
+	c.t.Helper()
+	msg, err := c.read()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return msg
+}
+
+// diagnostics waits for the next diagnostics the server publishes.
+func (c *client) diagnostics() PublishDiagnosticsParams {
+	c.t.Helper()
+	msg := c.receive()
+	assert.Equal(c.t, "textDocument/publishDiagnostics", msg.Method)
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		c.t.Fatal(err)
+	}
+	return params
+}
+
+func (c *client) stop() {
+	c.t.Helper()
+	c.request("shutdown", nil)
+	c.send("exit", nil)
+	assert.NoError(c.t, <-c.done)
+}
+
+func TestInitialize(t *testing.T) {
+	standInTranslator(t)
+	c := startServer(t)
+	var result struct {
+		Capabilities map[string]any `json:"capabilities"`
+		ServerInfo   struct {
+			Name string `json:"name"`
+		} `json:"serverInfo"`
+	}
+	assert.NoError(t, json.Unmarshal(c.request("initialize", map[string]any{}), &result))
+	assert.Equal(t, "goanna", result.ServerInfo.Name)
+	assert.Equal(t, map[string]any{"openClose": true, "change": float64(1)}, result.Capabilities["textDocumentSync"])
+	assert.Equal(t, true, result.Capabilities["hoverProvider"])
+	c.send("initialized", map[string]any{})
+	c.stop()
+}
+
+func TestInitializeWithoutTranslator(t *testing.T) {
+	address := translator.Address
+	translator.Address = "http://127.0.0.1:1"
+	defer func() { translator.Address = address }()
+
+	c := startServer(t)
+	if err := c.write(message{Id: json.RawMessage("1"), Method: "initialize", Params: json.RawMessage("{}")}); err != nil {
+		t.Fatal(err)
+	}
+	warning := c.receive()
+	assert.Equal(t, "window/showMessage", warning.Method)
+	assert.Contains(t, string(warning.Params), "translator is not running")
+	assert.Equal(t, json.RawMessage("1"), c.receive().Id)
+	c.stop()
+}
+
+func TestPublishDiagnostics(t *testing.T) {
+	standInTranslator(t)
+	c := startServer(t)
+	c.request("initialize", map[string]any{})
+	uri := "file:///Main.hs"
+
+	c.send("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageId: "haskell", Version: 1, Text: illTyped},
+	})
+	published := c.diagnostics()
+	assert.Equal(t, uri, published.URI)
+	assert.Len(t, published.Diagnostics, 1)
+	diagnostic := published.Diagnostics[0]
+	assert.Equal(t, SeverityError, diagnostic.Severity)
+	assert.Equal(t, "Type error involving `'c'`, `'c' + 1`, `1`; 3 possible fixes", diagnostic.Message)
+	assert.Equal(t, Range{Start: Position{Line: 0, Character: 4}, End: Position{Line: 0, Character: 7}}, diagnostic.Range)
+	assert.Len(t, diagnostic.RelatedInformation, 3)
+
+	// Fixing the program clears the type error
+	c.send("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: uri},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: wellTyped}},
+	})
+	assert.Empty(t, c.diagnostics().Diagnostics)
+
+	// Only the last of several changes counts
+	c.send("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: uri},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: wellTyped}, {Text: "f = ("}},
+	})
+	published = c.diagnostics()
+	assert.Len(t, published.Diagnostics, 1)
+	assert.Equal(t, "Parse error", published.Diagnostics[0].Message)
+
+	c.send("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}})
+	assert.Empty(t, c.diagnostics().Diagnostics)
+	c.stop()
+}
+
+func TestHover(t *testing.T) {
+	standInTranslator(t)
+	c := startServer(t)
+	uri := "file:///Main.hs"
+	c.send("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, Text: wellTyped},
+	})
+	c.diagnostics()
+
+	var hover Hover
+	result := c.request("textDocument/hover", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: 0, Character: 4},
+	})
+	assert.NoError(t, json.Unmarshal(result, &hover))
+	assert.Equal(t, "markdown", hover.Contents.Kind)
+	assert.Equal(t, "```haskell\n1 :: Int\n```", hover.Contents.Value)
+	c.stop()
+}
+
+func TestUnknownMethod(t *testing.T) {
+	c := startServer(t)
+	if err := c.write(message{Id: json.RawMessage("1"), Method: "textDocument/rename"}); err != nil {
+		t.Fatal(err)
+	}
+	msg := c.receive()
+	assert.Equal(t, json.RawMessage("1"), msg.Id)
+	assert.Equal(t, codeMethodNotFound, msg.Error.Code)
+	c.stop()
+}
+
+func TestExitBeforeShutdown(t *testing.T) {
+	c := startServer(t)
+	c.send("exit", nil)
+	assert.Error(t, <-c.done)
+}
@@ -2,15 +2,23 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"github.com/mattn/go-isatty"
 	"github.com/urfave/cli/v3"
+	"goanna/haskell"
 	"goanna/haskell/meta"
 	"goanna/haskell/parser"
 	"goanna/haskell/rename"
+	"goanna/inventory"
+	"goanna/lsp"
+	"goanna/marco"
+	"goanna/translator"
 )
 
 func parseCommand(ctx context.Context, cmd *cli.Command) error {
@@ -62,7 +70,15 @@ func parseAndRename(dir string) ([]*parser.Module, error) {
 	if err != nil {
 		return nil, err
 	}
-	rename.RenameAll(modules)
+	diagnostics := rename.RenameAll(modules)
+	for _, m := range modules {
+		for _, conflict := range m.FixityConflicts {
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: warning: %s\n", m.Name, conflict.Loc.FromLine()+1, conflict.Loc.FromCol()+1, conflict.Message)
+		}
+	}
+	for _, d := range diagnostics {
+		fmt.Fprintf(os.Stderr, "%s:%d:%d: error: %s\n", d.Module, d.Loc.FromLine()+1, d.Loc.FromCol()+1, d)
+	}
 	return modules, nil
 }
 
@@ -202,10 +218,207 @@ func paramsCommand(ctx context.Context, cmd *cli.Command) error {
 	return nil
 }
 
-func main() {
-	cmd := &cli.Command{
+// translateFile reads a Haskell file and translates it into constraint rules
+// with the translator service.
+func translateFile(filePath string) (*inventory.Inventory, string, error) {
+	code, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	if !translator.Ready() {
+		return nil, "", fmt.Errorf("translator is not running at %s", translator.Address)
+	}
+	input, err := translator.Translate(string(code))
+	if err != nil {
+		return nil, "", err
+	}
+	inv := inventory.NewInventory(input)
+	if len(inv.ParsingErrors) != 0 {
+		return nil, "", fmt.Errorf("parse errors at %v", inv.ParsingErrors)
+	}
+	if len(inv.ImportErrors) != 0 {
+		return nil, "", fmt.Errorf("import errors at %v", inv.ImportErrors)
+	}
+	return inv, string(code), nil
+}
+
+func prologCommand(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("usage: prolog <file.hs>")
+	}
+	inv, code, err := translateFile(cmd.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	level := inv.MaxLevel
+	if cmd.IsSet("level") {
+		level = cmd.Int("level")
+	}
+	inv.Generalize(level)
+	program := inv.RenderAnnotatedProlog(code, cmd.Bool("standalone"))
+	if output := cmd.String("output"); output != "" {
+		return os.WriteFile(output, []byte(program), 0644)
+	}
+	fmt.Println(program)
+	return nil
+}
+
+// parsePosition parses a one-based "<line>:<col>" and returns it zero-based.
+func parsePosition(position string) (int, int, error) {
+	lineText, colText, ok := strings.Cut(position, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("position %q is not <line>:<col>", position)
+	}
+	line, err := strconv.Atoi(lineText)
+	if err != nil || line < 1 {
+		return 0, 0, fmt.Errorf("invalid line %q", lineText)
+	}
+	col, err := strconv.Atoi(colText)
+	if err != nil || col < 1 {
+		return 0, 0, fmt.Errorf("invalid column %q", colText)
+	}
+	return line - 1, col - 1, nil
+}
+
+func typeAtCommand(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 2 {
+		return fmt.Errorf("usage: type-at <file.hs> <line>:<col>")
+	}
+	line, col, err := parsePosition(cmd.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	inv, code, err := translateFile(cmd.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	localisation := haskell.Localise(inv, inv.MaxLevel, marcoOptions(cmd)...)
+	if !localisation.Localised {
+		return fmt.Errorf("could not localise the type errors at any level")
+	}
+	// Columns on the command line count characters, the translator counts bytes
+	col = inventory.NewSource(code).ToBytes(line, col, inventory.CodepointColumns)
+	nodeType, ok := haskell.TypeAt(*inv, localisation, code, line, col)
+	if !ok {
+		return fmt.Errorf("no typed expression at %s", cmd.Args().Get(1))
+	}
+	fmt.Printf("%s\n", nodeType.DisplayName)
+	if localisation.WellTyped() {
+		fmt.Printf("  :: %s\n", nodeType.Types[0].Type)
+		return nil
+	}
+	for i, candidate := range nodeType.Types {
+		fmt.Printf("  fix %d: :: %s\n", i+1, candidate.Type)
+	}
+	return nil
+}
+
+func checkCommand(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("missing file argument")
+	}
+	filePath := cmd.Args().Get(0)
+	format := cmd.String("format")
+	if format != "text" && format != "json" && format != "sarif" {
+		return fmt.Errorf("unknown format %q, expected text, json or sarif", format)
+	}
+	inv, code, err := translateFile(filePath)
+	if err != nil {
+		return err
+	}
+	localisation := haskell.Localise(inv, inv.MaxLevel, marcoOptions(cmd)...)
+	result := haskell.NewCheckResult(inv, localisation, filePath, code)
+	if cmd.Bool("suggest") {
+		haskell.SuggestEdits(&result.Report, code, haskell.RecheckWith(translator.Translate))
+	}
+	switch format {
+	case "json":
+		err = printJSON(result.JSON())
+	case "sarif":
+		err = printJSON(result.SARIF())
+	default:
+		colour := isatty.IsTerminal(os.Stdout.Fd()) && os.Getenv("NO_COLOR") == ""
+		fmt.Print(result.Text(colour))
+	}
+	if err != nil {
+		return err
+	}
+	if result.Status != haskell.StatusWellTyped {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+func annotateCommand(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() < 1 {
+		return fmt.Errorf("missing file argument")
+	}
+	filePath := cmd.Args().Get(0)
+	var defaults haskell.NumericDefaults
+	switch cmd.String("numeric") {
+	case "generalise":
+		defaults = haskell.GeneraliseDefaults
+	case "monomorphise":
+		defaults = haskell.MonomorphiseDefaults
+	default:
+		return fmt.Errorf("unknown numeric defaults %q, expected generalise or monomorphise", cmd.String("numeric"))
+	}
+	inv, code, err := translateFile(filePath)
+	if err != nil {
+		return err
+	}
+	localisation := haskell.Localise(inv, inv.MaxLevel, marcoOptions(cmd)...)
+	if !localisation.WellTyped() {
+		return fmt.Errorf("%s is not well typed; run check to see why", filePath)
+	}
+	annotations, err := haskell.Annotate(code, haskell.InferStructuredTypes(*inv), defaults)
+	if err != nil {
+		return fmt.Errorf("cannot annotate %s: %w", filePath, err)
+	}
+	if cmd.Bool("dry-run") {
+		fmt.Print(haskell.AnnotationDiff(filePath, code, annotations))
+		return nil
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, []byte(haskell.ApplyAnnotations(code, annotations)), info.Mode())
+}
+
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func lspCommand(ctx context.Context, cmd *cli.Command) error {
+	// The protocol owns stdout; anything else the pipeline prints goes to stderr
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	return lsp.Serve(os.Stdin, stdout)
+}
+
+// marcoOptions are the options of the MARCO runs of a command.
+func marcoOptions(cmd *cli.Command) []marco.Option {
+	return []marco.Option{marco.WithSeed(uint64(cmd.Int("seed")))}
+}
+
+// newCommand returns the command line interface of goanna.
+func newCommand() *cli.Command {
+	return &cli.Command{
 		Name:  "goanna",
 		Usage: "Haskell analysis and parsing tool",
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:    "seed",
+				Usage:   "shuffle the order MARCO tries rules in with this seed; 0 keeps it deterministic",
+				Sources: cli.EnvVars("GOANNA_SEED"),
+			},
+		},
 		Commands: []*cli.Command{
 			{
 				Name:      "parse",
@@ -261,10 +474,54 @@ func main() {
 				ArgsUsage: "<dir>",
 				Action:    typeVarClassesCommand,
 			},
+			{
+				Name:      "prolog",
+				Usage:     "Translate a Haskell file and print the constraint program, annotated with the source of every goal",
+				ArgsUsage: "<file.hs>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "standalone", Usage: "emit a program that can be loaded into SWI-Prolog"},
+					&cli.IntFlag{Name: "level", Usage: "generalisation level (default: the deepest level)"},
+					&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "write the program to a file"},
+				},
+				Action: prologCommand,
+			},
+			{
+				Name:      "check",
+				Usage:     "Translate and type check a Haskell file; exits with status 1 if it is not well typed",
+				ArgsUsage: "<file.hs>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "format", Value: "text", Usage: "output format: text, json or sarif"},
+					&cli.BoolFlag{Name: "suggest", Value: true, Usage: "suggest rewrites for fixes, re-checking each one"},
+				},
+				Action: checkCommand,
+			},
+			{
+				Name:      "annotate",
+				Usage:     "Insert a type signature above every top-level binding of a well-typed Haskell file that lacks one",
+				ArgsUsage: "<file.hs>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "dry-run", Usage: "print the changes as a unified diff instead of writing the file"},
+					&cli.StringFlag{Name: "numeric", Value: "generalise", Usage: "numeric defaults: generalise (Num a => a) or monomorphise (Integer)"},
+				},
+				Action: annotateCommand,
+			},
+			{
+				Name:      "type-at",
+				Usage:     "Translate a Haskell file and print the type of the expression at a one-based position, once per candidate fix if it is ill typed",
+				ArgsUsage: "<file.hs> <line>:<col>",
+				Action:    typeAtCommand,
+			},
+			{
+				Name:   "lsp",
+				Usage:  "Run a language server over stdio, reporting type errors, fixes and types on hover",
+				Action: lspCommand,
+			},
 		},
 	}
+}
 
-	if err := cmd.Run(context.Background(), os.Args); err != nil {
+func main() {
+	if err := newCommand().Run(context.Background(), os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
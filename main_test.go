@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"goanna/haskell"
+	"goanna/inventory"
+	"goanna/translator"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+)
+
+// runCommand runs goanna with args and returns what it wrote to stdout.
+func runCommand(t *testing.T, args ...string) []byte {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- data
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	cmd := newCommand()
+	// An ill-typed file exits with status 1; keep the test running
+	cmd.ExitErrHandler = func(context.Context, *cli.Command, error) {}
+	err = cmd.Run(context.Background(), append([]string{"goanna"}, args...))
+	os.Stdout = stdout
+	if closeErr := w.Close(); closeErr != nil {
+		t.Fatal(closeErr)
+	}
+	data := <-output
+	if _, ok := err.(cli.ExitCoder); err != nil && !ok {
+		t.Fatalf("goanna %v: %v", args, err)
+	}
+	return data
+}
+
+// decodeAll decodes data as exactly one JSON value into v.
+func decodeAll(t *testing.T, data []byte, v any) {
+	t.Helper()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		t.Fatalf("stdout is not a report: %v\n%s", err, data)
+	}
+	if decoder.More() {
+		t.Fatalf("stdout has more than the report:\n%s", data)
+	}
+}
+
+func TestCheckFormats(t *testing.T) {
+	if !translator.Ready() {
+		// Under CI the translator is expected to run, as for the corpus tests
+		if os.Getenv("CI") != "" {
+			t.Fatalf("translator is not running at %s", translator.Address)
+		}
+		t.Skipf("translator is not running at %s", translator.Address)
+	}
+	file := "test/corpus/ill-typed/if-branches.hs"
+
+	var report haskell.JSONReport
+	decodeAll(t, runCommand(t, "check", "--format=json", "--suggest=false", file), &report)
+	assert.Equal(t, haskell.ReportSchemaVersion, report.SchemaVersion)
+	assert.Equal(t, haskell.StatusTypeError, report.Status)
+	assert.NotEmpty(t, report.TypeErrors)
+
+	var log haskell.SarifLog
+	decodeAll(t, runCommand(t, "check", "--format=sarif", "--suggest=false", file), &log)
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Len(t, log.Runs, 1)
+	assert.Len(t, log.Runs[0].Results, len(report.TypeErrors))
+}
+
+// TestCheckFormatsWellTyped runs check against a stand-in translator, so that
+// the formats are checked to be alone on stdout without the real one.
+func TestCheckFormatsWellTyped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/translate" {
+			// f = 1
+			_ = json.NewEncoder(w).Encode(inventory.Input{
+				Declarations: []string{"f"},
+				Rules: []inventory.Rule{
+					{Id: 1, Head: inventory.RuleHead{Name: "f", Module: "Main", Type: "type"}, Body: "T = int"},
+				},
+				NodeTable: []inventory.NodePair{{Parent: 0, Child: 1}},
+				NodeDepth: map[int]int{1: 1},
+				NodeRange: map[int]inventory.Range{1: {FromLine: 1, FromCol: 4, ToLine: 1, ToCol: 5}},
+				TopLevels: []string{"f"},
+				MaxLevel:  1,
+			})
+		}
+	}))
+	defer server.Close()
+	address := translator.Address
+	translator.Address = server.URL
+	defer func() { translator.Address = address }()
+
+	file := filepath.Join(t.TempDir(), "well-typed.hs")
+	if err := os.WriteFile(file, []byte("module Main where\nf = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var report haskell.JSONReport
+	decodeAll(t, runCommand(t, "check", "--format=json", file), &report)
+	assert.Equal(t, haskell.StatusWellTyped, report.Status)
+	assert.Empty(t, report.TypeErrors)
+
+	var log haskell.SarifLog
+	decodeAll(t, runCommand(t, "check", "--format=sarif", file), &log)
+	assert.Empty(t, log.Runs[0].Results)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	mapset "github.com/deckarep/golang-set/v2"
 	"goanna/graph"
+	"math/rand/v2"
+	"slices"
 )
 
 type IntSet mapset.Set[int]
@@ -17,12 +19,30 @@ type Cause struct {
 type Error struct {
 	Causes        []Cause
 	CriticalNodes []int
+	// The MUSs whose union is CriticalNodes
+	MUSs []IntSet
 }
 
 func NewIntSet(vals ...int) IntSet {
 	return IntSet(mapset.NewSet[int](vals...))
 }
 
+// Sorted returns the elements of s in ascending order. Iterating a set
+// directly follows Go's random map order.
+func Sorted(s IntSet) []int {
+	result := s.ToSlice()
+	slices.Sort(result)
+	return result
+}
+
+// compareSets orders sets by size, then by their sorted elements.
+func compareSets(a, b IntSet) int {
+	if a.Cardinality() != b.Cardinality() {
+		return a.Cardinality() - b.Cardinality()
+	}
+	return slices.Compare(Sorted(a), Sorted(b))
+}
+
 type Marco struct {
 	Rules        IntSet
 	MUSs         []IntSet
@@ -33,9 +53,26 @@ type Marco struct {
 	SatFunc      func([]int) bool
 	Solver       Solver
 	singletonMUS IntSet
+	// Shuffles the order rules are tried in; nil tries them in ascending order
+	Random *rand.Rand
+}
+
+// Option configures a Marco when it is created.
+type Option func(*Marco)
+
+// WithSeed makes the run try rules in an order shuffled by seed instead of in
+// ascending order, to explore other search paths. Runs with the same seed are
+// alike, and the errors they find are reported in the same order either way.
+// A zero seed keeps the ascending order.
+func WithSeed(seed uint64) Option {
+	return func(m *Marco) {
+		if seed != 0 {
+			m.Random = rand.New(rand.NewPCG(seed, seed))
+		}
+	}
 }
 
-func NewMarco(rules []int, satFunc func([]int) bool) *Marco {
+func NewMarco(rules []int, satFunc func([]int) bool, options ...Option) *Marco {
 	marco := Marco{
 		Rules:        mapset.NewSet[int](rules...),
 		MUSs:         []IntSet{},
@@ -47,11 +84,25 @@ func NewMarco(rules []int, satFunc func([]int) bool) *Marco {
 		Solver:       NewMaxsatSolver(NewIntSet(rules...)),
 		singletonMUS: NewIntSet(),
 	}
+	for _, option := range options {
+		option(&marco)
+	}
 	return &marco
 }
 
+// order returns the elements of s in the order they are to be tried.
+func (m *Marco) order(s IntSet) []int {
+	elems := Sorted(s)
+	if m.Random != nil {
+		m.Random.Shuffle(len(elems), func(i, j int) {
+			elems[i], elems[j] = elems[j], elems[i]
+		})
+	}
+	return elems
+}
+
 func (m *Marco) Grow(seed IntSet) IntSet {
-	for elem := range (m.Rules.Difference(seed)).Iter() {
+	for _, elem := range m.order(m.Rules.Difference(seed)) {
 		newSet := seed.Clone()
 		newSet.Add(elem)
 		if m.Sat(newSet) {
@@ -62,8 +113,7 @@ func (m *Marco) Grow(seed IntSet) IntSet {
 }
 
 func (m *Marco) Shrink(seed IntSet) IntSet {
-	newSeed := seed.Clone()
-	for elem := range newSeed.Iter() {
+	for _, elem := range m.order(seed) {
 		if m.singletonMUS.Contains(elem) {
 			continue
 		}
@@ -76,7 +126,7 @@ func (m *Marco) Shrink(seed IntSet) IntSet {
 }
 
 func (m *Marco) Sat(rules IntSet) bool {
-	return m.SatFunc(rules.ToSlice())
+	return m.SatFunc(Sorted(rules))
 }
 
 func (m *Marco) Run() {
@@ -131,6 +181,11 @@ func combinations(input []int) [][]int {
 }
 
 func (m *Marco) Analysis() []Error {
+	// Discovery order depends on the solver, so put the sets in a canonical
+	// order before they decide the order of errors and causes
+	slices.SortFunc(m.MUSs, compareSets)
+	slices.SortFunc(m.MSSs, func(a, b IntSet) int { return compareSets(b, a) })
+
 	// Populate MCS List
 	for _, mss := range m.MSSs {
 		m.MCSs = append(m.MCSs, m.Rules.Difference(mss))
@@ -154,11 +209,12 @@ func (m *Marco) Analysis() []Error {
 		}
 	}
 
-	_, components := musGraph.CountAndGetConnectedComponents()
+	count, components := musGraph.CountAndGetConnectedComponents()
 	//fmt.Printf("Components: \n %v\n", components)
 
 	errors := make([]Error, 0)
-	for _, component := range components {
+	for id := 1; id <= count; id++ {
+		component := components[id]
 		musList := make([]IntSet, 0)
 		mcsList := make([]IntSet, 0)
 		for _, musId := range component {
@@ -192,10 +248,19 @@ func (m *Marco) Analysis() []Error {
 
 		errors = append(errors, Error{
 			Causes:        causes,
-			CriticalNodes: criticalNodes.ToSlice(),
+			CriticalNodes: Sorted(criticalNodes),
+			MUSs:          musList,
 		})
 	}
 
+	AssignMSS(errors, m.Rules)
+	return errors
+}
+
+// AssignMSS fills in the MSS of every cause in errors with respect to the
+// full rule set rules. Errors may come from different MARCO runs, as long as
+// their MCSs are expressed over the same rule IDs.
+func AssignMSS(errors []Error, rules IntSet) {
 	for i, err := range errors {
 		// This is to make sure the MSS correspond to each MCS is readily available to
 		// be query most concrete types.
@@ -210,10 +275,9 @@ func (m *Marco) Analysis() []Error {
 		}
 		for j, cause := range err.Causes {
 			combinedMCS := cause.MCS.Union(otherMCS)
-			errors[i].Causes[j].MSS = m.Rules.Difference(combinedMCS)
+			errors[i].Causes[j].MSS = rules.Difference(combinedMCS)
 		}
 	}
-	return errors
 }
 
 func TestMarco() {
@@ -0,0 +1,58 @@
+package marco
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Rules 1 and 2, 2 and 3, and 4 and 5 contradict each other.
+func conflicts(rules []int) bool {
+	for _, pair := range [][]int{{1, 2}, {2, 3}, {4, 5}} {
+		if slices.Contains(rules, pair[0]) && slices.Contains(rules, pair[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+type summary struct {
+	CriticalNodes []int
+	MCSs          [][]int
+	MUSs          [][]int
+}
+
+func analyse(options ...Option) []summary {
+	mc := NewMarco([]int{1, 2, 3, 4, 5, 6}, conflicts, options...)
+	mc.Run()
+	result := make([]summary, 0)
+	for _, e := range mc.Analysis() {
+		s := summary{CriticalNodes: e.CriticalNodes}
+		for _, cause := range e.Causes {
+			s.MCSs = append(s.MCSs, Sorted(cause.MCS))
+		}
+		for _, mus := range e.MUSs {
+			s.MUSs = append(s.MUSs, Sorted(mus))
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+func TestAnalysisIsDeterministic(t *testing.T) {
+	expect := []summary{
+		{CriticalNodes: []int{1, 2, 3}, MCSs: [][]int{{2}, {1, 3}}, MUSs: [][]int{{1, 2}, {2, 3}}},
+		{CriticalNodes: []int{4, 5}, MCSs: [][]int{{4}, {5}}, MUSs: [][]int{{4, 5}}},
+	}
+	for range 10 {
+		assert.Equal(t, expect, analyse())
+	}
+}
+
+func TestSeedKeepsErrors(t *testing.T) {
+	expect := analyse()
+	for seed := range uint64(5) {
+		assert.Equal(t, expect, analyse(WithSeed(seed+1)))
+	}
+}
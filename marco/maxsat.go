@@ -12,8 +12,8 @@ type MaxSatSolver struct {
 }
 
 func NewMaxsatSolver(vars IntSet) *MaxSatSolver {
-	softClauses := make([]maxsat.Constr, len(vars.ToSlice()))
-	for i, v := range vars.ToSlice() {
+	softClauses := make([]maxsat.Constr, vars.Cardinality())
+	for i, v := range Sorted(vars) {
 		softClauses[i] = maxsat.SoftClause(maxsat.Var(strconv.Itoa(v)))
 	}
 
@@ -43,8 +43,8 @@ func (s *MaxSatSolver) Model() IntSet {
 }
 
 func (s *MaxSatSolver) AddClause(vars IntSet) {
-	clauses := make([]maxsat.Lit, len(vars.ToSlice()))
-	for i, v := range vars.ToSlice() {
+	clauses := make([]maxsat.Lit, vars.Cardinality())
+	for i, v := range Sorted(vars) {
 		if v > 0 {
 			vStr := strconv.Itoa(v)
 			clauses[i] = maxsat.Var(vStr)
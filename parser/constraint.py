@@ -17,6 +17,12 @@ class GlobalState:
     def add_class_var(self, head_name: str, class_var:str):
         raise NotImplementedError
 
+    def add_hole(self, hole: Hole):
+        raise NotImplementedError
+
+    def locals_at(self, module: str, loc: Range) -> list[Vendor]:
+        raise NotImplementedError
+
 class ConstraintGenState:
     def __init__(self, global_state: GlobalState):
         self.fresh_counter = 0
@@ -395,7 +401,8 @@ def generate_constraint(ast: Pretty, head: RuleHead | None, state: ConstraintGen
             for exp in exps:
                 generate_constraint(exp, head, state)
 
-        case ExpVar(canonical_name=canonical_name) | ExpCon(canonical_name=canonical_name):
+        case ExpVar(canonical_name=canonical_name) | ExpCon(canonical_name=canonical_name) | \
+             ExpHole(canonical_name=str() as canonical_name):
             if canonical_name == 'builtin_unit':
                 state.add_rule(unify(node_var(ast), 'builtin_Top'), head, ast.id)
 
@@ -411,6 +418,16 @@ def generate_constraint(ast: Pretty, head: RuleHead | None, state: ConstraintGen
             else:
                 state.add_rule(unify(node_var(ast), LVar(value=f'_{canonical_name}')), head, ast.id)
 
+        case ExpHole(name=name, context_id=context_id):
+            # A hole places no constraint on its type. The rules only exist so that the hole
+            # and the local bindings in scope can be captured like any other node.
+            local_vendors = state.global_state.locals_at(state.module, ast.loc)
+            local_vars = [LVar(value=f'_{v.canonical_name}') for v in local_vendors]
+            state.add_rule(unify(node_var(ast), state.fresh()), head, ast.id)
+            state.add_rule(unify(LVar(value=f'_{context_id}'), LList(elements=local_vars)), head, context_id)
+            state.global_state.add_hole(Hole(node_id=ast.id, context_id=context_id, name=name, decl=head.name,
+                                             locals=[v.name for v in local_vendors]))
+
         case ExpEnumTo(exp=exp) | ExpEnumFrom(exp=exp):
             state.add_rule(unify(node_var(ast), list_of(node_var(exp))), head, ast.id)
             state.add_rule(has_class(node_var(exp), 'p_Enum'), head, ast.id)
@@ -45,7 +45,7 @@ Lit = Union['LitChar', 'LitString', 'LitInt', 'LitFrac']
 
 Ty = Union['TyCon', 'TyApp', 'TyFun', 'TyTuple', 'TyList', 'TyVar', 'TyForall', 'TyPrefixList', 'TyPrefixTuple', "TyPrefixFunction"]
 
-Exp = Union['ExpVar', 'ExpCon', 'Lit',  # 'ExpLit', Use lit to simplify
+Exp = Union['ExpVar', 'ExpCon', 'ExpHole', 'Lit',  # 'ExpLit', Use lit to simplify
 'ExpApp', 'ExpInfixApp',  # Added this
 'ExpLambda', 'ExpLet', 'ExpIf', 'ExpCase', 'ExpDo',
 'ExpTuple', 'ExpList', 'ExpLeftSection', 'ExpRightSection',
@@ -269,6 +269,15 @@ class ExpCon(Pretty):
     canonical_name: str | None
 
 
+@dataclass
+class ExpHole(Pretty):
+    name: str
+    context_id: int  # Extra id for the rule that captures the local bindings in scope
+    # A `_name` that is bound is a variable, not a hole; renaming sets its canonical name
+    canonical_name: str | None
+    module: str | None
+
+
 @dataclass
 class ExpApp(Pretty):
     exp1: Exp
@@ -74,7 +74,7 @@ class Traverse:
                 ast.pat2 = self.traverse(pat2, ast)
 
             # Expressions
-            case ExpVar() | ExpCon():
+            case ExpVar() | ExpCon() | ExpHole():
                 pass
 
             case ExpApp(exp1=exp1, exp2=exp2):
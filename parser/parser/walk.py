@@ -196,6 +196,10 @@ def match_exp(node: Node, env: ParseEnv) -> Exp:
                     return ExpCon(id=env.new_id(), loc=make_loc(node), name=get_text(ident), canonical_name=None,
                                   module=module)
         case "variable":
+            if get_text(node).startswith('_'):
+                # A hole unless renaming finds a binding of it
+                return ExpHole(id=env.new_id(), loc=make_loc(node), name=get_text(node), context_id=env.new_id(),
+                               canonical_name=None, module=None)
             return ExpVar(id=env.new_id(), loc=make_loc(node), name=get_text(node), canonical_name=None, module=None)
         case "parens":
             return match_exp(node.child_by_field_name("expression"), env)
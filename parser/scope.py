@@ -266,7 +266,8 @@ def get_vendors(asts: list[Pretty], state: State) -> list[Vendor]:
 def update_buyers(module_name: str, data: list[Buyer], ast: Pretty, *_) -> list[Buyer]:
     match ast:
         case ExpVar(name=name, module=module) | ExpCon(name=name, module=module) | \
-             PApp(name=name, module=module) | PInfix(name=name, module=module):
+             PApp(name=name, module=module) | PInfix(name=name, module=module) | \
+             ExpHole(name=name, module=module):
             data.append(Buyer(
                 node_id=ast.id,
                 name=name,
@@ -368,6 +369,9 @@ def allocate_buyers(vendors: list[Vendor], buyers: list[Buyer], import_map: dict
                     buyer.canonical_name = 'builtin_Float'
                     buyer.module = 'builtin'
                     new_buyers.append(buyer)
+                case name if name.startswith('_'):
+                    # Nothing binds `_name`, so it is a hole rather than a variable
+                    pass
                 case _:
                     import_errors.append(buyer)
             continue
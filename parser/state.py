@@ -127,6 +127,14 @@ class Identifier(BaseModel): # This is just a simpler vendor, for sending throug
         )
 
 
+class Hole(BaseModel):
+    node_id: int
+    context_id: int
+    name: str
+    decl: str
+    locals: list[str]  # Names of the local bindings in scope, in the order they are captured
+
+
 class InventoryInput(BaseModel):
     declarations: list[str]
     top_levels: list[str]
@@ -139,6 +147,8 @@ class InventoryInput(BaseModel):
     node_graph: list[dict] = []
     max_depth: int = 0
     collectors: dict[str, list[str]]
+    closures: Closures = {}
+    holes: list[Hole] = []
     node_range: dict[int, NodeRange] = {}
     parsing_errors: list[NodeRange]
     import_errors: list[Identifier]
@@ -163,6 +173,7 @@ class State(BaseModel):
     node_graph: list[tuple[int, int]] = []
     node_table: dict[int, Range] = {}
     max_depth: int = 0
+    holes: list[Hole] = []
 
     def get_declarations(self) -> list[str]:
         return self.declarations
@@ -178,3 +189,13 @@ class State(BaseModel):
 
     def add_class_var(self, head_name: str, class_var:str):
         self.collectors[head_name].append(class_var)
+
+    def add_hole(self, hole: Hole):
+        self.holes.append(hole)
+
+    def locals_at(self, module: str, loc: Range) -> list[Vendor]:
+        return [v for v in self.vendors
+                if v.type == 'term' and not v.is_declaration and v.module == module
+                and not v.effective_range.is_global
+                and within(loc, v.effective_range.ranges)
+                and not any(within(loc, r) for r in v.effective_range.excludes)]
@@ -51,6 +51,7 @@ def parse_modules(files: list[tuple[str, str]]) -> State:
     state.import_errors = import_errors
     if import_errors:
         return state
+    state.vendors = vendors
     for ast in asts:
         rename(ast, vendors, buyers)
 
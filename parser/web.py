@@ -238,6 +238,8 @@ async def translate(body: str = Body()):
             node_id: NodeRange.from_range(_range)
          for node_id, _range in state.node_table.items()},
         collectors=state.collectors,
+        closures=state.closures,
+        holes=state.holes,
     )
 
     return inventory_input
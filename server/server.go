@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"goanna/haskell"
 	"goanna/inventory"
-	"goanna/marco"
+	"goanna/translator"
 	"io"
 	"log"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 )
 
@@ -23,6 +23,8 @@ type Response struct {
 	InferredTypes map[string]string
 	TopLevels     []string
 	Declarations  []string
+	Levels        []haskell.LevelStep
+	Holes         []haskell.HoleType
 }
 
 const (
@@ -30,6 +32,7 @@ const (
 	TypeCheckingStage = "type-check"
 	ImportErrorStage  = "import"
 	WellTypedStage    = "well-typed"
+	UnlocalisedStage  = "unlocalised"
 )
 
 func handleParsingError(w http.ResponseWriter, inv *inventory.Inventory) {
@@ -79,7 +82,7 @@ func typeCheck(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	input, err := parseHaskellFile(haskellFile)
+	input, err := translator.Translate(haskellFile)
 	if err != nil {
 		fmt.Println("Error parsing Haskell file")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -96,70 +99,54 @@ func typeCheck(w http.ResponseWriter, r *http.Request) {
 		handleImportError(w, inv)
 		return
 	}
-	level := input.MaxLevel
-	errors := make([]marco.Error, 0)
-
-	for {
-		if level == 0 {
-			panic("No more level to generalize ")
-		}
-		inv.Generalize(level)
-		if !inv.AxiomCheck() {
-			level = level - 1
-			continue
-		}
-		if !inv.TypeCheck() {
-			ruleIds := inv.EffectiveRules
-			inv.ConsultAxioms()
-			mc := marco.NewMarco(ruleIds, inv.Satisfiable)
-			mc.Run()
-
-			errors = mc.Analysis()
-			if len(errors) == 1 && len(errors[0].CriticalNodes) == 0 {
-				fmt.Printf("No solutions: %v\n", errors)
-				level = level - 1
-				continue
-			}
-			break
-		} else {
-			fmt.Println("No type error")
-			break
-		}
+	localisation := haskell.Localise(inv, input.MaxLevel)
+	err = json.NewEncoder(w).Encode(makeCheckResponse(inv, localisation, haskellFile))
+	if err != nil {
+		panic(err)
 	}
-	if len(errors) != 0 { // Type error found
-		report := haskell.MakeReport(errors, *inv, haskellFile)
-		response := Response{
-			Stage:         TypeCheckingStage,
-			TypeErrors:    report.TypeErrors,
+}
+
+func makeCheckResponse(inv *inventory.Inventory, localisation haskell.Localisation, haskellFile string) Response {
+	if !localisation.Localised {
+		return Response{
+			Stage:         UnlocalisedStage,
+			TypeErrors:    []haskell.TypeError{},
 			ParsingErrors: []inventory.Range{},
 			ImportErrors:  []inventory.Identifier{},
-			NodeRange:     report.NodeRange,
+			NodeRange:     inv.NodeRange,
 			InferredTypes: make(map[string]string),
 			Declarations:  inv.Declarations,
 			TopLevels:     inv.TopLevels,
+			Levels:        localisation.Trace,
 		}
-		err := json.NewEncoder(w).Encode(response)
-		if err != nil {
-			panic(err)
-		}
-	} else {
-		// Well typed Program
-		response := Response{
-			Stage:         WellTypedStage,
-			TypeErrors:    []haskell.TypeError{},
+	}
+	if len(localisation.Errors) != 0 { // Type error found
+		report := haskell.MakeReport(localisation.Errors, *inv, haskellFile)
+		return Response{
+			Stage:         TypeCheckingStage,
+			TypeErrors:    report.TypeErrors,
 			ParsingErrors: []inventory.Range{},
 			ImportErrors:  []inventory.Identifier{},
-			NodeRange:     inv.NodeRange,
-			InferredTypes: haskell.InferTypes(*inv),
+			NodeRange:     report.NodeRange,
+			InferredTypes: make(map[string]string),
 			Declarations:  inv.Declarations,
 			TopLevels:     inv.TopLevels,
-		}
-		err := json.NewEncoder(w).Encode(response)
-		if err != nil {
-			panic(err)
+			Levels:        localisation.Trace,
 		}
 	}
-
+	// Well typed Program
+	return Response{
+		Stage:         WellTypedStage,
+		TypeErrors:    []haskell.TypeError{},
+		ParsingErrors: []inventory.Range{},
+		ImportErrors:  []inventory.Identifier{},
+		NodeRange:     inv.NodeRange,
+		InferredTypes: haskell.InferTypes(*inv),
+		Declarations:  inv.Declarations,
+		TopLevels:     inv.TopLevels,
+		Levels:        localisation.Trace,
+		Holes:         haskell.InferHoles(*inv, inv.EffectiveRules),
+	}
 }
 
 func renderProlog(w http.ResponseWriter, r *http.Request) {
@@ -172,7 +159,7 @@ func renderProlog(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	input, err := parseHaskellFile(haskellFile)
+	input, err := translator.Translate(haskellFile)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -188,6 +175,91 @@ func renderProlog(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// renderAnnotatedProlog is like renderProlog, but every goal is annotated with
+// the source it came from. With ?standalone=true the program can be loaded
+// into SWI-Prolog as is.
+func renderAnnotatedProlog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Accept")
+	haskellFile, err := getHaskellFile(r)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	input, err := translator.Translate(haskellFile)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	inv := inventory.NewInventory(input)
+	level := input.MaxLevel
+	inv.Generalize(level)
+	prologText := inv.RenderAnnotatedProlog(haskellFile, r.URL.Query().Get("standalone") == "true")
+	_, err = fmt.Fprint(w, prologText)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// typeAt reports the type of the smallest node at the zero-based position
+// given by ?line=&col=, once for every candidate fix if the program is ill typed.
+// col counts bytes, unless ?unit=utf16 or ?unit=codepoint says otherwise.
+func typeAt(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Accept")
+
+	line, err := strconv.Atoi(r.URL.Query().Get("line"))
+	if err != nil {
+		http.Error(w, "invalid line", http.StatusBadRequest)
+		return
+	}
+	col, err := strconv.Atoi(r.URL.Query().Get("col"))
+	if err != nil {
+		http.Error(w, "invalid col", http.StatusBadRequest)
+		return
+	}
+	haskellFile, err := getHaskellFile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	input, err := translator.Translate(haskellFile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	inv := inventory.NewInventory(input)
+	if len(inv.ParsingErrors) != 0 {
+		handleParsingError(w, inv)
+		return
+	}
+	if len(inv.ImportErrors) != 0 {
+		handleImportError(w, inv)
+		return
+	}
+	switch r.URL.Query().Get("unit") {
+	case "utf16":
+		col = inventory.NewSource(haskellFile).ToBytes(line, col, inventory.UTF16Columns)
+	case "codepoint":
+		col = inventory.NewSource(haskellFile).ToBytes(line, col, inventory.CodepointColumns)
+	}
+	localisation := haskell.Localise(inv, input.MaxLevel)
+	nodeType, ok := haskell.TypeAt(*inv, localisation, haskellFile, line, col)
+	if !ok {
+		http.Error(w, "no typed node at this position", http.StatusNotFound)
+		return
+	}
+	err = json.NewEncoder(w).Encode(nodeType)
+	if err != nil {
+		panic(err)
+	}
+}
+
 func getHaskellFile(r *http.Request) (string, error) {
 	bodyBytes, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -206,44 +278,9 @@ func getHaskellFile(r *http.Request) (string, error) {
 	return requestBody, nil
 }
 
-func parseHaskellFile(text string) (inventory.Input, error) {
-	resp, err := http.Post(
-		"http://localhost:8090/translate",
-		"text/plain",
-		strings.NewReader(text))
-	if err != nil {
-		fmt.Println("Error making HTTP request")
-		return inventory.Input{}, err
-
-	}
-	defer func() {
-		err := resp.Body.Close()
-		if err != nil {
-			log.Printf("Error closing response body: %v", err)
-		}
-	}()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Println("Error read respond body")
-		return inventory.Input{}, err
-	}
-	var input inventory.Input
-	err = json.Unmarshal(body, &input)
-	if err != nil {
-		fmt.Printf("Error unmarshalling JSON response: %v", err)
-		return inventory.Input{}, err
-	}
-	return input, nil
-}
-
-func isServerReady() bool {
-	resp, err := http.Get("http://localhost:8090/ping")
-	return err == nil && resp.StatusCode == 200
-}
-
 func main() {
 	counter := 0
-	for !isServerReady() {
+	for !translator.Ready() {
 		if counter > 100 {
 			panic("failed to detect parsing server")
 		}
@@ -251,6 +288,10 @@ func main() {
 		counter = counter + 1
 	}
 	http.HandleFunc("/prolog", renderProlog)
+	http.HandleFunc("/prolog/annotated", renderAnnotatedProlog)
 	http.HandleFunc("/typecheck", typeCheck)
+	http.HandleFunc("/type-at", typeAt)
+	http.HandleFunc("POST /session/{id}/edit", editSession)
+	http.HandleFunc("DELETE /session/{id}", closeSession)
 	_ = http.ListenAndServe(":8080", nil)
 }
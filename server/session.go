@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"goanna/haskell"
+	"goanna/inventory"
+	"goanna/translator"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sessionTTL is how long a session is kept after its last edit. Editors that
+// close without a DELETE would otherwise leave their sessions behind.
+const sessionTTL = 30 * time.Minute
+
+// Session guards the state of one editor buffer between edits.
+type Session struct {
+	mu    sync.Mutex
+	state haskell.Session
+	// lastUsed is guarded by sessionsMu
+	lastUsed time.Time
+}
+
+type SessionResponse struct {
+	Response
+	Rechecked []string
+	Reused    int
+}
+
+var (
+	sessionsMu sync.Mutex
+	sessions   = make(map[string]*Session)
+)
+
+// getSession returns the session id, creating it if needed, and evicts the
+// sessions that have been idle for longer than sessionTTL.
+func getSession(id string) *Session {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	now := time.Now()
+	for other, s := range sessions {
+		if now.Sub(s.lastUsed) > sessionTTL {
+			delete(sessions, other)
+		}
+	}
+	s, ok := sessions[id]
+	if !ok {
+		s = &Session{}
+		sessions[id] = s
+	}
+	s.lastUsed = now
+	return s
+}
+
+func editSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Accept")
+
+	haskellFile, err := getHaskellFile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	input, err := translator.Translate(haskellFile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s := getSession(r.PathValue("id"))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(input.ParsingErrors) != 0 || len(input.ImportErrors) != 0 {
+		s.state.Reset()
+		inv := inventory.NewInventory(input)
+		if len(inv.ParsingErrors) != 0 {
+			handleParsingError(w, inv)
+		} else {
+			handleImportError(w, inv)
+		}
+		return
+	}
+
+	rechecked, reused := s.state.Edit(input)
+	response := SessionResponse{
+		Response:  makeCheckResponse(s.state.Inv, s.state.Localisation, haskellFile),
+		Rechecked: rechecked,
+		Reused:    reused,
+	}
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func closeSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	sessionsMu.Lock()
+	delete(sessions, r.PathValue("id"))
+	sessionsMu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,3 @@
+module Main where
+
+greeting n = "You are number " ++ n ++ 1
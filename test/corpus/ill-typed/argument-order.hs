@@ -0,0 +1,3 @@
+module Main where
+
+evens = filter [1, 2, 3, 4] (\x -> mod x 2 == 0)
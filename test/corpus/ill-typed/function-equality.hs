@@ -0,0 +1,3 @@
+module Main where
+
+same = id == not
@@ -0,0 +1,3 @@
+module Main where
+
+describe n = if n > 0 then "positive" else n
@@ -0,0 +1,4 @@
+module Main where
+
+count :: [a] -> Bool
+count xs = length xs
@@ -0,0 +1,5 @@
+module Main where
+
+a = not 1
+
+b = length 'x'
@@ -0,0 +1,8 @@
+module Main where
+
+double x = x + x
+
+average :: Float -> Float -> Float
+average a b = (a + b) / 2
+
+total = foldr (+) 0 [1, 2, 3]
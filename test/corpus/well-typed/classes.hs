@@ -0,0 +1,8 @@
+module Main where
+
+largest :: Ord a => [a] -> a
+largest xs = foldr max (head xs) xs
+
+same x y = x == y
+
+wrap x = Just x
@@ -0,0 +1,7 @@
+module Main where
+
+pairs xs = zip xs (tail xs)
+
+lengths = map length
+
+firstOr d xs = if length xs == 0 then d else head xs
@@ -0,0 +1,58 @@
+package translator
+
+import (
+	"encoding/json"
+	"goanna/inventory"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Address is where the Python translator (parser/web.py) listens. It can be
+// overridden with the GOANNA_TRANSLATOR environment variable.
+var Address = "http://localhost:8090"
+
+func init() {
+	if address, ok := os.LookupEnv("GOANNA_TRANSLATOR"); ok {
+		Address = address
+	}
+}
+
+// Translate sends a Haskell program to the translator and returns the
+// constraint rules it produced.
+func Translate(text string) (inventory.Input, error) {
+	resp, err := http.Post(
+		Address+"/translate",
+		"text/plain",
+		strings.NewReader(text))
+	if err != nil {
+		log.Printf("Error making HTTP request: %v", err)
+		return inventory.Input{}, err
+
+	}
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading response body: %v", err)
+		return inventory.Input{}, err
+	}
+	var input inventory.Input
+	err = json.Unmarshal(body, &input)
+	if err != nil {
+		log.Printf("Error unmarshalling JSON response: %v", err)
+		return inventory.Input{}, err
+	}
+	return input, nil
+}
+
+func Ready() bool {
+	resp, err := http.Get(Address + "/ping")
+	return err == nil && resp.StatusCode == 200
+}